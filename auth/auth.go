@@ -1,66 +1,104 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"maps"
 	"net/http"
 	"os"
-
-	//"strconv"
+	"strconv"
 	"time"
 
-	"github.com/ArtemHvozdov/tg-auth-bot/config"
+	"github.com/ArtemHvozdov/tg-auth-bot/logging"
+	"github.com/ArtemHvozdov/tg-auth-bot/metrics"
 	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+	"github.com/ArtemHvozdov/tg-auth-bot/tracing"
 
-	"github.com/ethereum/go-ethereum/common"
 	circuits "github.com/iden3/go-circuits/v2"
 	auth "github.com/iden3/go-iden3-auth/v2"
-	"github.com/iden3/go-iden3-auth/v2/loaders"
 	"github.com/iden3/go-iden3-auth/v2/pubsignals"
-	"github.com/iden3/go-iden3-auth/v2/state"
 	"github.com/iden3/iden3comm/v2/protocol"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// LoadConfig loads the configuration
-// go func() {
-var	cfg = config.LoadConfig()
-// }
+// logger is replaced by main with SetLogger
+var logger = slog.Default()
+
+// SetLogger sets the logger of the auth package
+func SetLogger(l *slog.Logger) {
+	logger = l.With("component", "auth")
+}
 
 const VerificationKeyPath = "verification_key.json"
 
+// clock returns the current time, tests replace it
+var clock = time.Now
+
 type KeyLoader struct {
 	Dir string
 }
 
-type AuthRequestData struct {
-	Request protocol.AuthorizationRequestMessage
-	UserID  int64
-}
-
 // Load keys from embedded FS
 func (m KeyLoader) Load(id circuits.CircuitID) ([]byte, error) {
 	return os.ReadFile(fmt.Sprintf("%s/%v/%s", m.Dir, id, VerificationKeyPath))
 }
 
-// Map for storing authentication requests
-var requestMap = make(map[string]AuthRequestData)
+// requestQuery returns the query of the params for a request made at now. The birthday limit of an age check
+// is computed here from the minimum age, so it follows the date instead of the day the params were saved.
+func requestQuery(params storage_db.VerificationParams, now time.Time) map[string]interface{} {
+	if params.MinAge <= 0 {
+		return params.Query
+	}
+
+	// Members born before this date are at least MinAge years old today
+	cutoff := now.UTC().AddDate(-params.MinAge, 0, 1)
+	birthdayLimit, _ := strconv.Atoi(cutoff.Format("20060102"))
+
+	// The params are shared with the other requests, the maps are copied before the limit is set
+	subject := map[string]interface{}{}
+	if querySubject, ok := params.Query["credentialSubject"].(map[string]interface{}); ok {
+		maps.Copy(subject, querySubject)
+	}
+	subject["birthday"] = map[string]interface{}{
+		"$lt": birthdayLimit,
+	}
+
+	query := maps.Clone(params.Query)
+	if query == nil {
+		query = map[string]interface{}{}
+	}
+	query["credentialSubject"] = subject
+	return query
+}
+
+// GenerateAuthRequest generates a new authentication request of the tenant and stores it in a new session
+// for the join of the user at joinedAt
+func (t *Tenant) GenerateAuthRequest(ctx context.Context, userID int64, groupID int64, joinedAt time.Time, params storage_db.VerificationParams) (Session, error) {
+	ctx, span := tracing.Tracer.Start(ctx, "auth.GenerateAuthRequest", trace.WithAttributes(
+		tracing.AttrGroupID.Int64(groupID),
+		attribute.String("verification.circuit_id", params.CircuitID),
+	))
+	defer span.End()
 
-// GenerateAuthRequest generates a new authentication request and returns it as a JSON object
-func GenerateAuthRequest(userID int64, params storage_db.VerificationParams) ([]byte, error) {
-	rURL := cfg.NgrokURL
-	sessionID := "1"                                     // Use unique session IDs in production
-	//sessionID := strconv.Itoa(int(time.Now().UnixNano()))
+	sessionID, err := newSessionID()
+	if err != nil {
+		tracing.RecordError(span, err)
+		return Session{}, fmt.Errorf("error generating session ID: %w", err)
+	}
+	span.SetAttributes(tracing.AttrSessionID.String(sessionID))
 
-	log.Println("Session ID in Generate Auth Request:", sessionID)
-	CallbackURL := "/api/callback"
-	Audience := "did:polygonid:polygon:amoy:2qQ68JkRcf3xrHPQPWZei3YeVzHPP58wYNxx2mEouR"
+	log := logger.With("bot", t.Name, "session_id", sessionID, "group_id", groupID, "user_id", userID)
+	Audience := t.audience()
 
 	// Forming a URI for callback
-	uri := fmt.Sprintf("%s%s?sessionId=%s", rURL, CallbackURL, sessionID)
+	uri := t.callbackURL(sessionID)
 
-	log.Println("URI:", uri)
+	log.Debug("Callback URI", "uri", uri)
 
 	// Create an authorization request
 	var request protocol.AuthorizationRequestMessage = auth.CreateAuthorizationRequest("test flow", Audience, uri)
@@ -81,181 +119,194 @@ func GenerateAuthRequest(userID int64, params storage_db.VerificationParams) ([]
 	// }
 
 
+	now := clock()
 	mtpProofRequest.ID = params.ID
 	mtpProofRequest.CircuitID = params.CircuitID
-	mtpProofRequest.Query = params.Query
+	mtpProofRequest.Query = requestQuery(params, now)
 
 	request.Body.Scope = append(request.Body.Scope, mtpProofRequest)
 
 
-	// Store auth request in map associated with session ID
-	// requestMap[strconv.Itoa(sessionID)] = request
-
-	requestMap[sessionID] = AuthRequestData{
-        Request: request,
-        UserID:  userID,
-    }
+	// Store auth request in the session
+	session := &Session{
+		ID:        sessionID,
+		UserID:    userID,
+		GroupID:   groupID,
+		Status:    SessionPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Request:   request,
+		SpanContext: trace.SpanContextFromContext(ctx),
+		Tenant:    t,
+		JoinedAt:  joinedAt,
+	}
+	saveSession(session)
 
-	log.Println("reques map:", requestMap)
-	
-	// print request
-	fmt.Println(request)
-	
-	msgBytes, _ := json.Marshal(request)
+	// The request itself holds the query of the group, only its ID is logged
+	log.Info("Auth request generated", "auth_request_id", request.ID, "circuit_id", params.CircuitID)
 
-	// Returning a JSON object
-	return msgBytes, nil
+	return *session, nil
 }
 
 
-// Callback handles the callback from iden3
-func Callback(w http.ResponseWriter, r *http.Request) {
-	log.Println("Callback received")
+// Callback handles the callback from iden3 for the sessions of the tenant
+func (t *Tenant) Callback(w http.ResponseWriter, r *http.Request) {
 	sessionID := r.URL.Query().Get("sessionId")
+	log := logging.FromContext(r.Context(), logger).With("bot", t.Name, "session_id", sessionID)
+	log.Info("Callback received")
 
-	log.Println("Session ID in Cal:", sessionID)
+	start := time.Now()
+	result := "error"
+	defer func() {
+		metrics.CallbackDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	}()
 
 	tokenBytes, err := io.ReadAll(r.Body)
 	if err != nil {
-		log.Println("Error reading token from request body:", err)
+		log.Warn("Error reading token from request body", "error", err)
+		metrics.VerificationFailures.WithLabelValues("unknown", metrics.ReasonBadRequest).Inc()
 		http.Error(w, "Bad request", http.StatusBadRequest)
 		return
 	}
 
-	// Conevrting the token to a string
+	// Conevrting the token to a string, it's never logged
 	tokenStr := string(tokenBytes)
-	if tokenStr != "" {
-		log.Println("Token string there is. All is OK!")
-	}
-	//log.Println("Token string:", tokenStr)
-
-	
-	ethURL := fmt.Sprintf("https://polygon-amoy.infura.io/v3/%s", cfg.InfuraKey)
-	log.Println("ETH URL:", ethURL)
-	contractAddress := "0x1a4cC30f2aA0377b0c3bc9848766D90cb4404124"
-	resolverPrefix := "polygon:amoy"
-	
-	//keyDIR := "./keys"
+	log.Debug("Token received", "token_length", len(tokenStr))
 
-	log.Println("Callback func: map request:", requestMap)
+
+	//keyDIR := "./keys"
 
 	// Receiving authRequest by sessionID
-	authRequest, ok := requestMap[sessionID]
+	authRequest, ok := t.GetSession(sessionID)
 	if !ok {
-		log.Println("Session ID not found")
+		log.Warn("Session not found")
+		metrics.VerificationFailures.WithLabelValues("unknown", metrics.ReasonSessionNotFound).Inc()
 		http.Error(w, "Session not found", http.StatusNotFound)
 		return
 	}
 
 	userID := authRequest.UserID
-
-	//verificationKeyLoader := &KeyLoader{Dir: keyDIR}
-	resolver := state.ETHResolver{
-		RPCUrl:          ethURL,
-		ContractAddress: common.HexToAddress(contractAddress),
-	}
-
-	resolverPrivado := state.ETHResolver{
-		RPCUrl:          "https://rpc-mainnet.privado.id",
-		ContractAddress: common.HexToAddress("0x975556428F077dB5877Ea2474D783D6C69233742"),
+	group := metrics.Group(authRequest.GroupID)
+	log = log.With("group_id", authRequest.GroupID, "user_id", userID)
+
+	// A session is verified once, a replayed or concurrent token must not verify the user again
+	if !claimSession(sessionID) {
+		log.Warn("Callback for a finished session rejected", "status", authRequest.Status)
+		metrics.VerificationFailures.WithLabelValues(group, metrics.ReasonSessionFinal).Inc()
+		http.Error(w, "Session already finished", http.StatusConflict)
+		return
 	}
 
-	resolvers := map[string]pubsignals.StateResolver{
-		resolverPrefix: resolver,
-		"privado:main": resolverPrivado, 
-	}
+	// The wallet doesn't send a trace context, the callback continues the trace of the session
+	ctx, span := tracing.Tracer.Start(
+		trace.ContextWithRemoteSpanContext(r.Context(), authRequest.SpanContext),
+		"auth.Callback",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(tracing.AttrSessionID.String(sessionID), tracing.AttrGroupID.Int64(authRequest.GroupID)),
+	)
+	defer span.End()
 
-  //verifier, err := auth.NewVerifier(verificationKeyLoader, resolvers, auth.WithIPFSGateway("https://ipfs.io"))
-	verifier, err := auth.NewVerifier(loaders.NewEmbeddedKeyLoader(), resolvers)
+	//verificationKeyLoader := &KeyLoader{Dir: keyDIR}
+	// The callback keeps this snapshot of the verifier even if the config is reloaded meanwhile
+	snapshot, err := getSnapshot()
 	if err != nil {
-		log.Println("Error creating verifier:", err)
+		log.Error("Error creating verifier", "error", err)
+		tracing.RecordError(span, err)
+		setSessionStatus(sessionID, SessionFailed, "internal error")
+		metrics.VerificationFailures.WithLabelValues(group, metrics.ReasonInternalError).Inc()
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 
 	// Performing verification
-	authResponse, err := verifier.FullVerify(
-		r.Context(),
+	verifyStart := time.Now()
+	verifyCtx, verifySpan := tracing.Tracer.Start(ctx, "auth.FullVerify")
+	authResponse, err := snapshot.verifier.FullVerify(
+		verifyCtx,
 		string(tokenBytes),
 		authRequest.Request,
 		pubsignals.WithAcceptedStateTransitionDelay(time.Minute*5),
 	)
+	verifyResult := "verified"
 	if err != nil {
-		log.Println("Verification failed:", err)
-
-		// Getting the user using the GetUser method
-		_, err := storage_db.GetUser(userID)
-		if err == nil {
-			// Update user status via UpdateField
-			storage_db.UpdateField(userID, func(user *storage_db.UserVerification) {
-				user.IsPending = false
-				user.Verified = false
-			})
+		verifyResult = "failed"
+		tracing.RecordError(verifySpan, err)
+	}
+	verifySpan.End()
+	metrics.FullVerifyDuration.WithLabelValues(verifyResult).Observe(time.Since(verifyStart).Seconds())
+
+	if err != nil {
+		log.Info("Verification failed", "error", err)
+		setSessionStatus(sessionID, SessionFailed, "proof verification failed")
+		metrics.VerificationFailures.WithLabelValues(group, metrics.ReasonProofFailed).Inc()
+		result = "failed"
+		span.SetAttributes(attribute.String("verification.result", result))
+
+		// Only the join the session was started for fails, a later join of the user keeps its own verification
+		err := t.Store.UpdatePendingUser(ctx, userID, authRequest.GroupID, authRequest.JoinedAt, func(user *storage_db.UserVerification) {
+			user.IsPending = false
+			user.Verified = false
+		})
+		if err != nil && !errors.Is(err, storage_db.ErrStaleUser) && !errors.Is(err, storage_db.ErrNotFound) {
+			log.Error("Error updating user", "error", err)
 		}
 
 		http.Error(w, "Verification failed", http.StatusForbidden)
 		return
 	}
 
-	// Update the user status if verification is successful
-	userData, err := storage_db.GetUser(userID)
-	if err == nil {
-		userName := userData.Username
-		userAuthGroupID := userData.GroupID
+	setSessionStatus(sessionID, SessionVerified, "")
+	metrics.VerificationSuccesses.WithLabelValues(group).Inc()
+	result = "verified"
+	span.SetAttributes(attribute.String("verification.result", result))
+
+	// The user record is shared by the verifications of the user, it may already hold a join of another group.
+	// The verification is recorded for the group of the session, the record only if it still holds that join.
+	userAuthGroupID := authRequest.GroupID
+	userData, err := t.Store.GetUser(userID)
+	if err != nil || !userData.IsJoin(userAuthGroupID, authRequest.JoinedAt) {
+		userData = &storage_db.UserVerification{UserID: userID}
+	}
 
-		typeVerification, err := storage_db.GetVerificationType(userAuthGroupID)
-		if err != nil {
-			log.Println("Error getting verification type from database:", err)
-		}
+	typeVerification, err := t.Store.GetVerificationType(userAuthGroupID)
+	if err != nil {
+		log.Error("Error getting verification type from database", "error", err)
+	}
 
-		if userData.Role == "admin" {
-			storage_db.AddVerifiedUser(userAuthGroupID, userID, userName, tokenStr, typeVerification, tokenStr)
-		} else {
-			storage_db.AddVerifiedUser(userAuthGroupID, userID, userName, tokenStr, typeVerification, "")
-		}
-		log.Printf("User @%s (ID: %d) successfully verified via callback.", userData.Username, userID)
-		
-		storage_db.UpdateField(userID, func(user *storage_db.UserVerification) {
-			user.IsPending = false
-			user.Verified = true
-		})
+	if userData.Role == "admin" {
+		t.Store.AddVerifiedUser(userAuthGroupID, userID, userData.Username, tokenStr, typeVerification, tokenStr)
+	} else {
+		t.Store.AddVerifiedUser(userAuthGroupID, userID, userData.Username, tokenStr, typeVerification, "")
+	}
+	log.Info("User successfully verified via callback", "username", userData.Username)
+
+	err = t.Store.UpdatePendingUser(ctx, userID, userAuthGroupID, authRequest.JoinedAt, func(user *storage_db.UserVerification) {
+		user.IsPending = false
+		user.Verified = true
+	})
+	if errors.Is(err, storage_db.ErrStaleUser) || errors.Is(err, storage_db.ErrNotFound) {
+		log.Info("User record holds another join, the verification is recorded for the group only")
+	} else if err != nil {
+		log.Error("Error updating user", "error", err)
 	}
 
 	// Response to request with verification result
 	responseBytes, err := json.Marshal(authResponse)
 	if err != nil {
-		log.Println("Error serializing auth response:", err)
+		log.Error("Error serializing auth response", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	if responseBytes == nil {
-		log.Println("Response is empty")
+		log.Error("Response is empty")
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
-	} else {
-		log.Println("Response is here")
+		return
 	}
 
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	w.Write(responseBytes)
-	log.Println("Verification passed")
-
-
-	updatedUser, err := storage_db.GetUser(userID)
-	if err != nil {
-		log.Println("Error getting user from database:", err)
-	}
-
-	log.Println("Auth pack logs (Callback func): Updated user:")
-	log.Println("Auth pack logs (Callback func): User name:", updatedUser.Username)
-	log.Println("Auth pack logs (Callback func): Is Pending:", updatedUser.IsPending)
-	log.Println("Auth pack logs (Callback func): Verified:", updatedUser.Verified)
-	log.Println("Auth pack logs (Callback func): User role:", updatedUser.Role)
+	log.Info("Verification passed", "duration", time.Since(start))
 }
-
-
-func Home(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(w, "Server is running. Welcome to the home page!")
-}
\ No newline at end of file
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+)
+
+func TestAgeLimitFollowsClock(t *testing.T) {
+	oldClock := clock
+	t.Cleanup(func() { clock = oldClock })
+
+	params := storage_db.VerificationParams{
+		CircuitID: "credentialAtomicQuerySigV2",
+		ID:        1,
+		Query:     map[string]interface{}{"type": "KYCAgeCredential"},
+		MinAge:    18,
+	}
+	tenant := &Tenant{DID: "did:test"}
+
+	tests := []struct {
+		now       time.Time
+		wantLimit int // members born before it are 18
+	}{
+		{time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC), 20080311},
+		{time.Date(2027, 3, 10, 12, 0, 0, 0, time.UTC), 20090311},
+		{time.Date(2027, 12, 31, 23, 0, 0, 0, time.UTC), 20100101},
+	}
+
+	for _, tt := range tests {
+		clock = func() time.Time { return tt.now }
+
+		session, err := tenant.GenerateAuthRequest(context.Background(), 1, -1, time.Time{}, params)
+		if err != nil {
+			t.Fatalf("GenerateAuthRequest: %v", err)
+		}
+		sessionsMutex.Lock()
+		delete(sessions, session.ID)
+		sessionsMutex.Unlock()
+
+		query := session.Request.Body.Scope[0].Query
+		subject, _ := query["credentialSubject"].(map[string]interface{})
+		birthday, _ := subject["birthday"].(map[string]interface{})
+		if limit := birthday["$lt"]; limit != tt.wantLimit {
+			t.Errorf("birthday limit on %s = %v, want %d", tt.now.Format(time.DateOnly), limit, tt.wantLimit)
+		}
+	}
+
+	// The limit is set on a copy, the saved params keep only the age
+	if _, ok := params.Query["credentialSubject"]; ok {
+		t.Errorf("params query changed to %v", params.Query)
+	}
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/metrics"
+
+	"github.com/iden3/go-iden3-auth/v2/pubsignals"
+	"github.com/iden3/go-iden3-auth/v2/state"
+)
+
+// countingResolver counts the errors of a state resolver
+type countingResolver struct {
+	name     string
+	resolver pubsignals.StateResolver
+}
+
+func (r countingResolver) Resolve(ctx context.Context, id *big.Int, s *big.Int) (*state.ResolvedState, error) {
+	resolved, err := r.resolver.Resolve(ctx, id, s)
+	if err != nil {
+		metrics.ResolverErrors.WithLabelValues(r.name).Inc()
+	}
+	return resolved, err
+}
+
+func (r countingResolver) ResolveGlobalRoot(ctx context.Context, s *big.Int) (*state.ResolvedState, error) {
+	resolved, err := r.resolver.ResolveGlobalRoot(ctx, s)
+	if err != nil {
+		metrics.ResolverErrors.WithLabelValues(r.name).Inc()
+	}
+	return resolved, err
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/iden3/iden3comm/v2/protocol"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Session statuses, they follow the IsPending/Verified states of the user in the bot
+const (
+	SessionPending  = "pending"
+	SessionVerified = "verified"
+	SessionFailed   = "failed"
+)
+
+// Session is one verification attempt of a user
+type Session struct {
+	ID        string                               `json:"id"`
+	UserID    int64                                `json:"userId"`
+	GroupID   int64                                `json:"groupId"`
+	Status    string                               `json:"status"`
+	Reason    string                               `json:"reason,omitempty"`
+	CreatedAt time.Time                            `json:"createdAt"`
+	UpdatedAt time.Time                            `json:"updatedAt"`
+	Request   protocol.AuthorizationRequestMessage `json:"-"`
+	// SpanContext of the auth request generation, the callback continues its trace
+	SpanContext trace.SpanContext `json:"-"`
+	// Tenant is the bot the user verifies for
+	Tenant *Tenant `json:"-"`
+	// JoinedAt of the join the session verifies, the user record is only updated while it holds this join
+	JoinedAt time.Time `json:"-"`
+
+	// claimed is set by the callback that verifies the proof, a concurrent callback must not verify it again
+	claimed bool
+}
+
+var (
+	sessions      = make(map[string]*Session)
+	sessionsMutex sync.RWMutex
+
+	// Subscribers receive every status change of a session
+	subscribers = make(map[string]map[chan Session]struct{})
+)
+
+// newSessionID returns a random, unguessable session ID
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// saveSession stores a new session and forgets the expired ones
+func saveSession(session *Session) {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	for id, existing := range sessions {
+		if time.Since(existing.UpdatedAt) > currentConfig().Verification.SessionTTL {
+			delete(sessions, id)
+		}
+	}
+
+	sessions[session.ID] = session
+}
+
+// GetSession returns a copy of the session with the given ID
+func GetSession(sessionID string) (Session, bool) {
+	sessionsMutex.RLock()
+	defer sessionsMutex.RUnlock()
+
+	session, ok := sessions[sessionID]
+	if !ok {
+		return Session{}, false
+	}
+	return *session, true
+}
+
+// setSessionStatus records the result of the verification
+func setSessionStatus(sessionID string, status string, reason string) {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	session, ok := sessions[sessionID]
+	if !ok {
+		return
+	}
+
+	session.Status = status
+	session.Reason = reason
+	session.UpdatedAt = time.Now()
+
+	// Notify subscribers without blocking, a slow subscriber only misses intermediate states
+	for ch := range subscribers[sessionID] {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- *session
+	}
+}
+
+// claimSession lets only one callback verify the pending session,
+// it reports false if the session is unknown, finished or claimed by another callback
+func claimSession(sessionID string) bool {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	session, ok := sessions[sessionID]
+	if !ok || session.IsFinal() || session.claimed {
+		return false
+	}
+	session.claimed = true
+	return true
+}
+
+// IsFinal reports whether the session will not change anymore
+func (s Session) IsFinal() bool {
+	return s.Status != SessionPending
+}
+
+// SubscribeSession returns a channel with the status changes of the session and a function to unsubscribe
+func SubscribeSession(sessionID string) (<-chan Session, func()) {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	ch := make(chan Session, 1)
+	if subscribers[sessionID] == nil {
+		subscribers[sessionID] = make(map[chan Session]struct{})
+	}
+	subscribers[sessionID][ch] = struct{}{}
+
+	return ch, func() {
+		sessionsMutex.Lock()
+		defer sessionsMutex.Unlock()
+
+		delete(subscribers[sessionID], ch)
+		if len(subscribers[sessionID]) == 0 {
+			delete(subscribers, sessionID)
+		}
+	}
+}
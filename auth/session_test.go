@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClaimSession(t *testing.T) {
+	sessionsMutex.Lock()
+	sessions["pending"] = &Session{ID: "pending", Status: SessionPending}
+	sessions["verified"] = &Session{ID: "verified", Status: SessionVerified}
+	sessionsMutex.Unlock()
+	t.Cleanup(func() {
+		sessionsMutex.Lock()
+		delete(sessions, "pending")
+		delete(sessions, "verified")
+		sessionsMutex.Unlock()
+	})
+
+	// Concurrent callbacks of one session, only one of them verifies the proof
+	var claimed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if claimSession("pending") {
+				claimed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := claimed.Load(); got != 1 {
+		t.Errorf("pending session claimed %d times, want 1", got)
+	}
+	if claimSession("verified") {
+		t.Error("finished session claimed")
+	}
+	if claimSession("unknown") {
+		t.Error("unknown session claimed")
+	}
+}
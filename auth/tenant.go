@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+)
+
+// Tenant is one of the bots hosted by the process. The verifier is shared, the tenant chooses the audience
+// of its auth requests, the path prefix of its URLs and the storage namespace the results are written to.
+type Tenant struct {
+	Name       string
+	DID        string // verifier.did of the config if empty
+	PathPrefix string // e.g. /brand-a, empty for the root
+	Store      *storage_db.Store
+}
+
+// audience returns the verifier DID of the auth requests of the tenant
+func (t *Tenant) audience() string {
+	if t.DID != "" {
+		return t.DID
+	}
+	return currentConfig().Verifier.DID
+}
+
+// baseURL returns the public URL of the routes of the tenant
+func (t *Tenant) baseURL() string {
+	return strings.TrimRight(currentConfig().PublicURL, "/") + t.PathPrefix
+}
+
+// VerificationPageURL returns the URL of the web page that shows the QR code for the session
+func (t *Tenant) VerificationPageURL(sessionID string) string {
+	return fmt.Sprintf("%s/verify/%s", t.baseURL(), url.PathEscape(sessionID))
+}
+
+// SignInURL returns the URL the wallets fetch the authorization request of the session from
+func (t *Tenant) SignInURL(sessionID string) string {
+	return fmt.Sprintf("%s/api/sign-in/%s", t.baseURL(), url.PathEscape(sessionID))
+}
+
+// callbackURL returns the URL the wallets post the proof of the session to
+func (t *Tenant) callbackURL(sessionID string) string {
+	return fmt.Sprintf("%s/api/callback?sessionId=%s", t.baseURL(), url.QueryEscape(sessionID))
+}
+
+// WalletDeepLink returns the link that opens the request in the Privado ID web wallet
+func (t *Tenant) WalletDeepLink(sessionID string) string {
+	return "https://wallet.privado.id/#request_uri=" + url.QueryEscape(t.SignInURL(sessionID))
+}
+
+// QRCodePayload returns the iden3comm URI that mobile wallets read from a QR code
+func (t *Tenant) QRCodePayload(sessionID string) string {
+	return "iden3comm://?request_uri=" + url.QueryEscape(t.SignInURL(sessionID))
+}
+
+// GetSession returns a copy of the session with the given ID if it belongs to the tenant
+func (t *Tenant) GetSession(sessionID string) (Session, bool) {
+	session, ok := GetSession(sessionID)
+	if !ok || session.Tenant != t {
+		return Session{}, false
+	}
+	return session, true
+}
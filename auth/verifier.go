@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/config"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	auth "github.com/iden3/go-iden3-auth/v2"
+	"github.com/iden3/go-iden3-auth/v2/loaders"
+	"github.com/iden3/go-iden3-auth/v2/pubsignals"
+	"github.com/iden3/go-iden3-auth/v2/state"
+)
+
+// snapshot is the config of the auth package with the verifier built from it.
+// A reload swaps the whole snapshot, a callback keeps the one it started with until it's done.
+type snapshot struct {
+	cfg      config.Config
+	verifier *auth.Verifier
+}
+
+// current is the snapshot used by new requests, it's set by Configure
+var current atomic.Pointer[snapshot]
+
+// Configure builds the verifier with the resolvers of the config and swaps it in together with the public URL,
+// the verifier DID and the session TTL. It's called on start and on every reload, an error keeps the previous setup.
+func Configure(c config.Config) error {
+	resolvers := map[string]pubsignals.StateResolver{}
+	for _, r := range c.Verifier.Resolvers {
+		resolvers[r.Name] = countingResolver{
+			name: r.Name,
+			resolver: state.ETHResolver{
+				RPCUrl:          r.RPCURL,
+				ContractAddress: common.HexToAddress(r.ContractAddress),
+			},
+		}
+	}
+
+	verifier, err := auth.NewVerifier(loaders.NewEmbeddedKeyLoader(), resolvers)
+	if err != nil {
+		return fmt.Errorf("error creating verifier: %w", err)
+	}
+
+	current.Store(&snapshot{cfg: c, verifier: verifier})
+	return nil
+}
+
+// currentConfig returns the config of the current snapshot
+func currentConfig() config.Config {
+	if s := current.Load(); s != nil {
+		return s.cfg
+	}
+	return config.Config{}
+}
+
+// getSnapshot returns the current snapshot, the auth package can't be used before Configure
+func getSnapshot() (*snapshot, error) {
+	s := current.Load()
+	if s == nil {
+		return nil, errors.New("auth is not configured")
+	}
+	return s, nil
+}
+
+// CheckVerifier returns an error if the verifier isn't set up
+func CheckVerifier() error {
+	_, err := getSnapshot()
+	return err
+}
+
+// Resolvers returns the state resolvers of the current config
+func Resolvers() []config.ResolverConfig {
+	return currentConfig().Verifier.Resolvers
+}
+
+// CheckResolver checks that the RPC node of the resolver answers
+func CheckResolver(ctx context.Context, name string) error {
+	s, err := getSnapshot()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range s.cfg.Verifier.Resolvers {
+		if r.Name != name {
+			continue
+		}
+
+		client, err := ethclient.DialContext(ctx, r.RPCURL)
+		if err != nil {
+			return hideRPCURL(err)
+		}
+		defer client.Close()
+
+		_, err = client.BlockNumber(ctx)
+		return hideRPCURL(err)
+	}
+	return fmt.Errorf("unknown resolver %s", name)
+}
+
+// hideRPCURL removes the RPC URL from the error, the Infura URL contains the API key
+func hideRPCURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
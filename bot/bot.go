@@ -1,33 +1,89 @@
 package bot
  
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
 	"time"
 
+	"github.com/ArtemHvozdov/tg-auth-bot/auth"
 	"github.com/ArtemHvozdov/tg-auth-bot/bot/handlers"
 	"github.com/ArtemHvozdov/tg-auth-bot/config"
-	// "github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+	"github.com/ArtemHvozdov/tg-auth-bot/metrics"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+	"github.com/ArtemHvozdov/tg-auth-bot/web"
 
 	//"github.com/ArtemHvozdov/tg-auth-bot/storage"
 
 	"gopkg.in/telebot.v3"
 )
 
-var InstanceBot *telebot.Bot
+// Instance is one of the bots hosted by the process
+type Instance struct {
+	Bot    *telebot.Bot
+	Tenant *auth.Tenant
+
+	// eventsDone is closed when the store change listener has flushed all events
+	eventsDone <-chan struct{}
+	// started and stopped track the poller so it is stopped only once and only if it runs
+	started atomic.Bool
+	stopped atomic.Bool
+
+	logger *slog.Logger
+}
+
+// logger is replaced by main with SetLogger
+var logger = slog.Default()
+
+// SetLogger sets the logger of the bot
+func SetLogger(l *slog.Logger) {
+	logger = l.With("component", "bot")
+}
+
+// NewBot creates one of the configured bots, registers its commands and handlers and adds its routes
+// to the web server. The bot keeps its data in the store.
+func NewBot(cfg config.Config, botCfg config.BotConfig, store *storage_db.Store) (*Instance, error) {
+	instance := &Instance{
+		Tenant: &auth.Tenant{
+			Name:       botCfg.Name,
+			DID:        botCfg.VerifierDID,
+			PathPrefix: botCfg.PathPrefix,
+			Store:      store,
+		},
+		logger: logger.With("bot", botCfg.Name),
+	}
+	logger := instance.logger
 
-// StartBot runs Telegram-бота
-func StartBot(cfg config.Config) error {
 	pref := telebot.Settings{
-		Token:  cfg.TelegramToken,
-		Poller: &telebot.LongPoller{Timeout: 10 * time.Second},
+		Token:  botCfg.TelegramToken,
+		Poller: instance.newPoller(cfg),
+		// The same timeout as the default client of telebot, the transport counts the failed API calls
+		Client: &http.Client{Timeout: time.Minute, Transport: metrics.TelegramTransport{}},
+		// Errors returned by the handlers, telebot would print them with the standard logger
+		OnError: func(err error, c telebot.Context) {
+			if c != nil {
+				logger.Error("Handler error", "update_id", c.Update().ID, "error", err)
+				return
+			}
+			logger.Error("Bot error", "error", err)
+		},
 	}
 
 	bot, err := telebot.NewBot(pref)
 	if err != nil {
-		return fmt.Errorf("error creating bot: %v", err)
+		return nil, fmt.Errorf("error creating bot: %v", err)
+	}
+
+	if err := instance.prepareUpdateMode(bot, cfg); err != nil {
+		return nil, err
 	}
 
+	// The handlers of the bot use the store and the verification pages of its tenant
+	instance.Bot = bot
+	handlers.Register(bot, instance.Tenant)
+
 	bot.Use(AdminOnlyMiddleware(bot))
 
 	// Setting up commands
@@ -46,15 +102,23 @@ func StartBot(cfg config.Config) error {
 		{Text: "set_type_restriction", Description: "Set type restriction"},
 		{Text: "delete_all_verification_params", Description: "delete_all_verification_params"},
 		{Text: "delete_all_verified_users", Description: "delete_all_verified_users"},
+		{Text: "export_config", Description: "Export the group configuration as JSON"},
+		{Text: "import_config", Description: "Import a group configuration from JSON"},
+		{Text: "api_key", Description: "Get an API key for the admin REST API"},
+		{Text: "set_language", Description: "Set the default language of the group"},
+		{Text: "set_message", Description: "Customize the messages to new members"},
+		{Text: "invite_link", Description: "Get a link that gives verified users an invite link"},
+		{Text: "verify_link", Description: "Get a link that starts the verification of the group"},
 	})
 	if err != nil {
-		log.Printf("Failed to set bot commands: %v", err)
+		logger.Error("Failed to set bot commands", "error", err)
 	}
 
-	handlers.ListenForstorage_dbChanges(bot)
+	instance.eventsDone = handlers.ListenForstorage_dbChanges(bot)
 
 	// Handlers
 	bot.Handle(telebot.OnUserJoined, handlers.NewUserJoinedHandler(bot))
+	bot.Handle(telebot.OnChatJoinRequest, handlers.JoinRequestHandler(bot))
 	bot.Handle("/start", handlers.StartHandler(bot))
 	bot.Handle("/setup", handlers.SetupHandler(bot))
 	bot.Handle("/verify", handlers.VerifyHandler(bot))
@@ -67,6 +131,15 @@ func StartBot(cfg config.Config) error {
 	bot.Handle("/set_type_restriction", handlers.SetTypeRestrictionHandler(bot))
 	bot.Handle("/delete_all_verification_params", handlers.DeleteAllVerificationParamsHandler(bot))
 	bot.Handle("/delete_all_verified_users", handlers.DeleteAllVerifiedUsersHandler(bot))
+	bot.Handle("/export_config", handlers.ExportConfigHandler(bot))
+	bot.Handle("/import_config", handlers.ImportConfigHandler(bot))
+	bot.Handle("/api_key", handlers.APIKeyHandler(bot))
+	bot.Handle("/set_language", handlers.SetLanguageHandler(bot))
+	bot.Handle("/set_message", handlers.SetMessageHandler(bot))
+	bot.Handle("/invite_link", handlers.InviteLinkHandler(bot))
+	bot.Handle("/verify_link", handlers.VerifyLinkHandler(bot))
+
+	web.AddTenant(instance.Tenant, webAccess{bot: bot})
 
 
 		
@@ -84,11 +157,45 @@ func StartBot(cfg config.Config) error {
 		bot.Handle(messageType, handlers.UnifiedHandler(bot))
 	}
 
-	log.Println("Bot started...")
-	bot.Start()
+	return instance, nil
+}
+
+// Start runs Telegram-бота, it blocks until Stop is called
+func (i *Instance) Start() {
+	i.started.Store(true)
+	i.logger.Info("Bot started", "username", i.Bot.Me.Username)
+	i.Bot.Start()
+}
+
+// Stop stops the poller, updates that arrive via the webhook afterwards are rejected so Telegram retries them later
+func (i *Instance) Stop() {
+	if !i.started.Load() || !i.stopped.CompareAndSwap(false, true) {
+		return
+	}
+
+	i.logger.Info("Bot stopping")
+	i.Bot.Stop()
+	i.logger.Info("Bot stopped")
+}
+
+// StopTimeouts cancels the verification timeouts of the bot and waits for the ones being handled
+func (i *Instance) StopTimeouts(ctx context.Context) error {
+	return handlers.StopTimeouts(ctx, i.Bot)
+}
 
-	// log.Println("Default user storage:", storage.UserStore)
-	return nil
+// WaitForEvents waits until the store change listener has handled the pending events.
+// storage_db.CloseChanges must be called first.
+func (i *Instance) WaitForEvents(ctx context.Context) error {
+	if i.eventsDone == nil {
+		return nil
+	}
+
+	select {
+	case <-i.eventsDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // AdminOnlyMiddleware checks the user's role and allows access only to administrators
@@ -108,17 +215,17 @@ func AdminOnlyMiddleware(bot *telebot.Bot) telebot.MiddlewareFunc {
                 // Checking the user role
                 member, err := bot.ChatMemberOf(&telebot.Chat{ID: chatID}, &telebot.User{ID: userID})
                 if err != nil {
-                    log.Printf("Error fetching user's role: %v", err)
+                    logger.Error("Error fetching user's role", "group_id", chatID, "user_id", userID, "error", err)
                     return nil // Ignore the error and do not execute the command
                 }
 
-                // If the user is not an administrator
-                if member.Role != "administrator" && member.Role != "creator" {
+                // If the user is not an administrator, the bot operators are admins of every group
+                if member.Role != "administrator" && member.Role != "creator" && !handlers.IsBotAdmin(userID) {
                     // Delete the user's message with the command after 1 second
                     time.AfterFunc(1*time.Second, func() {
                         err := bot.Delete(c.Message())
                         if err != nil {
-                            log.Printf("Error deleting message: %v", err)
+                            logger.Warn("Error deleting message", "group_id", chatID, "error", err)
                         }
                     })
                     return nil // Ignore the command
@@ -129,7 +236,7 @@ func AdminOnlyMiddleware(bot *telebot.Bot) telebot.MiddlewareFunc {
 					time.AfterFunc(1*time.Minute, func() {
 						err := bot.Delete(c.Message())
 						if err != nil {
-							log.Printf("Error deleting message: %v", err)
+							logger.Warn("Error deleting message", "group_id", chatID, "error", err)
 						}
 					})
 				}
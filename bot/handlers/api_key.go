@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+
+	"gopkg.in/telebot.v3"
+)
+
+// Handler for /api_key, "/api_key revoke" deletes the key
+func APIKeyHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	store := storeOf(bot)
+	return func(c telebot.Context) error {
+		userID := c.Sender().ID
+		lang := langOf(bot, c)
+
+		if strings.TrimSpace(c.Message().Payload) == "revoke" {
+			err := store.RevokeAPIKey(userID)
+			if errors.Is(err, storage_db.ErrNotFound) {
+				return c.Send(i18n.T(lang, "api_key.none"))
+			}
+			if err != nil {
+				loggerFor(c).Error("Error revoking API key", "error", err)
+				return c.Send(i18n.T(lang, "api_key.revoke_failed"))
+			}
+			return c.Send(i18n.T(lang, "api_key.revoked"))
+		}
+
+		groupChatID, ok := getAdminGroup(bot, c)
+		if !ok {
+			return nil
+		}
+
+		// The key replaces the previous one only once it's delivered, so a failed message doesn't lock the admin out
+		key, err := storage_db.NewAPIKey()
+		if err != nil {
+			loggerFor(c).Error("Error issuing API key", "group_id", groupChatID, "error", err)
+			return c.Send(i18n.T(lang, "api_key.create_failed"))
+		}
+
+		groupChatName := fmt.Sprint(groupChatID)
+		if chat, err := bot.ChatByID(groupChatID); err == nil && chat.Title != "" {
+			groupChatName = chat.Title
+		}
+
+		msg := i18n.T(lang, "api_key.created", html.EscapeString(groupChatName), key)
+
+		// Always send the key privately, it must not be posted in the group
+		sent, err := bot.Send(c.Sender(), msg, telebot.ModeHTML)
+		if err != nil {
+			loggerFor(c).Warn("Error sending API key", "group_id", groupChatID, "error", err)
+			return c.Send(i18n.T(lang, "api_key.send_failed"))
+		}
+
+		if err := store.SaveAPIKey(userID, groupChatID, key); err != nil {
+			loggerFor(c).Error("Error issuing API key", "group_id", groupChatID, "error", err)
+			// The key doesn't work, it must not stay in the chat
+			bot.Delete(sent)
+			return c.Send(i18n.T(lang, "api_key.create_failed"))
+		}
+
+		if c.Chat().Type != telebot.ChatPrivate {
+			return c.Send(i18n.T(lang, "api_key.sent"))
+		}
+		return nil
+	}
+}
+
+// botAdmins are the user IDs of the bot operators from the config
+var botAdmins atomic.Pointer[[]int64]
+
+// SetBotAdmins sets the bot operators, they manage every group like its admins. It's called again on reload.
+func SetBotAdmins(userIDs []int64) {
+	botAdmins.Store(&userIDs)
+}
+
+// IsBotAdmin checks if the user is a bot operator
+func IsBotAdmin(userID int64) bool {
+	admins := botAdmins.Load()
+	return admins != nil && slices.Contains(*admins, userID)
+}
+
+// IsGroupAdmin checks if the user is an administrator of the group, e.g. for the web server
+func IsGroupAdmin(bot *telebot.Bot, groupID int64, userID int64) bool {
+	return isAdmin(bot, groupID, userID)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+
+	"gopkg.in/telebot.v3"
+)
+
+// verifyPayloadPrefix starts the /start payload of the verification deep links
+const verifyPayloadPrefix = "verify_"
+
+// Length of the signature in the verification deep links, the payload of /start is limited to 64 characters
+const deepLinkSignatureLength = 16
+
+// signDeepLink signs the value with a key derived from the bot token, so the links of one bot aren't valid for another
+func signDeepLink(bot *telebot.Bot, value string) string {
+	key := sha256.Sum256([]byte("deeplink:" + bot.Token))
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:deepLinkSignatureLength]
+}
+
+// verifyDeepLink returns the link that opens the bot and starts the verification in the group
+func verifyDeepLink(bot *telebot.Bot, groupID int64) string {
+	value := strconv.FormatInt(groupID, 10)
+	return fmt.Sprintf("https://t.me/%s?start=%s%s_%s", bot.Me.Username, verifyPayloadPrefix, value, signDeepLink(bot, value))
+}
+
+// parseVerifyPayload returns the group of a verification deep link, the payload is without the prefix
+func parseVerifyPayload(bot *telebot.Bot, payload string) (int64, bool) {
+	value, signature, ok := strings.Cut(payload, "_")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signDeepLink(bot, value))) {
+		return 0, false
+	}
+
+	groupID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return groupID, true
+}
+
+// startVerifyLink starts the verification of the user who opened the verification deep link of a group
+func startVerifyLink(bot *telebot.Bot, c telebot.Context, payload string) error {
+	store := storeOf(bot)
+	userID := c.Sender().ID
+	log := loggerFor(c)
+
+	ctx, span := startVerifySpan(bot, c, userID)
+	defer span.End()
+
+	groupID, ok := parseVerifyPayload(bot, payload)
+	if !ok {
+		log.Info("Invalid verification deep link", "payload", payload)
+		return c.Send(i18n.T(langOf(bot, c), "deeplink.invalid"))
+	}
+
+	// The link only starts a verification the user is awaiting in its group
+	userData, err := store.GetUser(userID)
+	if err != nil || !userData.IsPending || userData.GroupID != groupID {
+		log.Info("User is not awaiting verification in the group of the link", "group_id", groupID)
+		lang := memberLang(store, c.Sender().LanguageCode, groupID)
+
+		groupName := fmt.Sprint(groupID)
+		if chat, err := bot.ChatByID(groupID); err == nil && chat.Title != "" {
+			groupName = chat.Title
+		}
+		return c.Send(i18n.T(lang, "deeplink.not_pending", groupName))
+	}
+
+	return startVerification(bot, c, ctx, userData)
+}
+
+// Handler for /verify_link, the link starts the verification of the group for its pending members
+func VerifyLinkHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	return func(c telebot.Context) error {
+		groupChatID, ok := getAdminGroup(bot, c)
+		if !ok {
+			return nil
+		}
+
+		return c.Send(i18n.T(langOf(bot, c), "deeplink.verify_link", verifyDeepLink(bot, groupChatID)))
+	}
+}
@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+
+	"gopkg.in/telebot.v3"
+)
+
+// Maximum size of an imported config document
+const maxConfigDocumentSize = 1 << 20
+
+// getAdminGroup returns the group the admin is working with and checks that the sender is its administrator
+func getAdminGroup(bot *telebot.Bot, c telebot.Context) (int64, bool) {
+	store := storeOf(bot)
+	userID := c.Sender().ID
+	lang := langOf(bot, c)
+	var groupChatID int64
+
+	// Determine where the handler was called: in a group or in a private chat
+	if c.Chat().Type == telebot.ChatPrivate {
+		groupID, err := store.GetIdGroupFromGroupSetupState(userID)
+		if err != nil || groupID == 0 {
+			loggerFor(c).Debug("Group not set up for user")
+			c.Send(i18n.T(lang, "common.no_group"))
+			return 0, false
+		}
+		groupChatID = groupID
+	} else {
+		groupChatID = c.Chat().ID
+	}
+
+	// Check if the user is an administrator of the group
+	if !isAdmin(bot, groupChatID, userID) {
+		c.Send(i18n.T(lang, "common.not_admin"))
+		return 0, false
+	}
+
+	return groupChatID, true
+}
+
+// Handler for /export_config
+func ExportConfigHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	store := storeOf(bot)
+	return func(c telebot.Context) error {
+		groupChatID, ok := getAdminGroup(bot, c)
+		if !ok {
+			return nil
+		}
+		lang := langOf(bot, c)
+
+		groupConfig, err := store.GetGroupConfigParams(groupChatID)
+		if err != nil {
+			loggerFor(c).Warn("Error fetching group configuration", "group_id", groupChatID, "error", err)
+			return c.Send(i18n.T(lang, "common.params_not_configured"))
+		}
+
+		data, err := json.MarshalIndent(groupConfig, "", "  ")
+		if err != nil {
+			loggerFor(c).Error("Failed to format config", "group_id", groupChatID, "error", err)
+			return c.Send(i18n.T(lang, "config.export_failed"))
+		}
+
+		groupChatName := fmt.Sprint(groupChatID)
+		if chat, err := bot.ChatByID(groupChatID); err == nil && chat.Title != "" {
+			groupChatName = chat.Title
+		}
+
+		// Always send the document privately, the config should not be posted in the group
+		file := &telebot.Document{
+			File:     telebot.FromReader(bytes.NewReader(data)),
+			FileName: fmt.Sprintf("group_%d_config.json", groupChatID),
+			Caption:  i18n.T(lang, "config.export_caption", groupChatName),
+		}
+
+		if _, err := bot.Send(c.Sender(), file); err != nil {
+			loggerFor(c).Error("Error sending config document", "group_id", groupChatID, "error", err)
+			return c.Send(i18n.T(lang, "config.send_failed"))
+		}
+
+		return nil
+	}
+}
+
+// Handler for /import_config
+func ImportConfigHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	return func(c telebot.Context) error {
+		if c.Chat().Type != telebot.ChatPrivate {
+			return c.Send(i18n.T(langOf(bot, c), "common.private_only"))
+		}
+
+		groupChatID, ok := getAdminGroup(bot, c)
+		if !ok {
+			return nil
+		}
+
+		// The JSON can be passed right after the command
+		if payload := strings.TrimSpace(c.Message().Payload); payload != "" {
+			return previewImportConfig(bot, c, groupChatID, []byte(payload))
+		}
+
+		setPendingInput(bot, c.Sender().ID, pendingInput{Kind: inputImportConfig, GroupID: groupChatID})
+		return c.Send(i18n.T(langOf(bot, c), "config.import_prompt"))
+	}
+}
+
+// handleImportConfigInput reads the config sent after /import_config
+func handleImportConfigInput(bot *telebot.Bot, c telebot.Context, groupChatID int64) error {
+	var data []byte
+	lang := langOf(bot, c)
+
+	if doc := c.Message().Document; doc != nil {
+		if doc.FileSize > maxConfigDocumentSize {
+			return c.Send(i18n.T(lang, "config.file_too_large"))
+		}
+
+		reader, err := bot.File(&doc.File)
+		if err != nil {
+			loggerFor(c).Error("Error downloading config document", "error", err)
+			return c.Send(i18n.T(lang, "config.download_failed"))
+		}
+		defer reader.Close()
+
+		data, err = io.ReadAll(io.LimitReader(reader, maxConfigDocumentSize))
+		if err != nil {
+			loggerFor(c).Error("Error reading config document", "error", err)
+			return c.Send(i18n.T(lang, "config.read_failed"))
+		}
+	} else {
+		data = []byte(c.Text())
+	}
+
+	return previewImportConfig(bot, c, groupChatID, data)
+}
+
+// previewImportConfig validates the config, shows the changes and asks for confirmation
+func previewImportConfig(bot *telebot.Bot, c telebot.Context, groupChatID int64, data []byte) error {
+	store := storeOf(bot)
+	lang := langOf(bot, c)
+	var newConfig storage_db.GroupVerificationConfig
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&newConfig); err != nil {
+		loggerFor(c).Info("Failed to parse config", "error", err)
+		return c.Send(i18n.T(lang, "config.invalid_json", err))
+	}
+
+	if len(newConfig.VerificationParams) == 0 {
+		newConfig.ActiveIndex = -1
+	}
+
+	if err := storage_db.ValidateGroupConfig(newConfig); err != nil {
+		return c.Send(i18n.T(lang, "config.invalid", err))
+	}
+
+	// A group without a config is compared against an empty one
+	currentConfig, err := store.GetGroupConfigParams(groupChatID)
+	if err != nil {
+		currentConfig = storage_db.GroupVerificationConfig{ActiveIndex: -1}
+	}
+
+	changes := describeConfigDiff(lang, currentConfig, newConfig)
+	if len(changes) == 0 {
+		return c.Send(i18n.T(lang, "config.unchanged"))
+	}
+
+	previewID := newPreviewID()
+	btnApply := telebot.InlineButton{
+		Text:   i18n.T(lang, "config.button_apply"),
+		Unique: fmt.Sprintf("import_apply_%d", groupChatID),
+		Data:   previewID,
+	}
+	btnCancel := telebot.InlineButton{
+		Text:   i18n.T(lang, "config.button_cancel"),
+		Unique: fmt.Sprintf("import_cancel_%d", groupChatID),
+		Data:   previewID,
+	}
+	keyboard := &telebot.ReplyMarkup{InlineKeyboard: [][]telebot.InlineButton{{btnApply, btnCancel}}}
+
+	bot.Handle(&btnApply, func(c telebot.Context) error {
+		if !isAdmin(bot, groupChatID, c.Sender().ID) {
+			return c.Respond(&telebot.CallbackResponse{Text: i18n.T(lang, "common.not_admin")})
+		}
+
+		// Only the latest preview of the group can be applied
+		if c.Data() != previewID {
+			c.Respond()
+			return c.Edit(i18n.T(lang, "config.outdated"))
+		}
+
+		if err := store.SaveGroupConfig(groupChatID, newConfig); err != nil {
+			loggerFor(c).Error("Error saving imported config", "group_id", groupChatID, "error", err)
+			return c.Respond(&telebot.CallbackResponse{Text: i18n.T(lang, "config.apply_failed")})
+		}
+
+		loggerFor(c).Info("Config imported", "group_id", groupChatID)
+		c.Respond()
+		return c.Edit(i18n.T(lang, "config.applied"))
+	})
+
+	bot.Handle(&btnCancel, func(c telebot.Context) error {
+		c.Respond()
+		return c.Edit(i18n.T(lang, "config.cancelled"))
+	})
+
+	return c.Send(i18n.T(lang, "config.preview", strings.Join(changes, "\n")), keyboard)
+}
+
+// describeConfigDiff lists the differences between two group configs in a human readable form
+func describeConfigDiff(lang string, oldConfig, newConfig storage_db.GroupVerificationConfig) []string {
+	var changes []string
+
+	count := len(oldConfig.VerificationParams)
+	if len(newConfig.VerificationParams) > count {
+		count = len(newConfig.VerificationParams)
+	}
+
+	for i := 0; i < count; i++ {
+		switch {
+		case i >= len(oldConfig.VerificationParams):
+			changes = append(changes, i18n.T(lang, "config.param_added", i+1, newConfig.VerificationParams[i].DisplayName()))
+		case i >= len(newConfig.VerificationParams):
+			changes = append(changes, i18n.T(lang, "config.param_removed", i+1, oldConfig.VerificationParams[i].DisplayName()))
+		case !reflect.DeepEqual(normalizeParams(oldConfig.VerificationParams[i]), normalizeParams(newConfig.VerificationParams[i])):
+			changes = append(changes, i18n.T(lang, "config.param_changed", i+1, oldConfig.VerificationParams[i].DisplayName(), newConfig.VerificationParams[i].DisplayName()))
+		}
+	}
+
+	if oldConfig.ActiveIndex != newConfig.ActiveIndex {
+		changes = append(changes, i18n.T(lang, "config.active_changed", activeIndexLabel(lang, oldConfig.ActiveIndex), activeIndexLabel(lang, newConfig.ActiveIndex)))
+	}
+
+	if oldConfig.RestrictionType != newConfig.RestrictionType {
+		changes = append(changes, i18n.T(lang, "config.restriction", valueOrNotSet(lang, oldConfig.RestrictionType), valueOrNotSet(lang, newConfig.RestrictionType)))
+	}
+
+	if oldConfig.VerificationTimeout != newConfig.VerificationTimeout {
+		changes = append(changes, i18n.T(lang, "config.timeout", timeoutLabel(lang, oldConfig.VerificationTimeout), timeoutLabel(lang, newConfig.VerificationTimeout)))
+	}
+
+	if oldConfig.Language != newConfig.Language {
+		changes = append(changes, i18n.T(lang, "config.language", languageLabel(lang, oldConfig.Language), languageLabel(lang, newConfig.Language)))
+	}
+
+	for _, kind := range storage_db.TemplateKinds {
+		if oldConfig.Templates[kind] != newConfig.Templates[kind] {
+			changes = append(changes, i18n.T(lang, "config.template", kind))
+		}
+	}
+
+	return changes
+}
+
+// normalizeParams round-trips params through JSON so values decoded differently compare equal
+func normalizeParams(params storage_db.VerificationParams) interface{} {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return params
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return params
+	}
+	return normalized
+}
+
+func activeIndexLabel(lang string, index int) string {
+	if index < 0 {
+		return i18n.T(lang, "common.none")
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
+
+func valueOrNotSet(lang string, value string) string {
+	if value == "" {
+		return i18n.T(lang, "common.not_set")
+	}
+	return value
+}
+
+func timeoutLabel(lang string, minutes int) string {
+	if minutes <= 0 {
+		return i18n.T(lang, "config.timeout_default", storage_db.DefaultVerificationTimeout())
+	}
+	return i18n.T(lang, "config.timeout_minutes", minutes)
+}
+
+func languageLabel(lang string, language string) string {
+	if language == "" {
+		return i18n.T(lang, "language.not_set", i18n.Name(i18n.Default))
+	}
+	return i18n.Name(language)
+}
@@ -1,19 +1,24 @@
 package handlers
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"html"
+	"log/slog"
 	"os"
 	"strings"
 	"sync"
 
 	//"strconv"
 	//"sync"
-	"github.com/ArtemHvozdov/tg-auth-bot/auth"
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+	"github.com/ArtemHvozdov/tg-auth-bot/metrics"
 	
 	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+	"github.com/ArtemHvozdov/tg-auth-bot/tracing"
+	"github.com/ArtemHvozdov/tg-auth-bot/webhooks"
+	"go.opentelemetry.io/otel/trace"
 
 	"time"
 
@@ -22,11 +27,15 @@ import (
 
 var DataMutex sync.Mutex
 
-// isAdmin checks if the user is a group admin
+// isAdmin checks if the user is a group admin, the bot operators are admins of every group
 func isAdmin(bot *telebot.Bot, chatID int64, userID int64) bool {
+	if IsBotAdmin(userID) {
+		return true
+	}
+
 	member, err := bot.ChatMemberOf(&telebot.Chat{ID: chatID}, &telebot.User{ID: userID})
 	if err != nil {
-		log.Printf("Bot handlers log:(isAdmin func) - Error fetching user role: %v", err)
+		logger.Error("Error fetching user role", "group_id", chatID, "user_id", userID, "error", err)
 		return false
 	}
 	return member.Role == "administrator" || member.Role == "creator"
@@ -36,11 +45,17 @@ func isAdmin(bot *telebot.Bot, chatID int64, userID int64) bool {
 func StartHandler(bot *telebot.Bot) func(c telebot.Context) error {
     return func(c telebot.Context) error {
         userName := c.Sender().Username
+
+        // Deep links start the verification of their group right away
+        payload := c.Message().Payload
+        if token, ok := strings.CutPrefix(payload, invitePayloadPrefix); ok {
+            return startInvite(bot, c, token)
+        }
+        if rest, ok := strings.CutPrefix(payload, verifyPayloadPrefix); ok {
+            return startVerifyLink(bot, c, rest)
+        }
        
-        msg := fmt.Sprintf(
-            "Hello, %s!\n\nIf you want to be verified, run the command /verify.\nIf you want to configure the bot to verify participants, run the command /setup.",
-            userName,
-        )
+        msg := i18n.T(langOf(bot, c), "start.hello", userName)
         return c.Send(msg)
     }
 }
@@ -48,9 +63,9 @@ func StartHandler(bot *telebot.Bot) func(c telebot.Context) error {
 func SetupHandler(bot *telebot.Bot) func(c telebot.Context) error {
 	return func(c telebot.Context) error {
 		// Step 1: Send a message about the need to add the bot to the group with administrator rights
-		msg := "To set me up for verification in your group, please add me to the group as an administrator and call the /check_admin command in the group."
+		msg := i18n.T(langOf(bot, c), "setup.intro")
 		if err := c.Send(msg); err != nil {
-			log.Printf("Blog handler log: (SetupHandler func) - Error sending setup message: %v", err)
+			loggerFor(c).Error("Error sending setup message", "error", err)
 			return err
 		}
 		return nil
@@ -59,11 +74,12 @@ func SetupHandler(bot *telebot.Bot) func(c telebot.Context) error {
 
 // /check_admin command - check administrator rights in a group and verify verification parameters
 func CheckAdminHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	store := storeOf(bot)
 	return func(c telebot.Context) error {
 		// Check the type of chat
 		if c.Chat().Type == telebot.ChatPrivate {
 			// Send a message to the user indicating that this command is not available in private chats
-			return c.Send("This command can only be used in group or supergroup chats.")
+			return c.Send(i18n.T(langOf(bot, c), "common.group_only"))
 		}
 
 		chatID := c.Chat().ID // ID group chat (future: need rename this variable to groupID)
@@ -71,43 +87,47 @@ func CheckAdminHandler(bot *telebot.Bot) func(c telebot.Context) error {
 		chatName := c.Chat().Title // Getting the name of the chat (group)
 		userName := c.Sender().Username // Username
 
-		storage_db.AddAdminUser(userID, chatID)
+		store.AddAdminUser(userID, chatID)
+
+		// The admin is answered privately, the group gets its own language
+		lang := memberLang(store, c.Sender().LanguageCode, chatID)
+		chatLang := groupLang(store, chatID, c.Sender().LanguageCode)
 
-		log.Printf("Bot handler log: (CheckAdminHandler func) - User ID: %d, Chat ID: %d, Command received", userID, chatID)
-		log.Printf("Bot handler log: (CheckAdminHandler func) - User's name: %s %s (@%s)", c.Sender().FirstName, c.Sender().LastName, c.Sender().Username)
+		log := loggerFor(c)
+		log.Info("Check admin command received", "username", userName)
 
 		// Send a message to the user
-		msgContinueForAdmin, _ := bot.Send(&telebot.Chat{ID: chatID}, "Administrator, return to the private chat with me to continue configuring the settings")
+		msgContinueForAdmin, _ := bot.Send(&telebot.Chat{ID: chatID}, i18n.T(chatLang, "check_admin.continue_private"))
 
 		// Delete the message after 1 minute
 		go func() {
 			time.Sleep(1 * time.Minute)
 			if err := bot.Delete(msgContinueForAdmin); err != nil {
-				log.Printf("Bot handler log: (CheckAdminHandler func) - Error deleting continue for admins message: %v", err)
+				log.Warn("Error deleting continue for admins message", "error", err)
 			}
 		}()
 
 		// Checking if the bot is an administrator in this group
 		member, err := bot.ChatMemberOf(&telebot.Chat{ID: chatID}, &telebot.User{ID: bot.Me.ID})
 		if err != nil {
-			log.Printf("Bot handler log: (CheckAdminHandler func) - Bot handler log: (ChecnkAdminHandler func) - Error fetching bot's role in the group: %v", err)
+			log.Error("Error fetching bot's role in the group", "error", err)
 			// Send a private message to the user
-			msg := "I couldn't fetch my role in this group. Please make sure I am an administrator."
+			msg := i18n.T(lang, "check_admin.bot_role_failed")
 			if _, err := bot.Send(&telebot.User{ID: userID}, msg); err != nil {
-				log.Printf("Bot handler log: (CheckAdminHandler func) - Error sending bot admin check message: %v", err)
+				log.Error("Error sending bot admin check message", "error", err)
 				return err
 			}
 			return nil
 		}
 
 		// Logging the bot's role
-		log.Printf("Bot handler log: (CheckAdminHandler func) - Bot's role in the group '%s': %s", chatName, member.Role)
+		log.Info("Bot's role in the group", "group_title", chatName, "role", member.Role)
 
 		// Checking if the bot is an administrator
 		if member.Role != "administrator" && member.Role != "creator" {
-			msg := fmt.Sprintf("I am not an administrator in the group '%s'. Please promote me to an administrator.", chatName)
+			msg := i18n.T(lang, "check_admin.bot_not_admin", chatName)
 			if _, err := bot.Send(&telebot.User{ID: userID}, msg); err != nil {
-				log.Printf("Bot handler log: (CheckAdminHandler func) - Error sending bot admin check message: %v", err)
+				log.Error("Error sending bot admin check message", "error", err)
 				return err
 			}
 			return nil
@@ -116,40 +136,40 @@ func CheckAdminHandler(bot *telebot.Bot) func(c telebot.Context) error {
 		// Checking if the user the bot is interacting with is an administrator
 		memberUser, err := bot.ChatMemberOf(&telebot.Chat{ID: chatID}, &telebot.User{ID: userID})
 		if err != nil {
-			log.Printf("Bot handler log: (CheckAdminHandler func) - Error fetching user's role: %v", err)
+			log.Error("Error fetching user's role", "error", err)
 			// Send a private message to the user
-			msg := "I couldn't fetch your role in this group."
+			msg := i18n.T(lang, "check_admin.user_role_failed")
 			if _, err := bot.Send(&telebot.User{ID: userID}, msg); err != nil {
-				log.Printf("Bot handler log: (CheckAdminHandler func) - Error sending user admin check message: %v", err)
+				log.Error("Error sending user admin check message", "error", err)
 				return err
 			}
 			return nil
 		}
 
 		// Logging the user role
-		log.Printf("Bot handler log: (CheckAdminHandler func) - User's role in the group '%s': %s", chatName, memberUser.Role)
+		log.Info("User's role in the group", "group_title", chatName, "role", memberUser.Role)
 
 		// Checking if the user is an administrator
 		if memberUser.Role != "administrator" && memberUser.Role != "creator" {
 			// We inform the user that he is not an administrator
-			groupMsg := fmt.Sprintf("@%s, you are not an administrator in the group '%s'. You cannot configure me for this group.", userName, chatName)
+			groupMsg := i18n.T(chatLang, "check_admin.user_not_admin", userName, chatName)
 			if _, err := bot.Send(&telebot.Chat{ID: chatID}, groupMsg); err != nil {
-				log.Printf("Bot handler log: (CheckAdminHandler func) - Error sending message to group: %v", err)
+				log.Error("Error sending message to group", "error", err)
 				return err
 			}
 			return nil
 		}
 
 		// All checks were successful
-		msg := fmt.Sprintf("I have confirmed your admin status and my role in the group '%s'. You can now proceed with the setup.", chatName)
+		msg := i18n.T(lang, "check_admin.confirmed", chatName)
 		if _, err := bot.Send(&telebot.User{ID: userID}, msg); err != nil {
-			log.Printf("Bot handler log: (CheckAdminHandler func) - Error sending success message to user: %v", err)
+			log.Error("Error sending success message to user", "error", err)
 			return err
 		}
 
 		time.Sleep(700*time.Millisecond)
 			// Ask for verification parameters
-		bot.Send(&telebot.User{ID: userID}, "To add verification parameters, call the command\n /add_verification_params")
+		bot.Send(&telebot.User{ID: userID}, i18n.T(lang, "check_admin.add_params"))
 
 		return nil
 	}
@@ -159,296 +179,572 @@ func CheckAdminHandler(bot *telebot.Bot) func(c telebot.Context) error {
 func NewUserJoinedHandler(bot *telebot.Bot) func(c telebot.Context) error {
 	return func(c telebot.Context) error {
 		for _, member := range c.Message().UsersJoined {
-			if isAdmin(bot, c.Chat().ID, member.ID) {
-				log.Printf("Bot handler log:(NewUserJoinedHandler) - Skipping admin user @%s (ID: %d)", member.Username, member.ID)
-				continue
+			if err := welcomeMember(bot, c, member); err != nil {
+				return err
 			}
+		}
 
-			// Adding a new user to the repository
-			newUser := &storage_db.UserVerification{
-				UserID:    member.ID,
-				Username:  member.Username,
-				GroupID:   c.Chat().ID,
-				GroupName: c.Chat().Title,
-				IsPending: true,
-				Verified:  false,
-				SessionID: 0,
-				RestrictStatus: true,
-			}
+		return nil
+	}
+}
 
-			storage_db.AddOrUpdateUser(member.ID, newUser)
+// welcomeMember restricts the new member and asks them to pass the verification
+func welcomeMember(bot *telebot.Bot, c telebot.Context, member telebot.User) error {
+	store := storeOf(bot)
+	log := logger.With("update_id", c.Update().ID, "group_id", c.Chat().ID, "user_id", member.ID)
 
-			log.Println("Bot handler log:(NewUserJoinedHandler) - New user:", newUser)
+	// The member calls /verify in another update, its span links to this one
+	_, span := tracing.Tracer.Start(context.Background(), "telegram.memberJoined", trace.WithAttributes(
+		tracing.AttrUpdateID.Int(c.Update().ID),
+		tracing.AttrGroupID.Int64(c.Chat().ID),
+	))
+	defer span.End()
 
-			typeRestriction, err := storage_db.GetRestrictionType(c.Chat().ID)
-			if err != nil {
-				log.Printf("Bot handler log:(NewUserJoinedHandler) - Error getting restriction type: %v", err)
-				return err
-			}
+	if isAdmin(bot, c.Chat().ID, member.ID) {
+		log.Info("Skipping admin user", "username", member.Username)
+		return nil
+	}
 
-			log.Println("Bot handler log:(NewUserJoinedHandler) - New user handler was called")
-			log.Println("Bot handler log:(NewUserJoinedHandler) - Type restriction:", typeRestriction)
+	existing, err := store.GetUser(member.ID)
+	if err != nil {
+		existing = nil
+	}
 
-			// Restrict the user if the restriction type is "block"
-			if typeRestriction == "block" {
-				err := bot.Restrict(c.Chat(), &telebot.ChatMember{
-					User: &telebot.User{ID: member.ID},
-					Rights: telebot.Rights{
-						CanSendMessages: false, // Complete ban on sending messages
-					},
-				})
-				if err != nil {
-					log.Println("Bot handler log:(NewUserJoinedHandler) - New user handler")
-					log.Printf("Bot handler log:(NewUserJoinedHandler) - Failed to restrict user @%s (ID: %d): %s", member.Username, member.ID, err)
-					continue
+	// Applicants were verified before their join request was approved, the verified record lets them post
+	if existing != nil && existing.JoinRequest && existing.Verified && existing.GroupID == c.Chat().ID {
+		log.Info("Member joined with a verified join request", "username", member.Username)
+		// A later join without a request is verified again
+		existing.JoinRequest = false
+		if err := store.SaveVerifiedMember(*existing); err != nil {
+			log.Warn("Error updating the verified applicant", "error", err)
+		}
+		return nil
+	}
+
+	// Users verified for an invite link join with it
+	if link, err := store.GetInviteLink(c.Chat().ID, member.ID); err == nil && link.Usable(time.Now()) {
+		log.Info("Member joined with an invite link", "username", member.Username)
+		if err := store.MarkInviteLinkUsed(c.Chat().ID, member.ID); err != nil {
+			log.Warn("Error marking the invite link as used", "error", err)
+		}
+
+		// The verified record lets them post, a pending verification for another group is left alone
+		if existing == nil || !existing.IsPending {
+			if existing == nil || existing.GroupID != c.Chat().ID {
+				existing = &storage_db.UserVerification{
+					UserID:       member.ID,
+					Username:     member.Username,
+					GroupID:      c.Chat().ID,
+					GroupName:    c.Chat().Title,
+					JoinedAt:     time.Now(),
+					LanguageCode: member.LanguageCode,
 				}
 			}
+			// A later join without an invite link is verified again
+			existing.Invite = false
+			if err := store.SaveVerifiedMember(*existing); err != nil {
+				log.Warn("Error saving the invited member", "error", err)
+			}
+		}
+		return nil
+	}
 
-			log.Println("Bot handler log:(NewUserJoinedHandler) - new member -", newUser)
+	// The record of the user is shared by their verifications, the pending one for another group is finished first.
+	// Replacing it would leave the member restricted in the other group, so they are let out of this one.
+	if existing != nil && existing.IsPending && existing.GroupID != c.Chat().ID {
+		log.Info("Member is verifying for another group, removing them", "username", member.Username, "pending_group_id", existing.GroupID)
+		if err := bot.Ban(c.Chat(), &telebot.ChatMember{User: &member}); err != nil {
+			log.Warn("Failed to remove member", "error", err)
+			tracing.RecordError(span, err)
+			return nil
+		}
+		time.Sleep(1 * time.Second)
+		bot.Unban(c.Chat(), &member)
+		bot.Send(&member, i18n.T(memberLang(store, member.LanguageCode, c.Chat().ID), "verify.busy_member", c.Chat().Title))
+		return nil
+	}
+	stateOf(bot).joinSpans.Store(member.ID, span.SpanContext())
+
+	// Adding a new user to the repository
+	newUser := &storage_db.UserVerification{
+		UserID:    member.ID,
+		Username:  member.Username,
+		GroupID:   c.Chat().ID,
+		GroupName: c.Chat().Title,
+		IsPending: true,
+		Verified:  false,
+		SessionID: 0,
+		RestrictStatus: true,
+		JoinedAt:  time.Now(),
+		LanguageCode: member.LanguageCode,
+	}
 
-			btn := telebot.InlineButton{
-				Text: "Verify your age",
-				URL:  fmt.Sprintf("https://t.me/%s", bot.Me.Username),
-			}
+	store.AddOrUpdateUser(member.ID, newUser)
 
-			inlineKeys := [][]telebot.InlineButton{{btn}}
-			log.Printf("Bot handler log:(NewUserJoinedHandler) - New member @%s added to verification queue.", member.Username)
+	log.Info("New user joined", "username", member.Username)
 
-			msg, err := bot.Send(
-				c.Chat(),
-				fmt.Sprintf("Hi, @%s! Please verify your age by clicking the button below and call /verify command.", member.Username),
-				&telebot.ReplyMarkup{InlineKeyboard: inlineKeys},
-			)
-			if err != nil {
-				log.Printf("Bot handler log:(NewUserJoinedHandler) - Error sending verification message: %v", err)
-				return err
-			}
+	metrics.Joins.WithLabelValues(metrics.Group(c.Chat().ID)).Inc()
+	webhooks.Publish(store, webhooks.Event{
+		Type:     webhooks.EventMemberJoined,
+		GroupID:  c.Chat().ID,
+		UserID:   member.ID,
+		Username: member.Username,
+	})
 
-			// Save the message ID for further deletion
-			storage_db.AddVerificationMsg(member.ID, msg.ID, msg)
+	typeRestriction, err := store.GetRestrictionType(c.Chat().ID)
+	if err != nil {
+		log.Error("Error getting restriction type", "error", err)
+		tracing.RecordError(span, err)
+		return err
+	}
 
-			go handleVerificationTimeout(bot, member.ID, c.Chat().ID)
+	log.Debug("Restriction type", "restriction_type", typeRestriction)
+
+	// Restrict the user if the restriction type is "block"
+	if typeRestriction == "block" {
+		err := bot.Restrict(c.Chat(), &telebot.ChatMember{
+			User: &telebot.User{ID: member.ID},
+			Rights: telebot.Rights{
+				CanSendMessages: false, // Complete ban on sending messages
+			},
+		})
+		if err != nil {
+			log.Error("Failed to restrict user", "error", err)
+			tracing.RecordError(span, err)
+			return nil
 		}
+	}
 
-		return nil
+	// The welcome is posted in the group, so it's in the language of the group
+	lang := groupLang(store, c.Chat().ID, member.LanguageCode)
+
+	// Name the check the member has to pass
+	values := memberValues(store, lang, newUser)
+
+	btn := telebot.InlineButton{
+		Text: i18n.T(lang, "welcome.button"),
+		URL:  verifyDeepLink(bot, c.Chat().ID), // opens the bot and starts the verification right away
+	}
+
+	inlineKeys := [][]telebot.InlineButton{{btn}}
+	log.Info("New member added to verification queue")
+
+	msg, err := bot.Send(
+		c.Chat(),
+		groupMessage(store, c.Chat().ID, storage_db.TemplateWelcome, values, i18n.T(lang, "welcome.message", member.Username, values.Requirement)),
+		&telebot.ReplyMarkup{InlineKeyboard: inlineKeys},
+	)
+	if err != nil {
+		log.Error("Error sending verification message", "error", err)
+		tracing.RecordError(span, err)
+		return err
 	}
+
+	// Save the message ID for further deletion
+	store.AddVerificationMsg(member.ID, msg.ID, msg)
+
+	scheduleVerificationTimeout(bot, member.ID, c.Chat().ID, newUser.JoinedAt)
+	return nil
 }
 
 // Handler /verify
 func VerifyHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	store := storeOf(bot)
 	return func(c telebot.Context) error {
 		userID := c.Sender().ID
+		log := loggerFor(c)
+
+		ctx, span := startVerifySpan(bot, c, userID)
+		defer span.End()
 
-		userData, err := storage_db.GetUser(userID)
+		userData, err := store.GetUser(userID)
 		if err != nil || !userData.IsPending {
-			log.Printf("Bot handler log:(VerifyHandler) - User @%s (ID: %d) is not awaiting verification.", c.Sender().Username, userID)
-			return c.Send("You are not awaiting verification in any group.")
+			log.Info("User is not awaiting verification")
+			return c.Send(i18n.T(langOf(bot, c), "verify.not_pending"))
 		}
 
-		msg := fmt.Sprintf(
-			"Hi, @%s! To remain in the group \"%s\", you need to complete the verification process.",
-			userData.Username,
-			userData.GroupName,
-		)
-		if err := c.Send(msg); err != nil {
-			return err
-		}
+		return startVerification(bot, c, ctx, userData)
+	}
+}
 
-		// userGroupID := storage_db.UserStore[userID].GroupID
-		userGroupID, err := storage_db.GetUserGroupID(userID)
-		if err != nil {
-			log.Printf("Bot handler log:(VerifyHandler) - Error getting user group ID: %v", err)
-			return err
-		}
+// startVerification sends the pending user the intro and the verification link of their group
+func startVerification(bot *telebot.Bot, c telebot.Context, ctx context.Context, userData *storage_db.UserVerification) error {
+	store := storeOf(bot)
+	lang := memberLang(store, c.Sender().LanguageCode, userData.GroupID)
+
+	// Applicants of a join request are not in the group yet
+	introKey := "verify.intro"
+	if userData.JoinRequest {
+		introKey = "verify.intro_join"
+	} else if userData.Invite {
+		introKey = "verify.intro_invite"
+	}
+	if err := c.Send(i18n.T(lang, introKey, userData.Username, userData.GroupName)); err != nil {
+		return err
+	}
 
-		// Get active verification parameters
+	return sendVerificationLink(bot, ctx, loggerFor(c), c.Sender(), userData, lang)
+}
 
-		activeParams, _ := storage_db.GetActiveVerificationParams(userGroupID)
+// sendVerificationLink starts a verification session of the pending user and sends them the button to the verification page
+func sendVerificationLink(bot *telebot.Bot, ctx context.Context, log *slog.Logger, to telebot.Recipient, userData *storage_db.UserVerification, lang string) error {
+	store := storeOf(bot)
+	span := trace.SpanFromContext(ctx)
 
-		log.Println("Bot handler log:(VerifyHandler) func GetActiveVerificationParams - Active verification parameters:", activeParams)
+	userGroupID := userData.GroupID
+	log = log.With("group_id", userGroupID)
+	span.SetAttributes(tracing.AttrGroupID.Int64(userGroupID))
 
-		groupConfig, err := storage_db.GetGroupConfigParams(userGroupID)
-		if err != nil {
-			log.Printf("Bot handler log:(VerifyHandler) - Error getting group configuration: %v", err)
-		}
+	// Get active verification parameters
+	params, err := store.GetActiveVerificationParams(userGroupID)
+	if err != nil {
+		log.Warn("Error getting active verification parameters", "error", err)
+		_, err := bot.Send(to, i18n.T(lang, "verify.not_configured"))
+		return err
+	}
 
-		params := groupConfig.VerificationParams[groupConfig.ActiveIndex]
+	log.Debug("Active verification parameters", "params_name", params.DisplayName(), "circuit_id", params.CircuitID)
 
-		jsonData, _ := auth.GenerateAuthRequest(userID, params)
+	session, err := tenantOf(bot).GenerateAuthRequest(ctx, userData.UserID, userGroupID, userData.JoinedAt, params)
+	if err != nil {
+		log.Error("Error generating auth request", "error", err)
+		tracing.RecordError(span, err)
+		_, err := bot.Send(to, i18n.T(lang, "verify.request_failed"))
+		return err
+	}
 
-		base64Data := base64.StdEncoding.EncodeToString(jsonData)
+	metrics.VerificationAttempts.WithLabelValues(metrics.Group(userGroupID)).Inc()
 
-		// Create deeplink
-		deepLink := fmt.Sprintf("https://wallet.privado.id/#i_m=%s", base64Data)
+	// The page shows a QR code for desktop users and a wallet link for mobile users
+	verifyPageURL := tenantOf(bot).VerificationPageURL(session.ID)
 
-		// logs deeplinl
-		log.Println("Bot handler log:(VerifyHandler) - Deep Link:", deepLink)
+	log.Info("Verification session started", "session_id", session.ID)
 
-		btn := telebot.InlineButton{
-			Text: "Verify with Privado ID", // Text button
-			URL:  deepLink,                // URL for redirect
-		}
+	btn := telebot.InlineButton{
+		Text: i18n.T(lang, "verify.button"), // Text button
+		URL:  verifyPageURL,                 // URL for redirect
+	}
 
-		// Creating markup with a button
-		inlineKeyboard := &telebot.ReplyMarkup{}
-		inlineKeyboard.InlineKeyboard = [][]telebot.InlineButton{{btn}}
+	// Creating markup with a button
+	inlineKeyboard := &telebot.ReplyMarkup{}
+	inlineKeyboard.InlineKeyboard = [][]telebot.InlineButton{{btn}}
 
-		time.Sleep(2 * time.Second)
-		// Send a message with a button
-		return c.Send("Please click the button below to verify your age:", inlineKeyboard)
+	prompt := groupMessage(store, userGroupID, storage_db.TemplateVerify, memberValues(store, lang, userData), i18n.T(lang, "verify.prompt", params.DisplayName()))
+	if params.Description != "" {
+		prompt = fmt.Sprintf("%s\n\n%s", params.Description, prompt)
 	}
+
+	time.Sleep(2 * time.Second)
+	// Send a message with a button
+	_, err = bot.Send(to, prompt, inlineKeyboard)
+	return err
 }
 
-// Handling verification timeout
-func handleVerificationTimeout(bot *telebot.Bot, userID, groupID int64) {
-	time.Sleep(10 * time.Minute)
+// scheduleVerificationTimeout handles the verification timeout of the join at joinedAt in the background,
+// nothing is scheduled once StopTimeouts was called
+func scheduleVerificationTimeout(bot *telebot.Bot, userID, groupID int64, joinedAt time.Time) {
+	state := stateOf(bot)
+	state.timeoutsMutex.Lock()
+	defer state.timeoutsMutex.Unlock()
 
-	userData, err := storage_db.GetUser(userID)
-	if err == nil && userData.IsPending && !userData.Verified {
-		log.Printf("Bot handler log:(handleVerificationTimeout) - User @%s (ID: %d) failed verification on time. Removing from group.", userData.Username, userID)
-		bot.Ban(&telebot.Chat{ID: groupID}, &telebot.ChatMember{User: &telebot.User{ID: userID}})
-		time.Sleep(1 * time.Second)
-		bot.Unban(&telebot.Chat{ID: groupID}, &telebot.User{ID: userID})
-		bot.Send(&telebot.User{ID: userID}, "You did not complete the verification on time and were removed from the group.")
-		storage_db.DeleteUser(userID)
+	if state.timeoutsCtx.Err() != nil {
+		return
 	}
+
+	state.timeouts.Add(1)
+	go func() {
+		defer state.timeouts.Done()
+		handleVerificationTimeout(state.timeoutsCtx, bot, userID, groupID, joinedAt)
+	}()
 }
 
-// Store change listener
-func ListenForstorage_dbChanges(bot *telebot.Bot) {
+// Handling verification timeout of the join at joinedAt
+func handleVerificationTimeout(ctx context.Context, bot *telebot.Bot, userID, groupID int64, joinedAt time.Time) {
+	store := storeOf(bot)
+	timer := time.NewTimer(store.GetVerificationTimeout(groupID))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		// The bot is shutting down, the member stays pending
+		return
+	}
+
+	userData, err := store.GetUser(userID)
+	// A later join replaced the record, its own timeout handles it
+	if err != nil || userData.GroupID != groupID || !userData.JoinedAt.Equal(joinedAt) {
+		return
+	}
+
+	stateOf(bot).joinSpans.Delete(userID)
+
+	if userData.IsPending && !userData.Verified {
+		lang := memberLang(store, userData.LanguageCode, groupID)
+		values := memberValues(store, lang, userData)
+
+		if userData.JoinRequest {
+			// The applicant never entered the group
+			logger.Info("Applicant failed verification on time, declining the join request", "group_id", groupID, "user_id", userID, "username", userData.Username)
+			bot.Send(&telebot.User{ID: userID}, groupMessage(store, groupID, storage_db.TemplateTimeout, values, i18n.T(lang, "result.timeout_join")))
+			if err := bot.DeclineJoinRequest(&telebot.Chat{ID: groupID}, &telebot.User{ID: userID}); err != nil {
+				logger.Warn("Failed to decline the join request", "group_id", groupID, "user_id", userID, "error", err)
+			}
+		} else if userData.Invite {
+			// The user is not in the group, they just don't get the invite link
+			logger.Info("Invited user failed verification on time", "group_id", groupID, "user_id", userID, "username", userData.Username)
+			bot.Send(&telebot.User{ID: userID}, groupMessage(store, groupID, storage_db.TemplateTimeout, values, i18n.T(lang, "result.timeout_invite")))
+		} else {
+			logger.Info("User failed verification on time, removing from group", "group_id", groupID, "user_id", userID, "username", userData.Username)
+			bot.Ban(&telebot.Chat{ID: groupID}, &telebot.ChatMember{User: &telebot.User{ID: userID}})
+			time.Sleep(1 * time.Second)
+			bot.Unban(&telebot.Chat{ID: groupID}, &telebot.User{ID: userID})
+			bot.Send(&telebot.User{ID: userID}, groupMessage(store, groupID, storage_db.TemplateTimeout, values, i18n.T(lang, "result.timeout")))
+		}
+		store.DeleteUser(userID)
+
+		recordFailure(store, groupID, userID, userData.Username, storage_db.FailureTimeout)
+		metrics.VerificationTimeouts.WithLabelValues(metrics.Group(groupID)).Inc()
+		webhooks.Publish(store, webhooks.Event{
+			Type:     webhooks.EventMemberTimedOut,
+			GroupID:  groupID,
+			UserID:   userID,
+			Username: userData.Username,
+			Data:     map[string]interface{}{"timeoutMinutes": int(store.GetVerificationTimeout(groupID).Minutes()), "joinRequest": userData.JoinRequest, "invite": userData.Invite},
+		})
+	}
+}
+
+// Store change listener of the bot. The returned channel is closed once the changes of its store are closed and drained.
+func ListenForstorage_dbChanges(bot *telebot.Bot) <-chan struct{} {
+	store := storeOf(bot)
+	done := make(chan struct{})
+
 	go func() { // panic: runtime error: invalid memory address or nil pointer dereference
-		for event := range storage_db.DataChanges {
-			userID := event.UserID
-			data := event.Data
-
-			if data == nil {
-				// User was delete
-				log.Printf("Bot handler log:(ListenForstorage_dbChanges) - User ID: %d was removed from the store.", userID)
-				data, _ = storage_db.GetUser(userID)
-				if data == nil {
-					log.Println("Bot handler log:(ListenForstorage_dbChanges) - Error getting user data")
+		defer close(done)
+
+		for event := range store.Changes() {
+			handleUserChange(bot, event)
+		}
+	}()
+
+	return done
+}
+
+// handleUserChange applies a change of the user in the store to the group
+func handleUserChange(bot *telebot.Bot, event storage_db.UserChangeEvent) {
+	store := storeOf(bot)
+	ctx := event.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// The span continues the trace of the callback that changed the user
+	_, span := tracing.Tracer.Start(ctx, "bot.handleUserChange")
+	defer span.End()
+
+	userID := event.UserID
+	data := event.Data
+	log := logger.With("user_id", userID)
+
+	if data == nil {
+		// User was delete
+		log.Debug("User was removed from the store")
+		data, _ = store.GetUser(userID)
+		if data == nil {
+			log.Debug("Error getting user data")
+		}
+		return
+	}
+
+	groupChatID := data.GroupID
+	log = log.With("group_id", groupChatID)
+	span.SetAttributes(tracing.AttrGroupID.Int64(groupChatID))
+
+	typeRestriction, err := store.GetRestrictionType(groupChatID)
+	if err != nil {
+		log.Error("Error getting restriction type", "error", err)
+		return
+	}
+
+	userIsAdminGroup := checkUserAsAdminInGroup(store, userID, groupChatID)
+	lang := memberLang(store, data.LanguageCode, groupChatID)
+
+	if !data.IsPending {
+		if data.Verified {
+			// Successful verification
+			log.Info("User passed verification", "username", data.Username)
+			stateOf(bot).joinSpans.Delete(userID)
+			
+			// Restrict the user, applicants of a join request and invited users were never restricted
+			if typeRestriction == "block" && !userIsAdminGroup && !data.JoinRequest && !data.Invite {
+				err := bot.Restrict(&telebot.Chat{ID: groupChatID}, &telebot.ChatMember{
+					User: &telebot.User{ID: userID},
+					Rights: telebot.Rights{
+						CanSendMessages: true, // Full permission to send messages
+						CanSendMedia:    true, // Full permission to send media files
+						CanSendOther:    true, // Full permission to send other messages
+					},
+				})
+				if err != nil {
+					log.Error("Failed to lift the restriction of user", "error", err)
+					tracing.RecordError(span, err)
+					return
 				}
-				continue
 			}
+			 
+			if !userIsAdminGroup {
+				successKey := "result.success"
+				if data.JoinRequest {
+					successKey = "result.success_join"
+				} else if data.Invite {
+					successKey = "result.success_invite"
+				}
+				bot.Send(&telebot.User{ID: userID}, groupMessage(store, groupChatID, storage_db.TemplateSuccess, memberValues(store, lang, data), i18n.T(lang, successKey)))
 
-			groupChatID := data.GroupID
+				if data.JoinRequest {
+					// The applicant joins now, welcomeMember recognizes them by the verified record
+					if err := bot.ApproveJoinRequest(&telebot.Chat{ID: groupChatID}, &telebot.User{ID: userID}); err != nil {
+						log.Error("Failed to approve the join request", "error", err)
+						tracing.RecordError(span, err)
+					}
+				} else if data.Invite {
+					// The user joins with the link, welcomeMember recognizes them by it and the verified record lets them post
+					if err := issueInviteLink(bot, log, data, lang); err != nil {
+						tracing.RecordError(span, err)
+					}
+				} else {
+					// Delete the verification message
+					store.DeleteVerifyMessage(bot, userID)
+					log.Debug("Verification message deleted")
+				}
 
-			typeRestriction, err := storage_db.GetRestrictionType(groupChatID)
-			if err != nil {
-				log.Printf("Bot handler log:(ListenForstorage_dbChanges) - Error getting restriction type from: %v", err)
-				continue
+				event := webhooks.Event{
+					Type:     webhooks.EventVerificationSucceeded,
+					GroupID:  groupChatID,
+					UserID:   userID,
+					Username: data.Username,
+					Data:     map[string]interface{}{"joinRequest": data.JoinRequest, "invite": data.Invite},
+				}
+				if verificationType, err := store.GetVerificationType(groupChatID); err == nil {
+					event.Data["verificationType"] = verificationType
+				}
+				webhooks.Publish(store, event)
 			}
 
-			userIsAdminGroup := checkUserAsAdminInGroup(userID, groupChatID)
-
-			if !data.IsPending {
-				if data.Verified {
-					// Successful verification
-					log.Printf("Bot handler log:(ListenForstorage_dbChanges) - User @%s (ID: %d) passed verification.", data.Username, userID)
-					
-					// Restrict the user
-					if typeRestriction == "block" && !userIsAdminGroup {
-						err := bot.Restrict(&telebot.Chat{ID: groupChatID}, &telebot.ChatMember{
-							User: &telebot.User{ID: userID},
-							Rights: telebot.Rights{
-								CanSendMessages: true, // Full permission to send messages
-								CanSendMedia:    true, // Full permission to send media files
-								CanSendOther:    true, // Full permission to send other messages
-							},
-						})
-						if err != nil {
-							log.Println("Bot handler log:(ListenForstorage_dbChanges) - Listen for storage_db changes handler")
-							log.Printf("Bot handler log:(ListenForstorage_dbChanges) - Failed to restrict user @%s (ID: %d): %s", data.Username, userID, err)
-							continue
-						}
-					}
-					 
-					if !userIsAdminGroup {
-						bot.Send(&telebot.User{ID: userID}, "You have successfully passed verification and can stay in the group.")
+			if userIsAdminGroup {
+				activeParams, err := store.GetActiveVerificationParams(groupChatID)
+				if err != nil {
+					log.Error("Error getting active verification parameters", "error", err)
+					return
+				}
+				
+				// Combine active parameter with type restriction
+				result := map[string]interface{}{
+					"activeVerificationParam": activeParams,
+					"typeRestriction":         typeRestriction,
+				}
 
-						// Delete the verification message
-						storage_db.DeleteVerifyMessage(bot, userID)
-						log.Println("Bot handler log:(ListenForstorage_dbChanges) - Verification message deleted for user:", userID)
-					}
+				formattedResult, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					log.Error("Failed to format result", "error", err)
+					return
+				}
 
-					if userIsAdminGroup {
-						activeParams, err := storage_db.GetActiveVerificationParams(groupChatID)
-						if err != nil {
-							log.Printf("Bot handler log:(ListenForstorage_dbChanges) - Error getting active verification parameters: %v", err)
-							return
-						}
-						
-						// Combine active parameter with type restriction
-						result := map[string]interface{}{
-							"activeVerificationParam": activeParams,
-							"typeRestriction":         typeRestriction,
-						}
-
-						formattedResult, err := json.MarshalIndent(result, "", "  ")
-						if err != nil {
-							log.Printf("Bot handler log:(ListenForstorage_dbChanges) - Failed to format result: %v", err)
-							return
-						}
-
-						// Get the user's token
-						tokenStr, errGettingToken := GetAuthTokenFromAdmin(groupChatID, userID)
-						if !errGettingToken {
-							log.Printf("Bot handler log:(ListenForstorage_dbChanges) - Failed to get token for user %d", userID)
-							return
-						}
-
-						// Create txt file for write token
-						fileName := fmt.Sprintf("token_%d.txt", userID)
-						err = os.WriteFile(fileName, []byte(tokenStr), 0644)
-						if err != nil {
-							log.Printf("Bot handler log:(ListenForstorage_dbChanges) - Error writing AuthToken to file: %v", err)
-							bot.Send(&telebot.User{ID: userID}, "Failed to create file with AuthToken.")
-						}
-
-						defer os.Remove(fileName) // Remove the file after sending
-
-						bot.Send(
-							&telebot.User{ID: userID},
-							fmt.Sprintf("Here is the current verification parameter being tested:\n```\n%s\n```\n", string(formattedResult)),
-							&telebot.SendOptions{ParseMode: telebot.ModeMarkdown},
-						)
-
-						time.Sleep(1*time.Second)
-
-						// Send the file to the chat
-						file := &telebot.Document{
-							File:     telebot.FromDisk(fileName),
-							FileName: fileName,
-						}
-
-						if _, err := bot.Send(&telebot.User{ID: userID}, file); err != nil {
-							log.Printf("Bot handler log:(ListenForstorage_dbChanges) - Error sending file: %v", err)
-						} else {
-							// Remove the file after successfully sending it
-							if err := os.Remove(fileName); err != nil {
-								log.Printf("Bot handler log:(ListenForstorage_dbChanges) - Error deleting file: %v", err)
-							}
-						}
-
-						time.Sleep(500*time.Millisecond)
-
-						bot.Send(&telebot.User{ID: userID}, "The test was successful. The parameters are configured correctly, the verification process is working.")
-						storage_db.DeleteUser(userID)
-						storage_db.RemoveVerifiedUser(groupChatID, userID)
-					}
+				// Get the user's token
+				tokenStr, errGettingToken := GetAuthTokenFromAdmin(store, groupChatID, userID)
+				if !errGettingToken {
+					log.Error("Failed to get token of admin")
+					return
+				}
+
+				// Create txt file for write token
+				fileName := fmt.Sprintf("token_%d.txt", userID)
+				err = os.WriteFile(fileName, []byte(tokenStr), 0644)
+				if err != nil {
+					log.Error("Error writing AuthToken to file", "error", err)
+					bot.Send(&telebot.User{ID: userID}, i18n.T(lang, "test.token_file_failed"))
+				}
+
+				defer os.Remove(fileName) // Remove the file after sending
+
+				bot.Send(
+					&telebot.User{ID: userID},
+					i18n.T(lang, "test.current_params", html.EscapeString(string(formattedResult))),
+					&telebot.SendOptions{ParseMode: telebot.ModeHTML},
+				)
+
+				time.Sleep(1*time.Second)
+
+				// Send the file to the chat
+				file := &telebot.Document{
+					File:     telebot.FromDisk(fileName),
+					FileName: fileName,
+				}
+
+				if _, err := bot.Send(&telebot.User{ID: userID}, file); err != nil {
+					log.Error("Error sending file", "error", err)
 				} else {
-					// Verification failed
-					log.Printf("Bot handler log:(ListenForstorage_dbChanges) - User @%s (ID: %d) failed verification. Removing from group.", data.Username, userID)
-					group := &telebot.Chat{ID: data.GroupID}
-					user := &telebot.User{ID: userID}
-					bot.Ban(group, &telebot.ChatMember{User: user})
-					time.Sleep(1 * time.Second)
-					bot.Unban(group, user)
-					bot.Send(user, "You failed verification and were removed from the group.")
+					// Remove the file after successfully sending it
+					if err := os.Remove(fileName); err != nil {
+						log.Warn("Error deleting file", "error", err)
+					}
+				}
+
+				time.Sleep(500*time.Millisecond)
+
+				bot.Send(&telebot.User{ID: userID}, i18n.T(lang, "test.success"))
+				store.DeleteUser(userID)
+				store.RemoveVerifiedUser(groupChatID, userID)
+			}
+		} else {
+			// Verification failed
+			stateOf(bot).joinSpans.Delete(userID)
+			group := &telebot.Chat{ID: data.GroupID}
+			user := &telebot.User{ID: userID}
+			values := memberValues(store, lang, data)
+
+			if data.JoinRequest {
+				log.Info("Applicant failed verification, declining the join request", "username", data.Username)
+				bot.Send(user, groupMessage(store, data.GroupID, storage_db.TemplateFailure, values, i18n.T(lang, "result.failure_join")))
+				if err := bot.DeclineJoinRequest(group, user); err != nil {
+					log.Warn("Failed to decline the join request", "error", err)
+					tracing.RecordError(span, err)
 				}
+			} else if data.Invite {
+				log.Info("Invited user failed verification", "username", data.Username)
+				bot.Send(user, groupMessage(store, data.GroupID, storage_db.TemplateFailure, values, i18n.T(lang, "result.failure_invite")))
+			} else {
+				log.Info("User failed verification, removing from group", "username", data.Username)
+				bot.Ban(group, &telebot.ChatMember{User: user})
+				time.Sleep(1 * time.Second)
+				bot.Unban(group, user)
+				bot.Send(user, groupMessage(store, data.GroupID, storage_db.TemplateFailure, values, i18n.T(lang, "result.failure")))
 			}
+
+			recordFailure(store, data.GroupID, userID, data.Username, storage_db.FailureProof)
+			webhooks.Publish(store, webhooks.Event{
+				Type:     webhooks.EventVerificationFailed,
+				GroupID:  data.GroupID,
+				UserID:   userID,
+				Username: data.Username,
+				Data:     map[string]interface{}{"joinRequest": data.JoinRequest, "invite": data.Invite},
+			})
 		}
-	}()
+	}
+}
+
+// recordFailure adds the failure to the log shown on the admin dashboard
+func recordFailure(store *storage_db.Store, groupID, userID int64, username string, reason string) {
+	err := store.AddVerificationFailure(storage_db.VerificationFailure{
+		GroupID:  groupID,
+		UserID:   userID,
+		Username: username,
+		Reason:   reason,
+		FailedAt: time.Now(),
+	})
+	if err != nil {
+		logger.Error("Error saving verification failure", "group_id", groupID, "user_id", userID, "error", err)
+	}
 }
 
 func UnifiedHandler(bot *telebot.Bot) func(c telebot.Context) error {
@@ -464,7 +760,7 @@ func UnifiedHandler(bot *telebot.Bot) func(c telebot.Context) error {
             return handlePrivateMessage(bot, c)
 
         default:
-            log.Printf("Bot handler log:(UnifiedHandler) - Unhandled chat type: %s", chatType)
+            loggerFor(c).Debug("Unhandled chat type", "chat_type", chatType)
             return nil
         }
     }
@@ -472,22 +768,22 @@ func UnifiedHandler(bot *telebot.Bot) func(c telebot.Context) error {
 
 // Handle group messages
 func handleGroupMessage(bot *telebot.Bot, c telebot.Context, userID int64) error {
+	store := storeOf(bot)
 	chatGroupId := c.Chat().ID
-	typeRestriction, err := storage_db.GetRestrictionType(chatGroupId)
+	typeRestriction, err := store.GetRestrictionType(chatGroupId)
 	if err != nil {
-		log.Printf("Bot handler log:(handleGroupMessage) - Error getting restriction type: %v", err)
+		loggerFor(c).Error("Error getting restriction type", "error", err)
 		return err
 	}
 
 	if typeRestriction == "delete" {
-		log.Println("Bot handler log:(handleGroupMessage) - Handle group message, if type == delete")
-		userData, err := storage_db.GetUser(userID)
+		userData, err := store.GetUser(userID)
 		if err != nil || userData.IsPending {
 			// Delete the user's message
 			if err := bot.Delete(c.Message()); err != nil {
-				log.Printf("Bot handler log:(handleGroupMessage) - Failed to delete message from @%s (ID: %d): %v", c.Sender().Username, userID, err)
+				loggerFor(c).Error("Failed to delete message", "error", err)
 			} else {
-				log.Printf("Bot handler log:(handleGroupMessage) - Message from @%s (ID: %d) deleted (user awaiting verification).", c.Sender().Username, userID)
+				loggerFor(c).Debug("Message deleted (user awaiting verification)")
 			}
 		}
 		return nil
@@ -497,120 +793,151 @@ func handleGroupMessage(bot *telebot.Bot, c telebot.Context, userID int64) error
 }
 
 func handlePrivateMessage(bot *telebot.Bot, c telebot.Context) error {
+	store := storeOf(bot)
 	userID := c.Sender().ID
 
+	// The admin may be answering a question asked by a previous command
+	if input, ok := takePendingInput(bot, userID); ok {
+		return handlePendingInput(bot, c, input)
+	}
+
 	//groupChatID := storage_db.GroupSetupState[userID]
-	groupChatID, err := storage_db.GetIdGroupFromGroupSetupState(userID)
+	groupChatID, err := store.GetIdGroupFromGroupSetupState(userID)
 	if groupChatID == 0 || err != nil {
-		log.Println("Bot handler log:(handlePrivateMessage) - Group not set up for user:", userID)
+		loggerFor(c).Debug("Group not set up for user")
 		return nil
 	}
 
 	groupChat, _ := bot.ChatByID(groupChatID)
 	if groupChat == nil {
-		log.Printf("Bot handler log:(handlePrivateMessage) - Failed to fetch group chat by ID: %d", groupChatID)
+		loggerFor(c).Warn("Failed to fetch group chat", "group_id", groupChatID)
 		return nil
 	}
 
 	groupChatName := groupChat.Title
 	if groupChatName == "" {
-		log.Printf("Bot handler log:(handlePrivateMessage) - Failed to fetch group chat name by ID: %d", groupChatID)
+		loggerFor(c).Warn("Failed to fetch group chat name", "group_id", groupChatID)
 		return nil
 	}
 
-    var params storage_db.VerificationParams
-
     // Parse JSON from the admin's message
-	if err := json.Unmarshal([]byte(c.Text()), &params); err != nil {
-		log.Printf("Bot handler log:(handlePrivateMessage) - Failed to parse JSON: %v", err)
-		bot.Send(c.Sender(), "Invalid JSON format. Please ensure your parameters match the expected structure.")
-		return nil
-	}
-
-    // Validate required fields in parsed JSON
-	if params.CircuitID == "" || params.ID == 0 || params.Query == nil {
-		log.Println("Bot handler log:(handlePrivateMessage) - JSON does not contain all required fields.")
-		bot.Send(c.Sender(), "Missing required fields in JSON. Please include 'circuitId', 'id', and 'query'.")
+	lang := langOf(bot, c)
+	params, errMsg := parseVerificationParams(lang, c.Text())
+	if errMsg != "" {
+		loggerFor(c).Info("Invalid verification parameters", "group_id", groupChatID, "reason", errMsg)
+		bot.Send(c.Sender(), errMsg)
 		return nil
 	}
 
 	// // Save parameters to storage_db
-	storage_db.SaveVerificationParams(groupChatID, params)
-	log.Printf("Bot handler log:(handlePrivateMessage) - Verification parameters set for group '%s': %+v", groupChatName, params)
-	bot.Send(c.Sender(), "JSON verification parameters have been add for the group.")
+	store.SaveVerificationParams(groupChatID, params)
+	loggerFor(c).Info("Verification parameters added", "group_id", groupChatID, "circuit_id", params.CircuitID)
+	bot.Send(c.Sender(), i18n.T(lang, "params.added"))
 
+	return askRestrictionTypeIfMissing(bot, c, groupChatID, groupChatName)
+}
+
+// askRestrictionTypeIfMissing continues the setup after params were added
+func askRestrictionTypeIfMissing(bot *telebot.Bot, c telebot.Context, groupChatID int64, groupChatName string) error {
+	store := storeOf(bot)
+	lang := langOf(bot, c)
 	// Send a message depending on the number of parameters
-	restrictionType, _ := storage_db.GetRestrictionType(groupChatID)
-	groupConfig, _ := storage_db.GetGroupConfigParams(groupChatID)
+	restrictionType, _ := store.GetRestrictionType(groupChatID)
+	groupConfig, _ := store.GetGroupConfigParams(groupChatID)
 	if restrictionType == "" {
 		time.Sleep(200*time.Millisecond)
 
 		// bot.Send(c.Sender(), "To set restriction parameters for new subscribers, call the command\n /add_type_restriction")
-		bot.Send(c.Sender(), "To set restriction parameters for new subscribers")
+		bot.Send(c.Sender(), i18n.T(lang, "restriction.ask"))
 
 		// Immediately ask for restriction type
         return AddRestrictionTypeFunc(bot, c, groupChatID, groupChatName, len(groupConfig.VerificationParams) == 1)
 	} else {
-		bot.Send(c.Sender(), "Another verification parameter has been added.")
+		bot.Send(c.Sender(), i18n.T(lang, "params.another_added"))
 	}
 	
 	return nil
-		
+}
+
+// parseVerificationParams parses params sent by an admin and returns a message for the admin if they are invalid
+func parseVerificationParams(lang, text string) (storage_db.VerificationParams, string) {
+	var params storage_db.VerificationParams
+
+	if err := json.Unmarshal([]byte(text), &params); err != nil {
+		return params, i18n.T(lang, "params.invalid_json")
+	}
+
+	// Validate required fields in parsed JSON
+	if params.CircuitID == "" || params.ID == 0 || params.Query == nil {
+		return params, i18n.T(lang, "params.missing_fields")
+	}
+
+	return params, ""
 }
 
 // Unified logic to set restriction type add_type_restriction_func
 func AddRestrictionTypeFunc(bot *telebot.Bot, c telebot.Context, groupChatID int64, groupChatName string, isFirstParameter bool) error {
+    store := storeOf(bot)
+    lang := langOf(bot, c)
     // Create buttons ''Block'' and ''Delete''
     btnBlock := telebot.InlineButton{
-        Text:   "Block",
+        Text:   i18n.T(lang, "restriction.button_block"),
         Unique: "block",
     }
     btnDelete := telebot.InlineButton{
-        Text:   "Delete",
+        Text:   i18n.T(lang, "restriction.button_delete"),
         Unique: "delete",
     }
     // Create a keyboard with buttons
     inlineKeys := [][]telebot.InlineButton{{btnBlock, btnDelete}}
     keyboard := &telebot.ReplyMarkup{InlineKeyboard: inlineKeys}
 
-    if _, err := bot.Send(c.Sender(), "Select restriction type:", keyboard); err != nil {
-        log.Printf("Bot handler log:(AddRestrictionTypeFunc) - Error sending keyboard: %v", err)
+    if _, err := bot.Send(c.Sender(), i18n.T(lang, "restriction.select"), keyboard); err != nil {
+        loggerFor(c).Error("Error sending keyboard", "error", err)
         return err
     }
 
     bot.Handle(&btnBlock, func(c telebot.Context) error {
-        storage_db.AddRestrictionType(groupChatID, "block")
-        c.Send("Restriction type set to 'block'.")
+        store.AddRestrictionType(groupChatID, "block")
+        c.Send(i18n.T(lang, "restriction.set", "block"))
 
-		groupConfig, _ := storage_db.GetGroupConfigParams(groupChatID)
+		groupConfig, _ := store.GetGroupConfigParams(groupChatID)
 		// Logs paprams for the group
-		log.Println("Bot handler log:(AddRestrictionTypeFunc, TR: block) - Function add restriction type")
-		log.Println("Bot handler log:(AddRestrictionTypeFunc, TR: block) - Group params:", groupConfig)
+		loggerFor(c).Info("Restriction type added",
+			"group_id", groupChatID,
+			"restriction_type", "block",
+			"params_count", len(groupConfig.VerificationParams),
+			"active_index", groupConfig.ActiveIndex,
+		)
 
         // Send a success message
         if isFirstParameter {
-            c.Send(fmt.Sprintf("Verification parameters have been successfully set for the group '%s'.", groupChatName))
+            c.Send(i18n.T(lang, "params.set_for_group", groupChatName))
         } else {
-            c.Send("Another verification parameter has been added.")
+            c.Send(i18n.T(lang, "params.another_added"))
         }
         return nil
     })
 
     bot.Handle(&btnDelete, func(c telebot.Context) error {
-        storage_db.AddRestrictionType(groupChatID, "delete")
-        c.Send("Restriction type set to 'delete'.")
+        store.AddRestrictionType(groupChatID, "delete")
+        c.Send(i18n.T(lang, "restriction.set", "delete"))
 
-		groupConfig, _ := storage_db.GetGroupConfigParams(groupChatID)
+		groupConfig, _ := store.GetGroupConfigParams(groupChatID)
 
 		// Logs paprams for the group
-		log.Println("Bot handler log:(AddRestrictionTypeFunc, TR: delete) - Function add restriction type")
-		log.Println("Bot handler log:(AddRestrictionTypeFunc, TR: delete) - Group params:", groupConfig)
+		loggerFor(c).Info("Restriction type added",
+			"group_id", groupChatID,
+			"restriction_type", "delete",
+			"params_count", len(groupConfig.VerificationParams),
+			"active_index", groupConfig.ActiveIndex,
+		)
 
         // Send a success message
         if isFirstParameter {
-            c.Send(fmt.Sprintf("Verification parameters have been successfully set for the group '%s'.", groupChatName))
+            c.Send(i18n.T(lang, "params.set_for_group", groupChatName))
         } else {
-            c.Send("Another verification parameter has been added.")
+            c.Send(i18n.T(lang, "params.another_added"))
         }
         return nil
     })
@@ -620,42 +947,44 @@ func AddRestrictionTypeFunc(bot *telebot.Bot, c telebot.Context, groupChatID int
 
 // Handler for /set_type_restriction
 func SetTypeRestrictionHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	store := storeOf(bot)
 	return func(c telebot.Context) error {
 		userID := c.Sender().ID
+		lang := langOf(bot, c)
 
 		// Check if the group is set up for this user
-		targetChatGroupID, err := storage_db.GetIdGroupFromGroupSetupState(userID)
+		targetChatGroupID, err := store.GetIdGroupFromGroupSetupState(userID)
 		if err != nil {
-			log.Println("Bot handler log:(SetTypeRestrictionHandler) - Bot handlers logs: Group not set up for user:", userID)
-			return c.Send("You need to specify a group for restriction setup.")
+			loggerFor(c).Debug("Group not set up for user")
+			return c.Send(i18n.T(lang, "common.need_group_restriction"))
 		}
 
 		// Get the group chat by ID
 		chat, err := bot.ChatByID(targetChatGroupID)
 		if err != nil {
-			log.Printf("Bot handler log:(SetTypeRestrictionHandler) - Error fetching chat: %v", err)
-			return c.Send("Failed to fetch chat information.")
+			loggerFor(c).Error("Error fetching chat", "group_id", targetChatGroupID, "error", err)
+			return c.Send(i18n.T(lang, "common.chat_info_failed"))
 		}
 		groupChatName := chat.Title
 
 		// Check if the user is an administrator of the group
 		if !isAdmin(bot, targetChatGroupID, userID) {
-			return c.Send("You are not an administrator in this group.")
+			return c.Send(i18n.T(lang, "common.not_admin"))
 		}
 
 		// Fetch current restriction type
-		currentRestriction, _ := storage_db.GetRestrictionType(targetChatGroupID)
+		currentRestriction, _ := store.GetRestrictionType(targetChatGroupID)
 		if currentRestriction == "" {
-			currentRestriction = "Not set"
+			currentRestriction = i18n.T(lang, "common.not_set")
 		}
 
 		// Update button text based on the current restriction
-		blockText := "Block"
-		deleteText := "Delete"
+		blockText := i18n.T(lang, "restriction.button_block")
+		deleteText := i18n.T(lang, "restriction.button_delete")
 		if currentRestriction == "block" {
-			blockText += " (active)"
+			blockText += i18n.T(lang, "common.active")
 		} else if currentRestriction == "delete" {
-			deleteText += " (active)"
+			deleteText += i18n.T(lang, "common.active")
 		}
 
 		// Create buttons
@@ -673,10 +1002,8 @@ func SetTypeRestrictionHandler(bot *telebot.Bot) func(c telebot.Context) error {
 		keyboard := &telebot.ReplyMarkup{InlineKeyboard: inlineKeys}
 
 		// Send current restriction type and options to change it
-		if _, err := bot.Send(c.Sender(), fmt.Sprintf(
-			"Current restriction type for the group '%s': %s.\n\nSelect a new restriction type:",
-			groupChatName, currentRestriction), keyboard); err != nil {
-			log.Printf("Bot handler log:(SetTypeRestrictionHandler) - Error sending keyboard: %v", err)
+		if _, err := bot.Send(c.Sender(), i18n.T(lang, "restriction.current", groupChatName, currentRestriction), keyboard); err != nil {
+			loggerFor(c).Error("Error sending keyboard", "error", err)
 			return err
 		}
 
@@ -684,52 +1011,60 @@ func SetTypeRestrictionHandler(bot *telebot.Bot) func(c telebot.Context) error {
 		bot.Handle(&btnBlock, func(c telebot.Context) error {
 			// Check if the current restriction type is already "block"
 			if currentRestriction == "block" {
-				_, err := bot.Send(c.Sender(), "The restriction type is already set to 'Block'.")
+				_, err := bot.Send(c.Sender(), i18n.T(lang, "restriction.already", "block"))
 				return	err
 			}
 
 			// Update the restriction type
-			storage_db.AddRestrictionType(targetChatGroupID, "block")
+			store.AddRestrictionType(targetChatGroupID, "block")
 
 			// Get config verification params for the group
-			groupConfig, err := storage_db.GetGroupConfigParams(targetChatGroupID)
+			groupConfig, err := store.GetGroupConfigParams(targetChatGroupID)
 			if err != nil {
-				log.Printf("Bot handler log:(SetTypeRestrictionHandler) - Bot handlers log: Error fetching group configuration: %v", err)
-				return c.Send("Failed to fetch group configuration.")
+				loggerFor(c).Error("Error fetching group configuration", "group_id", targetChatGroupID, "error", err)
+				return c.Send(i18n.T(lang, "common.group_config_failed"))
 			}
 			
 			// Logs paprams for the group durin change restriction type
-			log.Println("Bot handler log:(SetTypeRestrictionHandler) - Function set restriction type")
-			log.Println("Bot handler log:(SetTypeRestrictionHandler) - Group params:", groupConfig)
+			loggerFor(c).Info("Restriction type changed",
+				"group_id", targetChatGroupID,
+				"restriction_type", "block",
+				"params_count", len(groupConfig.VerificationParams),
+				"active_index", groupConfig.ActiveIndex,
+			)
 
 			// Send a confirmation message without deleting or editing the keyboard message
-			_, err = bot.Send(c.Sender(), fmt.Sprintf("Restriction type for group '%s' has been changed to 'block'.", groupChatName))
+			_, err = bot.Send(c.Sender(), i18n.T(lang, "restriction.changed", groupChatName, "block"))
 			return err
 		})
 
 		bot.Handle(&btnDelete, func(c telebot.Context) error {
 			// Check if the current restriction type is already "delete"
 			if currentRestriction == "delete" {
-				_, err := bot.Send(c.Sender(), "The restriction type is already set to 'Block'.")
+				_, err := bot.Send(c.Sender(), i18n.T(lang, "restriction.already", "delete"))
 				return err
 			}
 
 			// Update the restriction type
-			storage_db.AddRestrictionType(targetChatGroupID, "delete")
+			store.AddRestrictionType(targetChatGroupID, "delete")
 
 			// Get config verification params for the group
-			groupConfig, err := storage_db.GetGroupConfigParams(targetChatGroupID)
+			groupConfig, err := store.GetGroupConfigParams(targetChatGroupID)
 			if err != nil {
-				log.Printf("Bot handlers log: Error fetching group configuration: %v", err)
-				return c.Send("Failed to fetch group configuration.")
+				loggerFor(c).Error("Error fetching group configuration", "group_id", targetChatGroupID, "error", err)
+				return c.Send(i18n.T(lang, "common.group_config_failed"))
 			}
 
 			// Logs paprams for the group durin change restriction type
-			log.Println("Bot handler log:(SetTypeRestrictionHandler) - Function set restriction type")
-			log.Println("Bot handler log:(SetTypeRestrictionHandler) - Group params:", groupConfig)
+			loggerFor(c).Info("Restriction type changed",
+				"group_id", targetChatGroupID,
+				"restriction_type", "delete",
+				"params_count", len(groupConfig.VerificationParams),
+				"active_index", groupConfig.ActiveIndex,
+			)
 
 			// Send a confirmation message without deleting or editing the keyboard message
-			_, err = bot.Send(c.Sender(), fmt.Sprintf("Restriction type for group '%s' has been changed to 'delete'.", groupChatName))
+			_, err = bot.Send(c.Sender(), i18n.T(lang, "restriction.changed", groupChatName, "delete"))
 			return err
 		})
 
@@ -739,28 +1074,31 @@ func SetTypeRestrictionHandler(bot *telebot.Bot) func(c telebot.Context) error {
  
 // Handler for /test_verification
 func TestVerificationHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	store := storeOf(bot)
 	return func(c telebot.Context) error {
 		userID := c.Sender().ID
+		lang := langOf(bot, c)
 		var groupChatID int64
 
 		// Determine where the handler was called: in a group or in a private chat
 		if c.Chat().Type == telebot.ChatPrivate {
 			// Check if there is a saved group for this administrator
-			groupID, _ := storage_db.GetIdGroupFromGroupSetupState(userID)
+			groupID, _ := store.GetIdGroupFromGroupSetupState(userID)
 			if groupID != 0 {
 				groupChatID = groupID
 			} else {
-				return c.Send("You need to specify a group for verification setup.")
+				return c.Send(i18n.T(lang, "common.need_group_verification"))
 			}
 		} else {
 			groupChatID = c.Chat().ID
 		}
 
-		log.Println("Bot handler log:(TestVerificationHandler) - Group Chat ID:", groupChatID)
+		log := loggerFor(c).With("group_id", groupChatID)
+		log.Info("Test verification requested")
 
 		// Check if the user is an administrator of the group
 		if !isAdmin(bot, groupChatID, userID) {
-			return c.Send("You are not an administrator in this group.")
+			return c.Send(i18n.T(lang, "common.not_admin"))
 		}
 
 		// Create a record for the admin's test verification
@@ -774,44 +1112,44 @@ func TestVerificationHandler(bot *telebot.Bot) func(c telebot.Context) error {
 			SessionID:      0,
 			RestrictStatus: false,
 			Role : 			"admin",
+			LanguageCode:   c.Sender().LanguageCode,
 		}
 
-		storage_db.AddOrUpdateUser(userID, adminUser)
+		store.AddOrUpdateUser(userID, adminUser)
 
-		groupConfig, err := storage_db.GetGroupConfigParams(groupChatID)
+		groupConfig, err := store.GetGroupConfigParams(groupChatID)
 		if err != nil {
-			log.Printf("Bot handler log:(TestVerificationHandler) - Bot handlers log: Error fetching group configuration: %v", err)
-			return c.Send("Verification parameters are not configured for your group.")
+			log.Warn("Error fetching group configuration", "error", err)
+			return c.Send(i18n.T(lang, "common.params_not_configured"))
 		}
 
 		// Check that the active index is valid
 		if groupConfig.ActiveIndex < 0 || groupConfig.ActiveIndex >= len(groupConfig.VerificationParams) {
-			log.Printf("Bot handler log:(TestVerificationHandler) - Invalid active index for group ID: %d", groupChatID)
-			return c.Send("Verification configuration error. Please contact the group administrator.")
+			log.Error("Invalid active index", "index", groupConfig.ActiveIndex)
+			return c.Send(i18n.T(lang, "test.config_error"))
 		}
 
 		// Get active verification parameters
 		params := groupConfig.VerificationParams[groupConfig.ActiveIndex]
 
-		// Determine the type of current verification
-		verificationType := "unknown"
-		if queryType, ok := params.Query["type"].(string); ok {
-			verificationType = queryType
-		}
+		// Determine the label of current verification
+		verificationType := params.DisplayName()
 
 		// Generate a test request for verification
-		jsonData, err := auth.GenerateAuthRequest(userID, params)
+		ctx, span := startVerifySpan(bot, c, userID)
+		defer span.End()
+		span.SetAttributes(tracing.AttrGroupID.Int64(groupChatID))
+
+		session, err := tenantOf(bot).GenerateAuthRequest(ctx, userID, groupChatID, adminUser.JoinedAt, params)
 		if err != nil {
-			log.Printf("Bot handler log:(TestVerificationHandler) - Error generating auth request: %v", err)
-			return c.Send("Failed to generate verification request. Please try again later.")
+			log.Error("Error generating auth request", "error", err)
+			tracing.RecordError(span, err)
+			return c.Send(i18n.T(lang, "verify.request_failed"))
 		}
 
-		base64Data := base64.StdEncoding.EncodeToString(jsonData)
-		deepLink := fmt.Sprintf("https://wallet.privado.id/#i_m=%s", base64Data)
-
 		btn := telebot.InlineButton{
-			Text: fmt.Sprintf("Test verify (%s)", verificationType),
-			URL:  deepLink,
+			Text: i18n.T(lang, "test.button", verificationType),
+			URL:  tenantOf(bot).VerificationPageURL(session.ID),
 		}
 
 		// Creating markup with a button
@@ -819,17 +1157,17 @@ func TestVerificationHandler(bot *telebot.Bot) func(c telebot.Context) error {
 		inlineKeyboard.InlineKeyboard = [][]telebot.InlineButton{{btn}}
 
 		// Send a message with a link for test verification
-		_, err = bot.Send(c.Sender(), "Please test verify your age by clicking the link below:", inlineKeyboard)
+		_, err = bot.Send(c.Sender(), i18n.T(lang, "test.prompt", verificationType), inlineKeyboard)
 		if err != nil {
-			log.Printf("Bot handler log:(TestVerificationHandler) - Error sending verification message: %v", err)
-			return c.Send("Failed to send verification link. Please check your private messages.")
+			log.Error("Error sending verification message", "error", err)
+			return c.Send(i18n.T(lang, "test.send_failed"))
 		}
 
 		if c.Chat().Type == telebot.ChatSuperGroup || c.Chat().Type == telebot.ChatGroup {
-			msg, err := bot.Send(c.Chat(), "A verification link has been sent to your private messages. Please check your inbox.")
+			msg, err := bot.Send(c.Chat(), i18n.T(groupLang(store, groupChatID, c.Sender().LanguageCode), "test.sent"))
 			//msg, err := c.Send("A verification link has been sent to your private messages. Please check your inbox.")
 			if err != nil {
-				log.Printf("Error sending group message: %v", err)
+				log.Error("Error sending group message", "error", err)
 				return err
 			}
 
@@ -837,7 +1175,7 @@ func TestVerificationHandler(bot *telebot.Bot) func(c telebot.Context) error {
 			go func() {
 				time.Sleep(1 * time.Minute)
 				if err := bot.Delete(msg); err != nil {
-					log.Printf("Error deleting message: %v", err)
+					log.Warn("Error deleting message", "error", err)
 				}
 			}()
 		}
@@ -848,38 +1186,57 @@ func TestVerificationHandler(bot *telebot.Bot) func(c telebot.Context) error {
 
 // heandler for /verified_users_list
 func VerifiedUsersListHeandler(bot *telebot.Bot) func(c telebot.Context) error {
+	store := storeOf(bot)
 	return func(c telebot.Context) error {
 		userID := c.Sender().ID
+		lang := langOf(bot, c)
 
-		targetChatGroupID, err := storage_db.GetIdGroupFromGroupSetupState(userID)
+		targetChatGroupID, err := store.GetIdGroupFromGroupSetupState(userID)
 		if err != nil {
-			log.Println("Bot handler log:(VerifiedUsersListHeandler) - Bot handlers log: Group not set up for user:", userID)
-			return c.Send("You need to set up a group for verification.")
+			loggerFor(c).Debug("Group not set up for user")
+			return c.Send(i18n.T(lang, "common.need_setup_group"))
 		}
 
 		// Get chat data
 		chat, err := bot.ChatByID(targetChatGroupID)
 		if err != nil {
-			log.Printf("Bot handler log:(VerifiedUsersListHeandler) - Error fetching chat: %v", err)
-			return c.Send("Failed to fetch chat information.")
+			loggerFor(c).Error("Error fetching chat", "group_id", targetChatGroupID, "error", err)
+			return c.Send(i18n.T(lang, "common.chat_info_failed"))
 		}
 		targetChatGroupName := chat.Title
 
-		verifiedUsers, err := storage_db.GetVerifiedUsersList(targetChatGroupID)
+		verifiedUsers, err := store.GetVerifiedUsersList(targetChatGroupID)
 		if err != nil {
-			log.Printf("Bot handler log:(VerifiedUsersListHeandler) - Bot handlers log: Error fetching verified users list: %v", err)
+			loggerFor(c).Error("Error fetching verified users list", "group_id", targetChatGroupID, "error", err)
 		}
 
 		// If the list for the group is empty or the group does not exist
 		if err !=nil || len(verifiedUsers) == 0 {
-			return c.Send(fmt.Sprintf("No verified users in the group '%s'.", targetChatGroupName))
+			return c.Send(i18n.T(lang, "verified.none", targetChatGroupName))
 		}
 		
+		// Show the names admins gave to the params instead of raw credential types
+		typeLabels := make(map[string]string)
+		if groupConfig, err := store.GetGroupConfigParams(targetChatGroupID); err == nil {
+			for _, params := range groupConfig.VerificationParams {
+				if _, ok := typeLabels[params.Type()]; !ok && params.Name != "" {
+					typeLabels[params.Type()] = params.Name
+				}
+			}
+		}
+
 		// Forming a message with a list of verified users
-		msg := fmt.Sprintf("Verified users in the group '%s':\n\n", targetChatGroupName)
+		msg := i18n.T(lang, "verified.list", targetChatGroupName)
 		for _, verifiedUser := range verifiedUsers {
 			// Combine all verification types into a comma-separated string
-			types := strings.Join(verifiedUser.TypesVerification, ", ")
+			labels := make([]string, 0, len(verifiedUser.TypesVerification))
+			for _, verificationType := range verifiedUser.TypesVerification {
+				if label, ok := typeLabels[verificationType]; ok {
+					verificationType = label
+				}
+				labels = append(labels, verificationType)
+			}
+			types := strings.Join(labels, ", ")
 			msg += fmt.Sprintf("@%s - %s\n", verifiedUser.User.UserName, types)
 		}
 
@@ -890,54 +1247,67 @@ func VerifiedUsersListHeandler(bot *telebot.Bot) func(c telebot.Context) error {
 
 // Handler for delering all verified users /delete_all_verified_users for the group
 func DeleteAllVerifiedUsersHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	store := storeOf(bot)
 	return func(c telebot.Context) error {
 		userID := c.Sender().ID
+		lang := langOf(bot, c)
 
-		targetChatGroupID, err := storage_db.GetIdGroupFromGroupSetupState(userID)
+		targetChatGroupID, err := store.GetIdGroupFromGroupSetupState(userID)
 		if err != nil {
-			log.Println("Bot handler log:(DeleteAllVerifiedUsersHandler) - Bot handlers log: Group not set up for user:", userID)
-			return c.Send("You need to set up a group for verification.")
+			loggerFor(c).Debug("Group not set up for user")
+			return c.Send(i18n.T(lang, "common.need_setup_group"))
 		}
 
 		// Get chat data
 		chat, err := bot.ChatByID(targetChatGroupID)
 		if err != nil {
-			log.Printf("Bot handler log:(DeleteAllVerifiedUsersHandler) - Error fetching chat: %v", err)
-			return c.Send("Failed to fetch chat information.")
+			loggerFor(c).Error("Error fetching chat", "group_id", targetChatGroupID, "error", err)
+			return c.Send(i18n.T(lang, "common.chat_info_failed"))
 		}
 		targetChatGroupName := chat.Title
 
-		verifiedUsers, err := storage_db.GetVerifiedUsersList(targetChatGroupID)
+		verifiedUsers, err := store.GetVerifiedUsersList(targetChatGroupID)
 		if err != nil {
-			log.Printf("Bot handler log:(DeleteAllVerifiedUsersHandler) - Bot handlers log: Error fetching verified users list: %v", err)
+			loggerFor(c).Error("Error fetching verified users list", "group_id", targetChatGroupID, "error", err)
 		}
 
 		// If the list for the group is empty or the group does not exist
 		if err != nil || len(verifiedUsers) == 0 {
-			return c.Send(fmt.Sprintf("No verified users in the group '%s'.", targetChatGroupName))
+			return c.Send(i18n.T(lang, "verified.none", targetChatGroupName))
 		}
 
-		storage_db.DeleteAllVerifiedUsers(targetChatGroupID)
+		store.DeleteAllVerifiedUsers(targetChatGroupID)
 
-		return c.Send(fmt.Sprintf("All verified users have been deleted for the group '%s'.", targetChatGroupName))
+		for _, verifiedUser := range verifiedUsers {
+			webhooks.Publish(store, webhooks.Event{
+				Type:     webhooks.EventVerificationRevoked,
+				GroupID:  targetChatGroupID,
+				UserID:   verifiedUser.User.ID,
+				Username: verifiedUser.User.UserName,
+			})
+		}
+
+		return c.Send(i18n.T(lang, "verified.deleted", targetChatGroupName))
 	}
 }
 
 // AddVerificationParamsHandler handles adding verification parameters in a single step /add_verification_params
 func AddVerificationParamsHandler(bot *telebot.Bot) func(c telebot.Context) error {
+    store := storeOf(bot)
     return func(c telebot.Context) error {
         userID := c.Sender().ID
+        lang := langOf(bot, c)
 
-		groupChatID, err := storage_db.GetIdGroupFromGroupSetupState(userID)
+		groupChatID, err := store.GetIdGroupFromGroupSetupState(userID)
 		if err != nil {
-			log.Println("Bot handler log:(AddVerificationParamsHandler) - Bot handlers log: Group not set up for user:", userID)
-			return c.Send("You are not associated with any group. Use /setup first.")
+			loggerFor(c).Debug("Group not set up for user")
+			return c.Send(i18n.T(lang, "common.no_group"))
 		}
 
         groupChat, _ := bot.ChatByID(groupChatID)
         if groupChat == nil {
-            log.Printf("Bot handler log:(AddVerificationParamsHandler) - Failed to fetch group chat by ID: %d", groupChatID)
-            return c.Send("Failed to fetch the group chat. Please try again.")
+            loggerFor(c).Warn("Failed to fetch group chat", "group_id", groupChatID)
+            return c.Send(i18n.T(lang, "common.group_chat_failed"))
         }
 
         // Request verification parameters
@@ -951,118 +1321,133 @@ func AddVerificationParamsHandler(bot *telebot.Bot) func(c telebot.Context) erro
             "    \"credentialSubject\": {\n" +
             "      \"birthday\": {\"$lt\": 20000101}\n" +
             "    }\n" +
-            "  }\n" +
+            "  },\n" +
+            "  \"name\": \"18+ age check\",\n" +
+            "  \"description\": \"Prove that you are over 18 without revealing your birthday.\"\n" +
             "}"
-        return c.Send("Please send verification parameters in JSON format. Example:\n\n" + exampleJSON)
+        if err := c.Send(i18n.T(lang, "params.send_json", exampleJSON)); err != nil {
+            return err
+        }
+
+        // Ready-made presets for admins who don't want to write the query by hand
+        return sendPresetsKeyboard(bot, c, groupChatID)
     }
 }
 
 // Handler deleting all verification parameters for a group /delete_all_verification_params
 func DeleteAllVerificationParamsHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	store := storeOf(bot)
 	return func(c telebot.Context) error {
 		userID := c.Sender().ID
+		lang := langOf(bot, c)
 
-		groupChatID, err := storage_db.GetIdGroupFromGroupSetupState(userID)
+		groupChatID, err := store.GetIdGroupFromGroupSetupState(userID)
 		if err != nil {
-			log.Println("Bot handler log:(DeleteAllVerificationParamsHandler) - Bot handlers log: Bot handlers log: Group not set up for user:", userID)
-			return c.Send("You are not associated with any group. Use /setup first.")
+			loggerFor(c).Debug("Group not set up for user")
+			return c.Send(i18n.T(lang, "common.no_group"))
 		}
 
 		groupChat, _ := bot.ChatByID(groupChatID)
 		if groupChat == nil {
-			log.Printf("Bot handler log:(DeleteAllVerificationParamsHandler) - Failed to fetch group chat by ID: %d", groupChatID)
-			return c.Send("Failed to fetch the group chat. Please try again.")
+			loggerFor(c).Warn("Failed to fetch group chat", "group_id", groupChatID)
+			return c.Send(i18n.T(lang, "common.group_chat_failed"))
 		}
 
-		storage_db.DeleteAllVerificationParams(groupChatID)
+		store.DeleteAllVerificationParams(groupChatID)
 
 		// Notify the user
-		return c.Send("All verification parameters have been successfully cleared for this group.")
+		return c.Send(i18n.T(lang, "params.cleared"))
 	}
 }
 
 
 // ListVerificationParamsHandler displays the list of added verification parameters /list_verification_params
 func ListVerificationParamsHandler(bot *telebot.Bot) func(c telebot.Context) error {
+    store := storeOf(bot)
     return func(c telebot.Context) error {
         userID := c.Sender().ID
+        lang := langOf(bot, c)
 
-		groupChatID, err := storage_db.GetIdGroupFromGroupSetupState(userID)
+		groupChatID, err := store.GetIdGroupFromGroupSetupState(userID)
 		if err != nil {
-			log.Println("Bot handler log:(ListVerificationParamsHandler) - Group not set up for user:", userID)
-			return c.Send("You are not associated with any group. Use /setup first.")
+			loggerFor(c).Debug("Group not set up for user")
+			return c.Send(i18n.T(lang, "common.no_group"))
 		}
 
-		groupConfig, err := storage_db.GetGroupConfigParams(groupChatID)
+		groupConfig, err := store.GetGroupConfigParams(groupChatID)
 		if err != nil || len(groupConfig.VerificationParams) == 0 {
-			log.Println("Bot handler log:(ListVerificationParamsHandler) - No verification parameters found for group:", groupChatID)
-			return c.Send("No verification parameters have been added yet. Use /add_verification_params to add one.")
+			loggerFor(c).Debug("No verification parameters found", "group_id", groupChatID)
+			return c.Send(i18n.T(lang, "params.none"))
 		}
 
         // Fetch restriction type
         restrictionType := groupConfig.RestrictionType
         if restrictionType == "" {
-            restrictionType = "Not set"
+            restrictionType = i18n.T(lang, "common.not_set")
         }
 
         // Build the response
         var response strings.Builder
-        response.WriteString("*Verification parameters for the group:*\n\n")
+        response.WriteString(i18n.T(lang, "params.list_title"))
         for i, param := range groupConfig.VerificationParams {
             activeMarker := ""
             if i == groupConfig.ActiveIndex {
-                activeMarker = " (active)"
-            }
-
-            // Extract the "type" field from the "query"
-            queryType := "unknown" // Default value in case "type" is missing
-            if query, ok := param.Query["type"]; ok {
-                if queryStr, ok := query.(string); ok {
-                    queryType = queryStr
-                }
+                activeMarker = i18n.T(lang, "common.active")
             }
 
             // Add the type header
-            response.WriteString(fmt.Sprintf("*Type:* `%s`%s\n\n", queryType, activeMarker))
+            // Names, descriptions and queries are set by the admins, so they are escaped
+            response.WriteString(i18n.T(lang, "params.type_line", html.EscapeString(param.Type()), activeMarker))
+            if param.Name != "" {
+                response.WriteString(i18n.T(lang, "params.name_line", html.EscapeString(param.Name)))
+            }
+            response.WriteString("\n")
 
             // Convert the parameter to JSON with indentation
             formattedJSON, err := json.MarshalIndent(param, "", "    ")
             if err != nil {
-                log.Printf("Bot handler log:(ListVerificationParamsHandler) - Failed to format JSON for param %d: %v", i+1, err)
-                response.WriteString("Error formatting JSON\n\n")
+                loggerFor(c).Error("Failed to format JSON of params", "group_id", groupChatID, "index", i, "error", err)
+                response.WriteString(i18n.T(lang, "params.format_error"))
                 continue
             }
 
             // Add the JSON as a code block
-            response.WriteString("```\n")
-            response.WriteString(string(formattedJSON))
-            response.WriteString("\n```\n\n")
+            response.WriteString("<pre>")
+            response.WriteString(html.EscapeString(string(formattedJSON)))
+            response.WriteString("</pre>\n\n")
         }
 
         // Add the restriction type information
-        response.WriteString("*Restriction type for new members:* ")
-        response.WriteString(fmt.Sprintf("`%s`", restrictionType))
+        response.WriteString(i18n.T(lang, "params.restriction_line"))
+        response.WriteString(fmt.Sprintf("<code>%s</code>", html.EscapeString(restrictionType)))
 
         // Send the list to the admin
-        return c.Send(response.String(), telebot.ModeMarkdown)
+        if err := c.Send(response.String(), telebot.ModeHTML); err != nil {
+            return err
+        }
+
+        // Inline buttons to edit, duplicate, delete, move and rename single parameters
+        return sendParamsManagementList(bot, c, groupChatID)
     }
 }
 
 // Handler to switch active verification parameters /set_active_verification_params
 func SetActiveVerificationParamsHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	store := storeOf(bot)
 	return func(c telebot.Context) error {
 		userID := c.Sender().ID
+		lang := langOf(bot, c)
 		var groupChatID int64
 
 		// Determine where the handler was called: in a group or in a private chat
 		if c.Chat().Type == telebot.ChatPrivate {
-			groupID, err := storage_db.GetIdGroupFromGroupSetupState(userID)
+			groupID, err := store.GetIdGroupFromGroupSetupState(userID)
 			if err != nil {
-				log.Println("Bot handler log:(SetActiveVerificationParamsHandler) - Group not set up for user:", userID)
-				return c.Send("You need to specify a group for verification setup.")
+				loggerFor(c).Debug("Group not set up for user")
+				return c.Send(i18n.T(lang, "common.need_group_verification"))
 			} else if groupID == 0 {
-				log.Println("Bot handler log:(SetActiveVerificationParamsHandler) - Id group = 0. Group not set up for user:", userID)
-				return c.Send("You need to specify a group for verification setup.")
+				loggerFor(c).Debug("Group not set up for user")
+				return c.Send(i18n.T(lang, "common.need_group_verification"))
 			} else {
 				groupChatID = groupID
 			}
@@ -1072,28 +1457,28 @@ func SetActiveVerificationParamsHandler(bot *telebot.Bot) func(c telebot.Context
 
 		// Check if the user is an administrator of the group
 		if !isAdmin(bot, groupChatID, userID) {
-			return c.Send("You are not an administrator in this group.")
+			return c.Send(i18n.T(lang, "common.not_admin"))
 		}
 
-		groupConfig, err := storage_db.GetGroupConfigParams(groupChatID)
+		groupConfig, err := store.GetGroupConfigParams(groupChatID)
 		if err != nil {
-			log.Printf("Bot handler log:(SetActiveVerificationParamsHandler) - Error fetching group configuration: %v", err)
-			return c.Send("No verification parameters have been set for this group. Error fetching group configuration")
+			loggerFor(c).Warn("Error fetching group configuration", "group_id", groupChatID, "error", err)
+			return c.Send(i18n.T(lang, "params.config_failed"))
 		} else if len(groupConfig.VerificationParams) == 0 {
-			return c.Send("No verification parameters have been set for this group.")
+			return c.Send(i18n.T(lang, "params.not_set"))
 		}
 
 		// If there is only one verification parameter, notify the admin
 		if len(groupConfig.VerificationParams) == 1 {
-			return c.Send("Only one verification type is available. Switching is not possible.")
+			return c.Send(i18n.T(lang, "params.only_one"))
 		}
 
 		// Generate buttons for all verification types
 		inlineKeyboard := &telebot.ReplyMarkup{}
 		for i, param := range groupConfig.VerificationParams {
-			text := fmt.Sprintf("%d. %s", i+1, param.Query["type"])
+			text := fmt.Sprintf("%d. %s", i+1, param.DisplayName())
 			if i == groupConfig.ActiveIndex {
-				text += " (active)"
+				text += i18n.T(lang, "common.active")
 			}
 
 			btn := telebot.InlineButton{
@@ -1108,28 +1493,22 @@ func SetActiveVerificationParamsHandler(bot *telebot.Bot) func(c telebot.Context
 				// Validate the index
 				if index < 0 || index >= len(groupConfig.VerificationParams) {
 					return c.Respond(&telebot.CallbackResponse{
-						Text: "Invalid selection.",
+						Text: i18n.T(lang, "params.invalid_selection"),
 					})
 				}
 
 				// If the selected index is already active, notify the admin
 				if groupConfig.ActiveIndex == index {
-					_, err := bot.Send(c.Sender(), fmt.Sprintf(
-						"The selected verification type '%s' is already active.",
-						groupConfig.VerificationParams[index].Query["type"],
-					))
+					_, err := bot.Send(c.Sender(), i18n.T(lang, "params.already_active", groupConfig.VerificationParams[index].DisplayName()))
 					return err
 				}
 
-				storage_db.SetActiveVerificationParams(groupChatID, index)
+				store.SetActiveVerificationParams(groupChatID, index)
 
 				// Notify the admin of the change
-				typeStr, ok := groupConfig.VerificationParams[index].Query["type"].(string)
-				if !ok {
-					typeStr = "Unknown type"
-				}
+				typeStr := groupConfig.VerificationParams[index].DisplayName()
 
-				bot.Send(c.Sender(), fmt.Sprintf("Verification type '%s' has been set as active.", typeStr))
+				bot.Send(c.Sender(), i18n.T(lang, "params.activated", typeStr))
 
 				// Respond to the callback to clear the loading state on the button
 				return c.Respond()
@@ -1139,15 +1518,15 @@ func SetActiveVerificationParamsHandler(bot *telebot.Bot) func(c telebot.Context
 		}
 
 		// Send the list of options to the admin
-		return c.Send("Select the verification type to activate:", inlineKeyboard)
+		return c.Send(i18n.T(lang, "params.select_active"), inlineKeyboard)
 	}
 }
 
 
-func checkUserAsAdminInGroup(userID, groupID int64) bool {
-	groupIdByUser, err := storage_db.GetIdGroupFromGroupSetupState(userID)
+func checkUserAsAdminInGroup(store *storage_db.Store, userID, groupID int64) bool {
+	groupIdByUser, err := store.GetIdGroupFromGroupSetupState(userID)
 	if err != nil {
-		log.Println("Bot handler log:(checkUserAsAdminInGroup) - Group not set up for user:", userID)
+		logger.Debug("Group not set up for user", "user_id", userID)
 		return false
 	}
 	if groupIdByUser == groupID {
@@ -1157,11 +1536,11 @@ func checkUserAsAdminInGroup(userID, groupID int64) bool {
 	}
 }
 
-func GetAuthTokenFromAdmin(groupID int64, userID int64) (string, bool) {
+func GetAuthTokenFromAdmin(store *storage_db.Store, groupID int64, userID int64) (string, bool) {
 	DataMutex.Lock()
 	defer DataMutex.Unlock()
 
-	users, err := storage_db.GetVerifiedUsersList(groupID)
+	users, err := store.GetVerifiedUsersList(groupID)
 	if err != nil {
 		return "", false // Group not found
 	}
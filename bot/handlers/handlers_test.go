@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/auth"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+
+	"gopkg.in/telebot.v3"
+)
+
+// testStore is opened in a temporary database, the tests use their own users and groups in it
+var testStore *storage_db.Store
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "handlers")
+	if err != nil {
+		panic(err)
+	}
+
+	if err := storage_db.InitDB(filepath.Join(dir, "test.db")); err != nil {
+		panic(err)
+	}
+	if testStore, err = storage_db.Open("handlers"); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	storage_db.CloseChanges()
+	storage_db.CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// fakeAPI answers the Bot API methods like Telegram and records which ones were called
+type fakeAPI struct {
+	mutex   sync.Mutex
+	methods []string
+}
+
+func (api *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := path.Base(r.URL.Path)
+
+	api.mutex.Lock()
+	api.methods = append(api.methods, method)
+	api.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch method {
+	case "getChatMember":
+		w.Write([]byte(`{"ok":true,"result":{"status":"member","user":{"id":1}}}`))
+	case "sendMessage", "editMessageText":
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"date":0,"chat":{"id":1,"type":"private"}}}`))
+	case "createChatInviteLink":
+		w.Write([]byte(`{"ok":true,"result":{"invite_link":"https://t.me/+test","member_limit":1}}`))
+	default:
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}
+}
+
+// called reports whether the Bot API method was called
+func (api *fakeAPI) called(method string) bool {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+	return slices.Contains(api.methods, method)
+}
+
+// newTestBot returns a bot of the test store that talks to a fake Bot API
+func newTestBot(t *testing.T) (*telebot.Bot, *fakeAPI) {
+	t.Helper()
+
+	api := &fakeAPI{}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	bot, err := telebot.NewBot(telebot.Settings{URL: server.URL, Token: "test", Offline: true, Synchronous: true})
+	if err != nil {
+		t.Fatalf("NewBot: %v", err)
+	}
+	Register(bot, &auth.Tenant{Store: testStore})
+	return bot, api
+}
+
+// groupContext returns the context of a message of the user in the group
+func groupContext(bot *telebot.Bot, groupID, userID int64) telebot.Context {
+	return bot.NewContext(telebot.Update{
+		ID: 1,
+		Message: &telebot.Message{
+			ID:     10,
+			Chat:   &telebot.Chat{ID: groupID, Type: telebot.ChatSuperGroup, Title: "Test group"},
+			Sender: &telebot.User{ID: userID},
+			Text:   "hello",
+		},
+	})
+}
+
+// joinAndPost lets the member join the group and post a message, it reports whether the message was deleted
+func joinAndPost(t *testing.T, bot *telebot.Bot, api *fakeAPI, groupID, userID int64) bool {
+	t.Helper()
+
+	if err := welcomeMember(bot, groupContext(bot, groupID, userID), telebot.User{ID: userID}); err != nil {
+		t.Fatalf("welcomeMember: %v", err)
+	}
+	if err := handleGroupMessage(bot, groupContext(bot, groupID, userID), userID); err != nil {
+		t.Fatalf("handleGroupMessage: %v", err)
+	}
+	return api.called("deleteMessage")
+}
+
+func TestVerifiedApplicantCanPost(t *testing.T) {
+	bot, api := newTestBot(t)
+	const groupID, userID = -100, 1001
+	testStore.AddRestrictionType(groupID, "delete")
+
+	// The callback verified the applicant before the join request was approved
+	testStore.AddOrUpdateUser(userID, &storage_db.UserVerification{
+		UserID:      userID,
+		GroupID:     groupID,
+		Verified:    true,
+		JoinRequest: true,
+	})
+	drainChanges()
+
+	if joinAndPost(t, bot, api, groupID, userID) {
+		t.Error("message of the verified member was deleted")
+	}
+	// The verification was handled when it passed, the join must not be handled as another one
+	if events := drainChanges(); len(events) != 0 {
+		t.Errorf("join published %d change events, want none", len(events))
+	}
+
+	user, err := testStore.GetUser(userID)
+	if err != nil {
+		t.Fatalf("record of the verified member is gone: %v", err)
+	}
+	if !user.Verified || user.IsPending || user.JoinRequest {
+		t.Errorf("record = %+v, want verified, not pending and no join request", user)
+	}
+}
+
+func TestPendingMemberCannotPost(t *testing.T) {
+	bot, api := newTestBot(t)
+	const groupID, userID = -101, 1002
+	testStore.AddRestrictionType(groupID, "delete")
+
+	testStore.AddOrUpdateUser(userID, &storage_db.UserVerification{
+		UserID:    userID,
+		GroupID:   groupID,
+		IsPending: true,
+	})
+	drainChanges()
+
+	if err := handleGroupMessage(bot, groupContext(bot, groupID, userID), userID); err != nil {
+		t.Fatalf("handleGroupMessage: %v", err)
+	}
+	if !api.called("deleteMessage") {
+		t.Error("message of the pending member was kept")
+	}
+}
+
+func TestJoinKeepsVerificationOfAnotherGroup(t *testing.T) {
+	bot, api := newTestBot(t)
+	const groupID, otherGroupID, userID = -102, -103, 1003
+
+	testStore.AddOrUpdateUser(userID, &storage_db.UserVerification{
+		UserID:    userID,
+		GroupID:   otherGroupID,
+		IsPending: true,
+	})
+	drainChanges()
+
+	if err := welcomeMember(bot, groupContext(bot, groupID, userID), telebot.User{ID: userID}); err != nil {
+		t.Fatalf("welcomeMember: %v", err)
+	}
+
+	user, err := testStore.GetUser(userID)
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if user.GroupID != otherGroupID || !user.IsPending {
+		t.Errorf("record = %+v, want the pending verification of group %d", user, otherGroupID)
+	}
+	if !api.called("kickChatMember") {
+		t.Error("member wasn't let out of the group")
+	}
+}
+
+// drainChanges returns the change events published by the store so far
+func drainChanges() []storage_db.UserChangeEvent {
+	var events []storage_db.UserChangeEvent
+	for {
+		select {
+		case event := <-testStore.Changes():
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestInvitedMemberCanPost(t *testing.T) {
+	bot, api := newTestBot(t)
+	const groupID, userID = -104, 1004
+	testStore.AddRestrictionType(groupID, "delete")
+
+	// The user passed the verification for an invite link and gets the link
+	user := &storage_db.UserVerification{
+		UserID:   userID,
+		GroupID:  groupID,
+		Verified: true,
+		Invite:   true,
+	}
+	testStore.AddOrUpdateUser(userID, user)
+	drainChanges()
+	handleUserChange(bot, storage_db.UserChangeEvent{UserID: userID, Data: user})
+
+	if !api.called("createChatInviteLink") {
+		t.Fatal("no invite link was issued")
+	}
+	if _, err := testStore.GetUser(userID); err != nil {
+		t.Fatalf("record of the invited user is gone: %v", err)
+	}
+
+	if joinAndPost(t, bot, api, groupID, userID) {
+		t.Error("message of the invited member was deleted")
+	}
+	if events := drainChanges(); len(events) != 0 {
+		t.Errorf("join published %d change events, want none", len(events))
+	}
+
+	link, err := testStore.GetInviteLink(groupID, userID)
+	if err != nil || link.UsedAt.IsZero() {
+		t.Errorf("invite link = %+v (%v), want it used", link, err)
+	}
+	if user, err := testStore.GetUser(userID); err != nil || !user.Verified || user.IsPending || user.Invite {
+		t.Errorf("record = %+v (%v), want verified, not pending and no invite", user, err)
+	}
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+	"github.com/ArtemHvozdov/tg-auth-bot/tracing"
+
+	"gopkg.in/telebot.v3"
+)
+
+// invitePayloadPrefix starts the /start payload of the invite deep links
+const invitePayloadPrefix = "invite_"
+
+// inviteLinkTTL is how long the invite links sent to the verified users stay valid
+var inviteLinkTTL atomic.Int64
+
+// SetInviteLinkTTL sets the validity of the invite links sent to the verified users. It's called again on reload.
+func SetInviteLinkTTL(ttl time.Duration) {
+	inviteLinkTTL.Store(int64(ttl))
+}
+
+// getInviteLinkTTL returns the validity of the invite links, an hour if it isn't set
+func getInviteLinkTTL() time.Duration {
+	if ttl := time.Duration(inviteLinkTTL.Load()); ttl > 0 {
+		return ttl
+	}
+	return time.Hour
+}
+
+// inviteDeepLink returns the link that opens the bot and starts the verification of the invite policy
+func inviteDeepLink(bot *telebot.Bot, token string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s%s", bot.Me.Username, invitePayloadPrefix, token)
+}
+
+// Handler for /invite_link, "/invite_link revoke" disables the link
+func InviteLinkHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	store := storeOf(bot)
+	return func(c telebot.Context) error {
+		groupChatID, ok := getAdminGroup(bot, c)
+		if !ok {
+			return nil
+		}
+		lang := langOf(bot, c)
+		log := loggerFor(c).With("group_id", groupChatID)
+
+		if strings.TrimSpace(c.Message().Payload) == "revoke" {
+			err := store.RevokeInvitePolicy(groupChatID)
+			if errors.Is(err, storage_db.ErrNotFound) {
+				return c.Send(i18n.T(lang, "invite.none"))
+			}
+			if err != nil {
+				log.Error("Error revoking invite policy", "error", err)
+				return c.Send(i18n.T(lang, "invite.revoke_failed"))
+			}
+			return c.Send(i18n.T(lang, "invite.revoked"))
+		}
+
+		if _, err := store.GetActiveVerificationParams(groupChatID); err != nil {
+			return c.Send(i18n.T(lang, "invite.not_configured"))
+		}
+
+		policy, err := store.CreateInvitePolicy(groupChatID, c.Sender().ID)
+		if err != nil {
+			log.Error("Error creating invite policy", "error", err)
+			return c.Send(i18n.T(lang, "invite.create_failed"))
+		}
+
+		links, err := store.ListInviteLinks(groupChatID)
+		if err != nil {
+			log.Warn("Error listing invite links", "error", err)
+		}
+		used := 0
+		for _, link := range links {
+			if !link.UsedAt.IsZero() {
+				used++
+			}
+		}
+
+		return c.Send(i18n.T(lang, "invite.link", inviteDeepLink(bot, policy.Token), int(getInviteLinkTTL().Minutes()), len(links), used))
+	}
+}
+
+// inChat reports whether the member is in the chat, restricted members may have left it
+func inChat(member *telebot.ChatMember) bool {
+	switch member.Role {
+	case telebot.Creator, telebot.Administrator, telebot.Member:
+		return true
+	case telebot.Restricted:
+		return member.Member
+	}
+	return false
+}
+
+// startInvite starts the verification of the user who opened the invite deep link of a group
+func startInvite(bot *telebot.Bot, c telebot.Context, token string) error {
+	store := storeOf(bot)
+	user := c.Sender()
+	log := loggerFor(c)
+
+	policy, err := store.GetInvitePolicy(token)
+	if err != nil {
+		log.Info("Unknown invite token", "error", err)
+		return c.Send(i18n.T(langOf(bot, c), "invite.invalid"))
+	}
+	groupID := policy.GroupID
+	log = log.With("group_id", groupID)
+	lang := memberLang(store, user.LanguageCode, groupID)
+
+	group, err := bot.ChatByID(groupID)
+	if err != nil {
+		log.Warn("Error fetching the group of the invite", "error", err)
+		return c.Send(i18n.T(lang, "invite.invalid"))
+	}
+
+	if member, err := bot.ChatMemberOf(group, user); err == nil && inChat(member) {
+		return c.Send(i18n.T(lang, "invite.already_member", group.Title))
+	}
+
+	// A link that is still valid is sent again instead of creating another one
+	if link, err := store.GetInviteLink(groupID, user.ID); err == nil && link.Usable(time.Now()) {
+		return sendInviteLink(bot, user, lang, link)
+	}
+
+	existing, err := store.GetUser(user.ID)
+	restart := err == nil && existing.IsPending && existing.Invite && existing.GroupID == groupID
+	if err == nil && existing.IsPending && !restart {
+		// The record of the user is shared by their verifications, the pending one is finished first
+		return c.Send(i18n.T(lang, "invite.busy"))
+	}
+
+	userData := existing
+	if !restart {
+		userData = &storage_db.UserVerification{
+			UserID:       user.ID,
+			Username:     user.Username,
+			GroupID:      groupID,
+			GroupName:    group.Title,
+			IsPending:    true,
+			JoinedAt:     time.Now(),
+			LanguageCode: user.LanguageCode,
+			Invite:       true,
+		}
+		if err := store.AddOrUpdateUser(user.ID, userData); err != nil {
+			log.Error("Error saving invited user", "error", err)
+			return err
+		}
+
+		log.Info("Invite requested", "username", user.Username)
+		scheduleVerificationTimeout(bot, user.ID, groupID, userData.JoinedAt)
+	}
+
+	ctx, span := startVerifySpan(bot, c, user.ID)
+	defer span.End()
+	span.SetAttributes(tracing.AttrGroupID.Int64(groupID))
+
+	if err := c.Send(i18n.T(lang, "verify.intro_invite", user.Username, group.Title)); err != nil {
+		return err
+	}
+
+	return sendVerificationLink(bot, ctx, loggerFor(c), user, userData, lang)
+}
+
+// issueInviteLink creates a single-use invite link to the group of the verified user and sends it to them
+func issueInviteLink(bot *telebot.Bot, log *slog.Logger, data *storage_db.UserVerification, lang string) error {
+	store := storeOf(bot)
+	user := &telebot.User{ID: data.UserID}
+	expiresAt := time.Now().Add(getInviteLinkTTL())
+
+	created, err := bot.CreateInviteLink(&telebot.Chat{ID: data.GroupID}, &telebot.ChatInviteLink{
+		Name:           fmt.Sprintf("Verified %d", data.UserID),
+		MemberLimit:    1,
+		ExpireUnixtime: expiresAt.Unix(),
+	})
+	if err != nil {
+		log.Error("Failed to create the invite link", "error", err)
+		bot.Send(user, i18n.T(lang, "invite.link_failed"))
+		return err
+	}
+
+	link := storage_db.InviteLink{
+		GroupID:   data.GroupID,
+		UserID:    data.UserID,
+		Username:  data.Username,
+		Link:      created.InviteLink,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	if err := store.SaveInviteLink(link); err != nil {
+		log.Warn("Error saving the invite link", "error", err)
+	}
+	log.Info("Invite link issued", "username", data.Username, "expires_at", expiresAt)
+
+	return sendInviteLink(bot, user, lang, link)
+}
+
+// sendInviteLink sends the invite link with a button to join the group
+func sendInviteLink(bot *telebot.Bot, to telebot.Recipient, lang string, link storage_db.InviteLink) error {
+	btn := telebot.InlineButton{
+		Text: i18n.T(lang, "invite.join_button"),
+		URL:  link.Link,
+	}
+
+	_, err := bot.Send(
+		to,
+		i18n.T(lang, "invite.issued", link.ExpiresAt.UTC().Format(deadlineLayout)),
+		&telebot.ReplyMarkup{InlineKeyboard: [][]telebot.InlineButton{{btn}}},
+	)
+	return err
+}
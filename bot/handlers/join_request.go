@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+	"github.com/ArtemHvozdov/tg-auth-bot/metrics"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+	"github.com/ArtemHvozdov/tg-auth-bot/tracing"
+	"github.com/ArtemHvozdov/tg-auth-bot/webhooks"
+	"go.opentelemetry.io/otel/trace"
+
+	"gopkg.in/telebot.v3"
+)
+
+// Handler for join requests: the applicant gets the verification link privately,
+// the request is approved once they pass the verification and declined if they fail or run out of time
+func JoinRequestHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	store := storeOf(bot)
+	return func(c telebot.Context) error {
+		request := c.ChatJoinRequest()
+		applicant := request.Sender
+		groupID := request.Chat.ID
+		log := loggerFor(c)
+
+		ctx, span := tracing.Tracer.Start(context.Background(), "telegram.joinRequest", trace.WithAttributes(
+			tracing.AttrUpdateID.Int(c.Update().ID),
+			tracing.AttrGroupID.Int64(groupID),
+		))
+		defer span.End()
+
+		// Without verification params the request is left to the admins
+		if _, err := store.GetActiveVerificationParams(groupID); err != nil {
+			log.Info("Verification is not configured, leaving the join request to the admins")
+			return nil
+		}
+		// The chat of the request reaches the applicant even if they never started the bot
+		var to telebot.Recipient = applicant
+		if request.UserChatID != 0 {
+			to = telebot.ChatID(request.UserChatID)
+		}
+		lang := memberLang(store, applicant.LanguageCode, groupID)
+
+		// The record of the user is shared by their verifications, the pending one for another group is finished first
+		if existing, err := store.GetUser(applicant.ID); err == nil && existing.IsPending && existing.GroupID != groupID {
+			log.Info("Applicant is verifying for another group, declining the join request", "pending_group_id", existing.GroupID)
+			if err := bot.DeclineJoinRequest(request.Chat, applicant); err != nil {
+				log.Warn("Failed to decline the join request", "error", err)
+			}
+			_, err := bot.Send(to, i18n.T(lang, "verify.busy_join", request.Chat.Title))
+			return err
+		}
+		stateOf(bot).joinSpans.Store(applicant.ID, span.SpanContext())
+
+		userData := &storage_db.UserVerification{
+			UserID:       applicant.ID,
+			Username:     applicant.Username,
+			GroupID:      groupID,
+			GroupName:    request.Chat.Title,
+			IsPending:    true,
+			JoinedAt:     time.Now(),
+			LanguageCode: applicant.LanguageCode,
+			JoinRequest:  true,
+		}
+		if err := store.AddOrUpdateUser(applicant.ID, userData); err != nil {
+			log.Error("Error saving applicant", "error", err)
+			tracing.RecordError(span, err)
+			return err
+		}
+
+		log.Info("Join request received", "username", applicant.Username)
+
+		metrics.Joins.WithLabelValues(metrics.Group(groupID)).Inc()
+		webhooks.Publish(store, webhooks.Event{
+			Type:     webhooks.EventJoinRequested,
+			GroupID:  groupID,
+			UserID:   applicant.ID,
+			Username: applicant.Username,
+		})
+
+		scheduleVerificationTimeout(bot, applicant.ID, groupID, userData.JoinedAt)
+
+		if _, err := bot.Send(to, i18n.T(lang, "verify.intro_join", applicant.Username, request.Chat.Title)); err != nil {
+			// The applicant can still start the bot and call /verify
+			log.Warn("Error sending verification to applicant", "error", err)
+			tracing.RecordError(span, err)
+			return nil
+		}
+
+		return sendVerificationLink(bot, ctx, log, to, userData, lang)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+
+	"gopkg.in/telebot.v3"
+)
+
+// memberLang returns the language of the messages sent to a user of the group:
+// the language of their Telegram app if there is a catalog for it, the default language of the group otherwise
+func memberLang(store *storage_db.Store, languageCode string, groupID int64) string {
+	if lang := i18n.Match(languageCode); lang != "" {
+		return lang
+	}
+	if groupID != 0 {
+		if lang := store.GetGroupLanguage(groupID); lang != "" {
+			return lang
+		}
+	}
+	return i18n.Default
+}
+
+// groupLang returns the language of the messages posted in the group, the default language of the group wins
+// over the language of the user the message is about
+func groupLang(store *storage_db.Store, groupID int64, languageCode string) string {
+	if lang := store.GetGroupLanguage(groupID); lang != "" {
+		return lang
+	}
+	if lang := i18n.Match(languageCode); lang != "" {
+		return lang
+	}
+	return i18n.Default
+}
+
+// langOf returns the language of the messages sent to the sender of the update
+func langOf(bot *telebot.Bot, c telebot.Context) string {
+	sender := c.Sender()
+	if sender == nil {
+		return i18n.Default
+	}
+	if lang := i18n.Match(sender.LanguageCode); lang != "" {
+		return lang
+	}
+
+	// The group default applies in the group itself and in the private chat about it
+	store := storeOf(bot)
+	var groupID int64
+	if chat := c.Chat(); chat != nil && chat.Type != telebot.ChatPrivate {
+		groupID = chat.ID
+	} else if user, err := store.GetUser(sender.ID); err == nil && user.IsPending {
+		groupID = user.GroupID
+	} else {
+		groupID, _ = store.GetIdGroupFromGroupSetupState(sender.ID)
+	}
+
+	return memberLang(store, "", groupID)
+}
+
+// languageList lists the supported languages for the admins, e.g. "en (English), uk (Українська)"
+func languageList() string {
+	var languages []string
+	for _, lang := range i18n.Languages() {
+		languages = append(languages, fmt.Sprintf("%s (%s)", lang, i18n.Name(lang)))
+	}
+	return strings.Join(languages, ", ")
+}
+
+// Handler for /set_language, "/set_language default" resets the language of the group
+func SetLanguageHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	store := storeOf(bot)
+	return func(c telebot.Context) error {
+		groupChatID, ok := getAdminGroup(bot, c)
+		if !ok {
+			return nil
+		}
+
+		lang := langOf(bot, c)
+		payload := strings.ToLower(strings.TrimSpace(c.Message().Payload))
+
+		switch {
+		case payload == "":
+			current := i18n.T(lang, "language.not_set", i18n.Name(i18n.Default))
+			if groupLanguage := store.GetGroupLanguage(groupChatID); groupLanguage != "" {
+				current = i18n.Name(groupLanguage)
+			}
+			return c.Send(i18n.T(lang, "language.current", current, languageList()))
+
+		case payload == "default":
+			if err := store.SetGroupLanguage(groupChatID, ""); err != nil {
+				loggerFor(c).Error("Error resetting group language", "group_id", groupChatID, "error", err)
+				return c.Send(i18n.T(lang, "language.failed"))
+			}
+			loggerFor(c).Info("Group language reset", "group_id", groupChatID)
+			return c.Send(i18n.T(lang, "language.reset", i18n.Name(i18n.Default)))
+
+		case !i18n.IsSupported(payload):
+			return c.Send(i18n.T(lang, "language.unsupported", payload, languageList()))
+		}
+
+		if err := store.SetGroupLanguage(groupChatID, payload); err != nil {
+			loggerFor(c).Error("Error setting group language", "group_id", groupChatID, "error", err)
+			return c.Send(i18n.T(lang, "language.failed"))
+		}
+
+		loggerFor(c).Info("Group language set", "group_id", groupChatID, "language", payload)
+		return c.Send(i18n.T(lang, "language.set", i18n.Name(payload)))
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"log/slog"
+
+	"gopkg.in/telebot.v3"
+)
+
+// logger is replaced by main with SetLogger
+var logger = slog.Default()
+
+// SetLogger sets the logger of the handlers
+func SetLogger(l *slog.Logger) {
+	logger = l.With("component", "handlers")
+}
+
+// loggerFor returns the logger with the IDs of the update: the update itself, the sender and the group
+func loggerFor(c telebot.Context) *slog.Logger {
+	args := []any{"update_id", c.Update().ID}
+	if sender := c.Sender(); sender != nil {
+		args = append(args, "user_id", sender.ID)
+	}
+	// The ID of a private chat is the user ID, only groups are added
+	if chat := c.Chat(); chat != nil && chat.Type != telebot.ChatPrivate {
+		args = append(args, "group_id", chat.ID)
+	}
+	return logger.With(args...)
+}
@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+
+	"gopkg.in/telebot.v3"
+)
+
+// Maximum length of a verification params name and description
+const (
+	maxParamsNameLength        = 64
+	maxParamsDescriptionLength = 500
+)
+
+// paramsButtonText returns the text of a button for the params at index
+func paramsButtonText(lang string, groupConfig storage_db.GroupVerificationConfig, index int) string {
+	params := groupConfig.VerificationParams[index]
+
+	text := fmt.Sprintf("%d. %s", index+1, params.Type())
+	if params.Name != "" {
+		text = fmt.Sprintf("%d. %s (%s)", index+1, params.Name, params.Type())
+	}
+	if index == groupConfig.ActiveIndex {
+		text += i18n.T(lang, "common.active")
+	}
+	return text
+}
+
+// paramsFingerprint identifies the list of params the buttons were built for. It's passed as their data,
+// so a button doesn't act on whatever params took its index after the list changed.
+func paramsFingerprint(groupConfig storage_db.GroupVerificationConfig) string {
+	data, _ := json.Marshal(groupConfig.VerificationParams)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// currentParams loads the params of the group and reports whether the list still has the fingerprint
+func currentParams(store *storage_db.Store, groupChatID int64, index int, fingerprint string) (storage_db.GroupVerificationConfig, bool) {
+	groupConfig, err := store.GetGroupConfigParams(groupChatID)
+	if err != nil || index >= len(groupConfig.VerificationParams) || paramsFingerprint(groupConfig) != fingerprint {
+		return groupConfig, false
+	}
+	return groupConfig, true
+}
+
+// paramsManagementKeyboard builds the inline list of params, a button per params opens its actions
+func paramsManagementKeyboard(bot *telebot.Bot, lang string, groupChatID int64, groupConfig storage_db.GroupVerificationConfig) *telebot.ReplyMarkup {
+	keyboard := &telebot.ReplyMarkup{}
+	fingerprint := paramsFingerprint(groupConfig)
+
+	for i := range groupConfig.VerificationParams {
+		index := i
+		btn := telebot.InlineButton{
+			Text:   paramsButtonText(lang, groupConfig, index),
+			Unique: fmt.Sprintf("param_open_%d_%d", groupChatID, index),
+			Data:   fingerprint,
+		}
+
+		bot.Handle(&btn, func(c telebot.Context) error {
+			c.Respond()
+			return showParamsActions(bot, c, groupChatID, index, c.Data())
+		})
+
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []telebot.InlineButton{btn})
+	}
+
+	return keyboard
+}
+
+// sendParamsManagementList sends the inline list of params to the admin
+func sendParamsManagementList(bot *telebot.Bot, c telebot.Context, groupChatID int64) error {
+	store := storeOf(bot)
+	groupConfig, err := store.GetGroupConfigParams(groupChatID)
+	if err != nil || len(groupConfig.VerificationParams) == 0 {
+		return nil
+	}
+
+	lang := langOf(bot, c)
+	return c.Send(i18n.T(lang, "params.select"), paramsManagementKeyboard(bot, lang, groupChatID, groupConfig))
+}
+
+// refreshParamsManagementList replaces the current message with the up to date list of params
+func refreshParamsManagementList(bot *telebot.Bot, c telebot.Context, groupChatID int64, notice string) error {
+	store := storeOf(bot)
+	lang := langOf(bot, c)
+	groupConfig, err := store.GetGroupConfigParams(groupChatID)
+	if err != nil || len(groupConfig.VerificationParams) == 0 {
+		return c.Edit(notice + "\n\n" + i18n.T(lang, "params.none_left"))
+	}
+
+	return c.Edit(notice+"\n\n"+i18n.T(lang, "params.select"), paramsManagementKeyboard(bot, lang, groupChatID, groupConfig))
+}
+
+// showParamsActions replaces the list with the actions for the params at index.
+// The buttons carry the fingerprint of the list, each of them checks that the params didn't change before acting.
+func showParamsActions(bot *telebot.Bot, c telebot.Context, groupChatID int64, index int, fingerprint string) error {
+	store := storeOf(bot)
+	lang := langOf(bot, c)
+	if !isAdmin(bot, groupChatID, c.Sender().ID) {
+		return c.Send(i18n.T(lang, "common.not_admin"))
+	}
+
+	groupConfig, ok := currentParams(store, groupChatID, index, fingerprint)
+	if !ok {
+		return c.Edit(i18n.T(lang, "params.gone"))
+	}
+
+	button := func(text, action string) telebot.InlineButton {
+		return telebot.InlineButton{
+			Text:   i18n.T(lang, text),
+			Unique: fmt.Sprintf("param_%s_%d_%d", action, groupChatID, index),
+			Data:   fingerprint,
+		}
+	}
+
+	btnEdit := button("params.button_edit", "edit")
+	btnDuplicate := button("params.button_duplicate", "dup")
+	btnDelete := button("params.button_delete", "del")
+	btnDeleteConfirm := button("params.button_delete_confirm", "delyes")
+	btnDeleteCancel := button("params.button_cancel", "delno")
+	btnUp := button("params.button_up", "up")
+	btnDown := button("params.button_down", "down")
+	btnRename := button("params.button_rename", "rename")
+	btnDescribe := button("params.button_description", "desc")
+	btnBack := button("params.button_back", "back")
+
+	// askInput waits for the admin to send the new value of the params
+	askInput := func(c telebot.Context, kind string, text func(storage_db.VerificationParams) string, opts ...interface{}) error {
+		c.Respond()
+		groupConfig, ok := currentParams(store, groupChatID, index, c.Data())
+		if !ok {
+			return c.Edit(i18n.T(lang, "params.gone"))
+		}
+
+		setPendingInput(bot, c.Sender().ID, pendingInput{Kind: kind, GroupID: groupChatID, Index: index, Fingerprint: c.Data()})
+		return c.Send(text(groupConfig.VerificationParams[index]), opts...)
+	}
+
+	bot.Handle(&btnEdit, func(c telebot.Context) error {
+		return askInput(c, inputEditParams, func(params storage_db.VerificationParams) string {
+			formattedJSON, _ := json.MarshalIndent(params, "", "    ")
+			return i18n.T(lang, "params.send_new_json", index+1, html.EscapeString(string(formattedJSON)))
+		}, telebot.ModeHTML)
+	})
+
+	bot.Handle(&btnRename, func(c telebot.Context) error {
+		return askInput(c, inputRenameParams, func(storage_db.VerificationParams) string {
+			return i18n.T(lang, "params.send_name", index+1)
+		})
+	})
+
+	bot.Handle(&btnDescribe, func(c telebot.Context) error {
+		return askInput(c, inputDescribeParams, func(storage_db.VerificationParams) string {
+			return i18n.T(lang, "params.send_description", index+1)
+		})
+	})
+
+	bot.Handle(&btnDuplicate, func(c telebot.Context) error {
+		return runParamsAction(bot, c, groupChatID, index, func() (string, error) {
+			return i18n.T(lang, "params.duplicated", index+1), store.DuplicateVerificationParams(groupChatID, index)
+		})
+	})
+
+	// Deleting asks for a confirmation first
+	bot.Handle(&btnDelete, func(c telebot.Context) error {
+		c.Respond()
+		groupConfig, ok := currentParams(store, groupChatID, index, c.Data())
+		if !ok {
+			return c.Edit(i18n.T(lang, "params.gone"))
+		}
+
+		text := i18n.T(lang, "params.confirm_delete", paramsButtonText(lang, groupConfig, index))
+		keyboard := &telebot.ReplyMarkup{InlineKeyboard: [][]telebot.InlineButton{{btnDeleteConfirm, btnDeleteCancel}}}
+		return c.Edit(text, keyboard)
+	})
+
+	bot.Handle(&btnDeleteConfirm, func(c telebot.Context) error {
+		return runParamsAction(bot, c, groupChatID, index, func() (string, error) {
+			activeDeleted, err := store.DeleteVerificationParams(groupChatID, index)
+			if err != nil || !activeDeleted {
+				return i18n.T(lang, "params.deleted", index+1), err
+			}
+
+			// Tell the admin which params new members are verified with now
+			groupConfig, err := store.GetGroupConfigParams(groupChatID)
+			if err != nil || groupConfig.ActiveIndex < 0 || groupConfig.ActiveIndex >= len(groupConfig.VerificationParams) {
+				return i18n.T(lang, "params.deleted", index+1), nil
+			}
+			active := groupConfig.VerificationParams[groupConfig.ActiveIndex]
+			return i18n.T(lang, "params.deleted_active", index+1, groupConfig.ActiveIndex+1, active.DisplayName()), nil
+		})
+	})
+
+	bot.Handle(&btnDeleteCancel, func(c telebot.Context) error {
+		c.Respond()
+		return showParamsActions(bot, c, groupChatID, index, c.Data())
+	})
+
+	bot.Handle(&btnUp, func(c telebot.Context) error {
+		return runParamsAction(bot, c, groupChatID, index, func() (string, error) {
+			return i18n.T(lang, "params.moved_up", index+1), store.MoveVerificationParams(groupChatID, index, -1)
+		})
+	})
+
+	bot.Handle(&btnDown, func(c telebot.Context) error {
+		return runParamsAction(bot, c, groupChatID, index, func() (string, error) {
+			return i18n.T(lang, "params.moved_down", index+1), store.MoveVerificationParams(groupChatID, index, 1)
+		})
+	})
+
+	bot.Handle(&btnBack, func(c telebot.Context) error {
+		c.Respond()
+		return refreshParamsManagementList(bot, c, groupChatID, i18n.T(lang, "params.list_header"))
+	})
+
+	keyboard := &telebot.ReplyMarkup{InlineKeyboard: [][]telebot.InlineButton{
+		{btnEdit, btnRename, btnDescribe},
+		{btnDuplicate, btnDelete},
+		{btnUp, btnDown},
+		{btnBack},
+	}}
+
+	text := i18n.T(lang, "params.item", paramsButtonText(lang, groupConfig, index))
+	if description := groupConfig.VerificationParams[index].Description; description != "" {
+		text += "\n" + description
+	}
+
+	return c.Edit(text+"\n\n"+i18n.T(lang, "params.choose_action"), keyboard)
+}
+
+// runParamsAction runs a storage update for the params at index if the list is still the one of the button,
+// and shows the refreshed list with the notice returned by the action
+func runParamsAction(bot *telebot.Bot, c telebot.Context, groupChatID int64, index int, action func() (string, error)) error {
+	lang := langOf(bot, c)
+	if !isAdmin(bot, groupChatID, c.Sender().ID) {
+		return c.Respond(&telebot.CallbackResponse{Text: i18n.T(lang, "common.not_admin")})
+	}
+
+	if _, ok := currentParams(storeOf(bot), groupChatID, index, c.Data()); !ok {
+		c.Respond()
+		return c.Edit(i18n.T(lang, "params.gone"))
+	}
+
+	notice, err := action()
+	if err != nil {
+		loggerFor(c).Warn("Error updating verification params", "group_id", groupChatID, "error", err)
+		return c.Respond(&telebot.CallbackResponse{Text: i18n.T(lang, "common.failed", err)})
+	}
+
+	c.Respond()
+	return refreshParamsManagementList(bot, c, groupChatID, notice)
+}
+
+// handleEditParamsInput replaces the params with the JSON sent by the admin
+func handleEditParamsInput(bot *telebot.Bot, c telebot.Context, input pendingInput) error {
+	store := storeOf(bot)
+	lang := langOf(bot, c)
+	groupChatID, index := input.GroupID, input.Index
+	if !isAdmin(bot, groupChatID, c.Sender().ID) {
+		return c.Send(i18n.T(lang, "common.not_admin"))
+	}
+
+	// The params may have been changed since the admin was asked
+	groupConfig, ok := currentParams(store, groupChatID, index, input.Fingerprint)
+	if !ok {
+		return c.Send(i18n.T(lang, "params.gone"))
+	}
+
+	params, errMsg := parseVerificationParams(lang, c.Text())
+	if errMsg != "" {
+		// Keep waiting for a valid JSON
+		setPendingInput(bot, c.Sender().ID, input)
+		return c.Send(errMsg)
+	}
+
+	// Keep the name and description unless the new JSON sets them
+	if params.Name == "" {
+		params.Name = groupConfig.VerificationParams[index].Name
+	}
+	if params.Description == "" {
+		params.Description = groupConfig.VerificationParams[index].Description
+	}
+
+	if err := store.UpdateVerificationParams(groupChatID, index, params); err != nil {
+		loggerFor(c).Warn("Error updating verification params", "group_id", groupChatID, "error", err)
+		return c.Send(i18n.T(lang, "params.update_failed", index+1, err))
+	}
+
+	if err := c.Send(i18n.T(lang, "params.updated", index+1)); err != nil {
+		return err
+	}
+	return sendParamsManagementList(bot, c, groupChatID)
+}
+
+// handleRenameParamsInput sets the name sent by the admin
+func handleRenameParamsInput(bot *telebot.Bot, c telebot.Context, input pendingInput) error {
+	store := storeOf(bot)
+	lang := langOf(bot, c)
+	groupChatID, index := input.GroupID, input.Index
+	if !isAdmin(bot, groupChatID, c.Sender().ID) {
+		return c.Send(i18n.T(lang, "common.not_admin"))
+	}
+
+	// The params may have been changed since the admin was asked
+	if _, ok := currentParams(store, groupChatID, index, input.Fingerprint); !ok {
+		return c.Send(i18n.T(lang, "params.gone"))
+	}
+
+	name := strings.TrimSpace(c.Text())
+	if name == "-" {
+		name = ""
+	}
+
+	if len([]rune(name)) > maxParamsNameLength {
+		setPendingInput(bot, c.Sender().ID, input)
+		return c.Send(i18n.T(lang, "params.name_too_long", maxParamsNameLength))
+	}
+
+	if err := store.RenameVerificationParams(groupChatID, index, name); err != nil {
+		loggerFor(c).Warn("Error renaming verification params", "group_id", groupChatID, "error", err)
+		return c.Send(i18n.T(lang, "params.rename_failed", index+1, err))
+	}
+
+	if err := c.Send(i18n.T(lang, "params.renamed", index+1)); err != nil {
+		return err
+	}
+	return sendParamsManagementList(bot, c, groupChatID)
+}
+
+// handleDescribeParamsInput sets the description sent by the admin
+func handleDescribeParamsInput(bot *telebot.Bot, c telebot.Context, input pendingInput) error {
+	store := storeOf(bot)
+	lang := langOf(bot, c)
+	groupChatID, index := input.GroupID, input.Index
+	if !isAdmin(bot, groupChatID, c.Sender().ID) {
+		return c.Send(i18n.T(lang, "common.not_admin"))
+	}
+
+	// The params may have been changed since the admin was asked
+	if _, ok := currentParams(store, groupChatID, index, input.Fingerprint); !ok {
+		return c.Send(i18n.T(lang, "params.gone"))
+	}
+
+	description := strings.TrimSpace(c.Text())
+	if description == "-" {
+		description = ""
+	}
+
+	if len([]rune(description)) > maxParamsDescriptionLength {
+		setPendingInput(bot, c.Sender().ID, input)
+		return c.Send(i18n.T(lang, "params.description_too_long", maxParamsDescriptionLength))
+	}
+
+	if err := store.DescribeVerificationParams(groupChatID, index, description); err != nil {
+		loggerFor(c).Warn("Error updating description", "group_id", groupChatID, "error", err)
+		return c.Send(i18n.T(lang, "params.describe_failed", index+1, err))
+	}
+
+	if err := c.Send(i18n.T(lang, "params.described", index+1)); err != nil {
+		return err
+	}
+	return sendParamsManagementList(bot, c, groupChatID)
+}
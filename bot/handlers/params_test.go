@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+
+	"gopkg.in/telebot.v3"
+)
+
+// callbackContext returns the context of a button with the data pressed by the user in the private chat
+func callbackContext(bot *telebot.Bot, userID int64, data string) telebot.Context {
+	return bot.NewContext(telebot.Update{
+		ID: 1,
+		Callback: &telebot.Callback{
+			ID:      "1",
+			Sender:  &telebot.User{ID: userID},
+			Message: &telebot.Message{ID: 20, Chat: &telebot.Chat{ID: userID, Type: telebot.ChatPrivate}},
+			Data:    data,
+		},
+	})
+}
+
+// saveTestParams saves params with the names to the group and returns the fingerprint of the list
+func saveTestParams(t *testing.T, groupID int64, names ...string) string {
+	t.Helper()
+
+	groupConfig := storage_db.GroupVerificationConfig{}
+	for i, name := range names {
+		groupConfig.VerificationParams = append(groupConfig.VerificationParams, storage_db.VerificationParams{
+			CircuitID: "credentialAtomicQuerySigV2",
+			ID:        uint32(i + 1),
+			Query:     map[string]interface{}{"type": "KYC"},
+			Name:      name,
+		})
+	}
+	if err := testStore.SaveGroupConfig(groupID, groupConfig); err != nil {
+		t.Fatalf("SaveGroupConfig: %v", err)
+	}
+	return paramsFingerprint(groupConfig)
+}
+
+func TestParamsButtonOfChangedList(t *testing.T) {
+	bot, _ := newTestBot(t)
+	const groupID, adminID = -110, 1010
+	SetBotAdmins([]int64{adminID})
+	t.Cleanup(func() { SetBotAdmins(nil) })
+
+	fingerprint := saveTestParams(t, groupID, "a", "b")
+
+	// Another admin deleted the first params, the button of #1 now points at "b"
+	if _, err := testStore.DeleteVerificationParams(groupID, 0); err != nil {
+		t.Fatalf("DeleteVerificationParams: %v", err)
+	}
+
+	err := runParamsAction(bot, callbackContext(bot, adminID, fingerprint), groupID, 0, func() (string, error) {
+		t.Error("action ran for the params of a changed list")
+		return "", nil
+	})
+	if err != nil {
+		t.Fatalf("runParamsAction: %v", err)
+	}
+
+	// The admin was asked for a new name before the list changed
+	input := pendingInput{Kind: inputRenameParams, GroupID: groupID, Index: 0, Fingerprint: fingerprint}
+	c := bot.NewContext(telebot.Update{ID: 2, Message: &telebot.Message{
+		ID:     21,
+		Chat:   &telebot.Chat{ID: adminID, Type: telebot.ChatPrivate},
+		Sender: &telebot.User{ID: adminID},
+		Text:   "renamed",
+	}})
+	if err := handleRenameParamsInput(bot, c, input); err != nil {
+		t.Fatalf("handleRenameParamsInput: %v", err)
+	}
+
+	groupConfig, err := testStore.GetGroupConfigParams(groupID)
+	if err != nil {
+		t.Fatalf("GetGroupConfigParams: %v", err)
+	}
+	if len(groupConfig.VerificationParams) != 1 || groupConfig.VerificationParams[0].Name != "b" {
+		t.Errorf("params = %+v, want only the untouched \"b\"", groupConfig.VerificationParams)
+	}
+}
+
+func TestParamsButtonOfCurrentList(t *testing.T) {
+	bot, _ := newTestBot(t)
+	const groupID, adminID = -111, 1011
+	SetBotAdmins([]int64{adminID})
+	t.Cleanup(func() { SetBotAdmins(nil) })
+
+	fingerprint := saveTestParams(t, groupID, "a", "b")
+
+	ran := false
+	err := runParamsAction(bot, callbackContext(bot, adminID, fingerprint), groupID, 1, func() (string, error) {
+		ran = true
+		return "", nil
+	})
+	if err != nil {
+		t.Fatalf("runParamsAction: %v", err)
+	}
+	if !ran {
+		t.Error("action didn't run for the params of the current list")
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+	"github.com/ArtemHvozdov/tg-auth-bot/presets"
+
+	"gopkg.in/telebot.v3"
+)
+
+// sendPresetsKeyboard offers the built-in presets as inline buttons
+func sendPresetsKeyboard(bot *telebot.Bot, c telebot.Context, groupChatID int64) error {
+	lang := langOf(bot, c)
+	keyboard := &telebot.ReplyMarkup{}
+
+	for _, preset := range presets.All() {
+		preset := preset
+		btn := telebot.InlineButton{
+			Text:   preset.Title(lang),
+			Unique: fmt.Sprintf("preset_%s_%d", preset.Key, groupChatID),
+		}
+
+		bot.Handle(&btn, func(c telebot.Context) error {
+			c.Respond()
+
+			if !isAdmin(bot, groupChatID, c.Sender().ID) {
+				return c.Send(i18n.T(lang, "common.not_admin"))
+			}
+
+			input := pendingInput{Kind: inputPresetAnswer, GroupID: groupChatID, Preset: preset.Key}
+			setPendingInput(bot, c.Sender().ID, input)
+
+			return c.Send(fmt.Sprintf("%s\n%s\n\n%s", preset.Title(lang), preset.Description(lang), preset.Prompts[0].Text(lang)))
+		})
+
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []telebot.InlineButton{btn})
+	}
+
+	return c.Send(i18n.T(lang, "presets.pick"), keyboard)
+}
+
+// handlePresetAnswer records the admin's answer to the current prompt and saves the params when all are answered
+func handlePresetAnswer(bot *telebot.Bot, c telebot.Context, input pendingInput) error {
+	store := storeOf(bot)
+	lang := langOf(bot, c)
+	preset, ok := presets.Get(input.Preset)
+	if !ok {
+		loggerFor(c).Error("Unknown preset", "preset", input.Preset)
+		return c.Send(i18n.T(lang, "presets.gone"))
+	}
+
+	prompt := preset.Prompts[len(input.Answers)]
+	answer, err := prompt.Answer(c.Text())
+	if err != nil {
+		message := err.Error()
+		var answerErr *presets.AnswerError
+		if errors.As(err, &answerErr) {
+			message = answerErr.Text(lang)
+		}
+
+		// Ask the same question again
+		setPendingInput(bot, c.Sender().ID, input)
+		return c.Send(message + "\n\n" + prompt.Text(lang))
+	}
+
+	input.Answers = append(input.Answers, answer)
+	if len(input.Answers) < len(preset.Prompts) {
+		setPendingInput(bot, c.Sender().ID, input)
+		return c.Send(preset.Prompts[len(input.Answers)].Text(lang))
+	}
+
+	if !isAdmin(bot, input.GroupID, c.Sender().ID) {
+		return c.Send(i18n.T(lang, "common.not_admin"))
+	}
+
+	// The name and description of the params are shown to the members
+	params, err := preset.Build(groupLang(store, input.GroupID, c.Sender().LanguageCode), input.Answers)
+	if err != nil {
+		loggerFor(c).Info("Error building preset", "preset", preset.Key, "error", err)
+		return c.Send(i18n.T(lang, "presets.build_failed", err))
+	}
+
+	groupChat, err := bot.ChatByID(input.GroupID)
+	if err != nil {
+		loggerFor(c).Warn("Failed to fetch group chat", "group_id", input.GroupID, "error", err)
+		return c.Send(i18n.T(lang, "common.group_chat_failed"))
+	}
+
+	if err := store.SaveVerificationParams(input.GroupID, params); err != nil {
+		loggerFor(c).Error("Error saving verification params", "group_id", input.GroupID, "error", err)
+		return c.Send(i18n.T(lang, "presets.save_failed"))
+	}
+
+	loggerFor(c).Info("Preset added", "group_id", input.GroupID, "group_title", groupChat.Title, "preset", preset.Key)
+	bot.Send(c.Sender(), i18n.T(lang, "presets.added", params.DisplayName()))
+
+	return askRestrictionTypeIfMissing(bot, c, input.GroupID, groupChat.Title)
+}
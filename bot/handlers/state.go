@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"gopkg.in/telebot.v3"
+)
+
+// Kinds of free-form input the bot can wait for in a private chat
+const (
+	inputImportConfig    = "import_config"
+	inputEditParams      = "edit_params"
+	inputRenameParams    = "rename_params"
+	inputDescribeParams  = "describe_params"
+	inputPresetAnswer    = "preset_answer"
+	inputMessageTemplate = "message_template"
+)
+
+// pendingInput describes what the next private message of an admin is expected to contain
+type pendingInput struct {
+	Kind    string
+	GroupID int64
+	Index   int // Index of the verification params, if the input is about one of them
+
+	Fingerprint string // Fingerprint of the list of params the index points into
+
+	// Preset being filled in and the answers given so far
+	Preset  string
+	Answers []string
+
+	Template string // Kind of the message template the text is for
+}
+
+// newPreviewID returns a random ID of a preview with confirmation buttons. The buttons of a group share one handler,
+// so the ID is passed as their data and the handler refuses the buttons of an older preview.
+func newPreviewID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// setPendingInput makes the next private message of the user to the bot be handled as the given input
+func setPendingInput(bot *telebot.Bot, userID int64, input pendingInput) {
+	state := stateOf(bot)
+	state.pendingInputsMutex.Lock()
+	defer state.pendingInputsMutex.Unlock()
+
+	state.pendingInputs[userID] = input
+}
+
+// takePendingInput returns and forgets the input the user was asked for
+func takePendingInput(bot *telebot.Bot, userID int64) (pendingInput, bool) {
+	state := stateOf(bot)
+	state.pendingInputsMutex.Lock()
+	defer state.pendingInputsMutex.Unlock()
+
+	input, ok := state.pendingInputs[userID]
+	if ok {
+		delete(state.pendingInputs, userID)
+	}
+	return input, ok
+}
+
+// handlePendingInput dispatches a private message to the command that asked for it
+func handlePendingInput(bot *telebot.Bot, c telebot.Context, input pendingInput) error {
+	switch input.Kind {
+	case inputImportConfig:
+		return handleImportConfigInput(bot, c, input.GroupID)
+	case inputEditParams:
+		return handleEditParamsInput(bot, c, input)
+	case inputRenameParams:
+		return handleRenameParamsInput(bot, c, input)
+	case inputDescribeParams:
+		return handleDescribeParamsInput(bot, c, input)
+	case inputPresetAnswer:
+		return handlePresetAnswer(bot, c, input)
+	case inputMessageTemplate:
+		return previewMessageTemplate(bot, c, input.GroupID, input.Template, c.Text())
+	default:
+		loggerFor(c).Error("Unknown input kind", "kind", input.Kind)
+		return nil
+	}
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+
+	"gopkg.in/telebot.v3"
+)
+
+// Layout of the {deadline} placeholder
+const deadlineLayout = "2006-01-02 15:04 UTC"
+
+// templateValues are the values of the placeholders of the message templates
+type templateValues struct {
+	Username    string
+	Group       string
+	Requirement string
+	Deadline    time.Time
+}
+
+// memberValues returns the placeholder values for a member of the group
+func memberValues(store *storage_db.Store, lang string, user *storage_db.UserVerification) templateValues {
+	values := templateValues{
+		Group:       user.GroupName,
+		Requirement: i18n.T(lang, "welcome.requirement"),
+	}
+	if !user.JoinedAt.IsZero() {
+		values.Deadline = user.JoinedAt.Add(store.GetVerificationTimeout(user.GroupID))
+	}
+	if user.Username != "" {
+		values.Username = "@" + user.Username
+	}
+	if params, err := store.GetActiveVerificationParams(user.GroupID); err == nil {
+		values.Requirement = params.DisplayName()
+	}
+	return values
+}
+
+// render replaces the placeholders of the template with the values
+func (v templateValues) render(text string) string {
+	deadline := ""
+	if !v.Deadline.IsZero() {
+		deadline = v.Deadline.UTC().Format(deadlineLayout)
+	}
+
+	return strings.NewReplacer(
+		"{username}", v.Username,
+		"{group}", v.Group,
+		"{requirement}", v.Requirement,
+		"{deadline}", deadline,
+	).Replace(text)
+}
+
+// groupMessage returns the custom message of the group rendered with the values, or the default message if the group has none
+func groupMessage(store *storage_db.Store, groupID int64, kind string, values templateValues, defaultMessage string) string {
+	if text := store.GetGroupTemplate(groupID, kind); text != "" {
+		return values.render(text)
+	}
+	return defaultMessage
+}
+
+// Handler for /set_message, "/set_message <message> <text>" previews the new text and "/set_message <message> default" resets it
+func SetMessageHandler(bot *telebot.Bot) func(c telebot.Context) error {
+	store := storeOf(bot)
+	return func(c telebot.Context) error {
+		lang := langOf(bot, c)
+		if c.Chat().Type != telebot.ChatPrivate {
+			return c.Send(i18n.T(lang, "common.private_only"))
+		}
+
+		groupChatID, ok := getAdminGroup(bot, c)
+		if !ok {
+			return nil
+		}
+
+		groupConfig, _ := store.GetGroupConfigParams(groupChatID)
+		placeholders := strings.Join(storage_db.TemplatePlaceholders, ", ")
+
+		kind, text, _ := strings.Cut(strings.TrimSpace(c.Message().Payload), " ")
+		text = strings.TrimSpace(text)
+
+		switch {
+		case kind == "":
+			var lines []string
+			for _, kind := range storage_db.TemplateKinds {
+				state := i18n.T(lang, "template.default")
+				if groupConfig.Templates[kind] != "" {
+					state = i18n.T(lang, "template.custom")
+				}
+				lines = append(lines, fmt.Sprintf("%s: %s", kind, state))
+			}
+			return c.Send(i18n.T(lang, "template.usage", strings.Join(lines, "\n"), placeholders))
+
+		case !storage_db.IsTemplateKind(kind):
+			return c.Send(i18n.T(lang, "template.unknown", kind, strings.Join(storage_db.TemplateKinds, ", ")))
+
+		case text == "default":
+			if err := store.SetGroupTemplate(groupChatID, kind, ""); err != nil {
+				loggerFor(c).Error("Error resetting message template", "group_id", groupChatID, "template", kind, "error", err)
+				return c.Send(i18n.T(lang, "template.save_failed"))
+			}
+			loggerFor(c).Info("Message template reset", "group_id", groupChatID, "template", kind)
+			return c.Send(i18n.T(lang, "template.reset", kind))
+
+		case text != "":
+			return previewMessageTemplate(bot, c, groupChatID, kind, text)
+		}
+
+		// Ask for the text in the next message, it's easier to write a long one there
+		setPendingInput(bot, c.Sender().ID, pendingInput{Kind: inputMessageTemplate, GroupID: groupChatID, Template: kind})
+
+		if current := groupConfig.Templates[kind]; current != "" {
+			return c.Send(i18n.T(lang, "template.prompt", kind, placeholders, current))
+		}
+		return c.Send(i18n.T(lang, "template.prompt_default", kind, placeholders))
+	}
+}
+
+// previewMessageTemplate shows the message as the members will see it and asks for confirmation
+func previewMessageTemplate(bot *telebot.Bot, c telebot.Context, groupChatID int64, kind, text string) error {
+	store := storeOf(bot)
+	lang := langOf(bot, c)
+
+	if err := storage_db.ValidateMessageTemplate(text); err != nil {
+		// Keep waiting for a valid text
+		setPendingInput(bot, c.Sender().ID, pendingInput{Kind: inputMessageTemplate, GroupID: groupChatID, Template: kind})
+		return c.Send(i18n.T(lang, "template.invalid", err))
+	}
+
+	// The admin plays the member in the preview
+	sample := &storage_db.UserVerification{
+		Username: c.Sender().Username,
+		GroupID:  groupChatID,
+		JoinedAt: time.Now(),
+	}
+	if chat, err := bot.ChatByID(groupChatID); err == nil {
+		sample.GroupName = chat.Title
+	}
+	preview := memberValues(store, memberLang(store, c.Sender().LanguageCode, groupChatID), sample).render(text)
+
+	previewID := newPreviewID()
+	btnSave := telebot.InlineButton{
+		Text:   i18n.T(lang, "template.button_save"),
+		Unique: fmt.Sprintf("template_save_%d", groupChatID),
+		Data:   previewID,
+	}
+	btnCancel := telebot.InlineButton{
+		Text:   i18n.T(lang, "template.button_cancel"),
+		Unique: fmt.Sprintf("template_cancel_%d", groupChatID),
+		Data:   previewID,
+	}
+	keyboard := &telebot.ReplyMarkup{InlineKeyboard: [][]telebot.InlineButton{{btnSave, btnCancel}}}
+
+	bot.Handle(&btnSave, func(c telebot.Context) error {
+		if !isAdmin(bot, groupChatID, c.Sender().ID) {
+			return c.Respond(&telebot.CallbackResponse{Text: i18n.T(lang, "common.not_admin")})
+		}
+
+		// Only the latest preview of the group can be saved, an older one may be of another message
+		if c.Data() != previewID {
+			c.Respond()
+			return c.Edit(i18n.T(lang, "template.outdated"))
+		}
+
+		if err := store.SetGroupTemplate(groupChatID, kind, text); err != nil {
+			loggerFor(c).Error("Error saving message template", "group_id", groupChatID, "template", kind, "error", err)
+			return c.Respond(&telebot.CallbackResponse{Text: i18n.T(lang, "template.save_failed")})
+		}
+
+		loggerFor(c).Info("Message template saved", "group_id", groupChatID, "template", kind)
+		c.Respond()
+		return c.Edit(i18n.T(lang, "template.saved", kind))
+	})
+
+	bot.Handle(&btnCancel, func(c telebot.Context) error {
+		c.Respond()
+		return c.Edit(i18n.T(lang, "template.cancelled", kind))
+	})
+
+	return c.Send(i18n.T(lang, "template.preview", kind, preview), keyboard)
+}
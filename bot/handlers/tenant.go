@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/auth"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+
+	"gopkg.in/telebot.v3"
+)
+
+// botState is what the handlers keep for one of the bots hosted by the process
+type botState struct {
+	tenant *auth.Tenant
+
+	pendingInputs      map[int64]pendingInput
+	pendingInputsMutex sync.Mutex
+
+	// joinSpans holds the span contexts of the joins by user ID until the member is verified or removed
+	joinSpans sync.Map
+
+	// timeouts tracks the verification timeouts, timeoutsCtx is cancelled by StopTimeouts
+	timeouts       sync.WaitGroup
+	timeoutsMutex  sync.Mutex
+	timeoutsCtx    context.Context
+	cancelTimeouts context.CancelFunc
+}
+
+// states holds the state of every registered bot
+var states sync.Map
+
+// Register binds the bot to its tenant, the handlers of the bot use the store and the verification pages of the tenant.
+// It must be called before the handlers of the bot are added.
+func Register(bot *telebot.Bot, tenant *auth.Tenant) {
+	ctx, cancel := context.WithCancel(context.Background())
+	states.Store(bot, &botState{
+		tenant:         tenant,
+		pendingInputs:  make(map[int64]pendingInput),
+		timeoutsCtx:    ctx,
+		cancelTimeouts: cancel,
+	})
+}
+
+// StopTimeouts cancels the verification timeouts that are still waiting and waits for the ones being handled,
+// so none of them uses the store after it's closed. The members of the cancelled timeouts stay pending.
+func StopTimeouts(ctx context.Context, bot *telebot.Bot) error {
+	state := stateOf(bot)
+	state.timeoutsMutex.Lock()
+	state.cancelTimeouts()
+	state.timeoutsMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		state.timeouts.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stateOf returns the state of a registered bot
+func stateOf(bot *telebot.Bot) *botState {
+	state, ok := states.Load(bot)
+	if !ok {
+		panic("handlers: bot is not registered")
+	}
+	return state.(*botState)
+}
+
+// tenantOf returns the tenant of the bot
+func tenantOf(bot *telebot.Bot) *auth.Tenant {
+	return stateOf(bot).tenant
+}
+
+// storeOf returns the storage namespace of the bot
+func storeOf(bot *telebot.Bot) *storage_db.Store {
+	return stateOf(bot).tenant.Store
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/telebot.v3"
+)
+
+// startVerifySpan starts the trace of a verification, it links to the join of the member if there was one
+func startVerifySpan(bot *telebot.Bot, c telebot.Context, userID int64) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithAttributes(tracing.AttrUpdateID.Int(c.Update().ID)),
+	}
+	if joined, ok := stateOf(bot).joinSpans.Load(userID); ok {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: joined.(trace.SpanContext)}))
+	}
+	return tracing.Tracer.Start(context.Background(), "telegram.verify", opts...)
+}
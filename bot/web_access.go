@@ -0,0 +1,39 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/bot/handlers"
+
+	"gopkg.in/telebot.v3"
+)
+
+// webAccess gives the web server access to the bot, it implements web.Telegram
+type webAccess struct {
+	bot *telebot.Bot
+}
+
+func (a webAccess) IsGroupAdmin(groupID int64, userID int64) bool {
+	return handlers.IsGroupAdmin(a.bot, groupID, userID)
+}
+
+func (a webAccess) GroupTitle(groupID int64) string {
+	chat, err := a.bot.ChatByID(groupID)
+	if err != nil || chat.Title == "" {
+		return fmt.Sprint(groupID)
+	}
+	return chat.Title
+}
+
+func (a webAccess) BotUsername() string {
+	return a.bot.Me.Username
+}
+
+func (a webAccess) BotToken() string {
+	return a.bot.Token
+}
+
+func (a webAccess) Ping() error {
+	_, err := a.bot.Raw("getMe", nil)
+	return err
+}
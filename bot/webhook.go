@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/config"
+	"github.com/ArtemHvozdov/tg-auth-bot/web"
+
+	"gopkg.in/telebot.v3"
+)
+
+// WebhookPath is the route of the web server that receives Telegram updates in webhook mode
+const WebhookPath = "/api/telegram/webhook"
+
+// newPoller returns the poller for the configured update mode, the webhook of the bot is under its path prefix
+func (i *Instance) newPoller(cfg config.Config) telebot.Poller {
+	if cfg.BotMode != config.BotModeWebhook {
+		return &telebot.LongPoller{Timeout: 10 * time.Second}
+	}
+
+	path := i.Tenant.PathPrefix + WebhookPath
+	poller := &webhookPoller{
+		webhook: &telebot.Webhook{
+			SecretToken: cfg.WebhookSecret,
+			// The updates are served by our own web server, so the webhook doesn't listen by itself
+			Endpoint: &telebot.WebhookEndpoint{
+				PublicURL: strings.TrimRight(cfg.PublicURL, "/") + path,
+			},
+		},
+		ready: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	web.Handle(path, i.webhookHandler(poller, cfg.WebhookSecret))
+	return poller
+}
+
+// webhookPoller registers the webhook and hands the updates received by our web server to the bot.
+// telebot.Webhook can't be used for this: its handler blocks on the updates channel until Poll sets it.
+type webhookPoller struct {
+	webhook *telebot.Webhook
+
+	// updates is the channel the bot reads, it is set before ready is closed
+	updates chan<- telebot.Update
+	// ready is closed once the bot reads the updates, done once it stopped
+	ready chan struct{}
+	done  chan struct{}
+}
+
+// Poll registers the webhook and waits until the bot is stopped
+func (p *webhookPoller) Poll(b *telebot.Bot, dest chan telebot.Update, stop chan struct{}) {
+	if err := b.SetWebhook(p.webhook); err != nil {
+		b.OnError(fmt.Errorf("error setting webhook: %w", err), nil)
+		return
+	}
+
+	p.updates = dest
+	close(p.ready)
+
+	<-stop
+	close(p.done)
+}
+
+// webhookHandler checks the secret token and passes the update to the bot
+func (i *Instance) webhookHandler(poller *webhookPoller, secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		token := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			i.logger.Warn("Webhook request with invalid secret token rejected", "remote_addr", r.RemoteAddr)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Before the bot started nobody reads the updates, let Telegram deliver them again later
+		select {
+		case <-poller.ready:
+		default:
+			http.Error(w, "Bot is starting", http.StatusServiceUnavailable)
+			return
+		}
+
+		var update telebot.Update
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, "Invalid update", http.StatusBadRequest)
+			return
+		}
+
+		select {
+		case poller.updates <- update:
+		case <-poller.done:
+			// After StopBot the update is delivered again on the next start
+			http.Error(w, "Bot is shutting down", http.StatusServiceUnavailable)
+		case <-r.Context().Done():
+		}
+	})
+}
+
+// prepareUpdateMode removes a webhook left from a previous run when the bot uses long polling
+func (i *Instance) prepareUpdateMode(bot *telebot.Bot, cfg config.Config) error {
+	if cfg.BotMode == config.BotModeWebhook {
+		i.logger.Info("Bot receives updates via webhook", "path", i.Tenant.PathPrefix+WebhookPath)
+		return nil
+	}
+
+	if err := bot.RemoveWebhook(); err != nil {
+		return fmt.Errorf("error removing webhook: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+)
+
+// dbFileName is the name of the database in the data directory
+const dbFileName = "tg-bot.db"
+
+// defaultDBPath is the database in the default data directory, the commands take -db for another one
+const defaultDBPath = "./data/" + dbFileName
+
+const cliUsage = `Usage:
+  tg-auth-bot                      run the bot and the web server
+  tg-auth-bot backup [flags]       write a snapshot of the database
+  tg-auth-bot export [flags]       export a group's config and verified users to JSON
+  tg-auth-bot import [flags]       import a group exported with "export"
+
+Run "tg-auth-bot <command> -h" to see the flags of a command.
+`
+
+// runCommand runs a CLI subcommand and returns the exit code
+func runCommand(args []string) int {
+	var err error
+
+	switch args[0] {
+	case "backup":
+		err = backupCommand(args[1:])
+	case "export":
+		err = exportCommand(args[1:])
+	case "import":
+		err = importCommand(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(cliUsage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", args[0], cliUsage)
+		return 2
+	}
+
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	return 0
+}
+
+// backupCommand writes a single snapshot and prunes old ones
+func backupCommand(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	dbPath := fs.String("db", defaultDBPath, "path to the database file")
+	dir := fs.String("dir", "./data/backups", "directory for snapshots")
+	retention := fs.Int("retention", 0, "number of snapshots to keep (0 keeps all)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := openDB(*dbPath, false); err != nil {
+		return err
+	}
+	defer storage_db.CloseDB()
+
+	path, err := storage_db.CreateBackup(*dir)
+	if err != nil {
+		return err
+	}
+
+	if err := storage_db.PruneBackups(*dir, *retention); err != nil {
+		return err
+	}
+
+	fmt.Println("Backup written to", path)
+	return nil
+}
+
+// exportCommand writes a group's config and verified users as JSON
+func exportCommand(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	dbPath := fs.String("db", defaultDBPath, "path to the database file (a backup snapshot works too)")
+	groupID := fs.Int64("group", 0, "ID of the group to export")
+	namespace := fs.String("namespace", "", "name of the bot the group belongs to (default the single bot)")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *groupID == 0 {
+		return fmt.Errorf("-group is required")
+	}
+
+	if err := openDBReadOnly(*dbPath); err != nil {
+		return err
+	}
+	defer storage_db.CloseDB()
+
+	store, err := storage_db.Open(*namespace)
+	if err != nil {
+		return err
+	}
+
+	export, err := store.ExportGroup(*groupID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(*out, data, 0600); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Group %d exported to %s (%d verified users)\n", *groupID, *out, len(export.VerifiedUsers))
+	return nil
+}
+
+// importCommand reads a JSON export and writes it into the database
+func importCommand(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dbPath := fs.String("db", defaultDBPath, "path to the database file")
+	in := fs.String("in", "", "input file (default stdin)")
+	groupID := fs.Int64("group", 0, "import into this group ID instead of the exported one")
+	namespace := fs.String("namespace", "", "name of the bot to import the group into (default the single bot)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var data []byte
+	var err error
+	if *in == "" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*in)
+	}
+	if err != nil {
+		return err
+	}
+
+	var export storage_db.GroupExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return fmt.Errorf("invalid export file: %w", err)
+	}
+
+	if err := openDB(*dbPath, true); err != nil {
+		return err
+	}
+	defer storage_db.CloseDB()
+
+	store, err := storage_db.Open(*namespace)
+	if err != nil {
+		return err
+	}
+
+	if err := store.ImportGroup(export, *groupID); err != nil {
+		return err
+	}
+
+	target := *groupID
+	if target == 0 {
+		target = export.GroupID
+	}
+	fmt.Printf("Group %d imported (%d verification params, %d verified users)\n", target, len(export.Config.VerificationParams), len(export.VerifiedUsers))
+	return nil
+}
+
+// openDB opens the database for a subcommand, creating the file only when create is set
+func openDB(dbPath string, create bool) error {
+	if create {
+		if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+			return err
+		}
+	} else if _, err := os.Stat(dbPath); err != nil {
+		return err
+	}
+
+	if err := storage_db.InitDB(dbPath); err != nil {
+		return fmt.Errorf("failed to open database (is the bot still running?): %w", err)
+	}
+	return nil
+}
+
+// openDBReadOnly opens an existing database for a subcommand that only reads it, so snapshots are left untouched
+func openDBReadOnly(dbPath string) error {
+	if _, err := os.Stat(dbPath); err != nil {
+		return err
+	}
+
+	if err := storage_db.InitDBReadOnly(dbPath); err != nil {
+		return fmt.Errorf("failed to open database (is the bot still running?): %w", err)
+	}
+	return nil
+}
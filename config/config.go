@@ -1,45 +1,483 @@
+// Package config loads the configuration of the bot from a YAML file and the environment.
+// The environment variables override the values of the file, so secrets don't have to be stored in it.
 package config
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/logging"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+// DefaultFile is read when CONFIG_FILE is not set, it's optional
+const DefaultFile = "config.yaml"
+
 type Config struct {
-	TelegramToken string
-    InfuraKey string
-    NgrokURL string
-}
-
-func LoadConfig() Config {
-    // Downloading environment variables from .env file
-	if err := godotenv.Load(); err != nil {
-        log.Println("No .env file found")
-    }
-
-    // Getting the Telegram token
-    token := os.Getenv("TELEGRAM_TOKEN")
-    if token == "" {
-        panic("TELEGRAM_BOT_TOKEN is not set")
-    }
-
-    // Getting the Infura key
-    infuraKey := os.Getenv("INFURA_KEY")
-    if infuraKey == "" {
-        panic("INFURA_KEY is not set")
-    }
-
-    // Getting the Ngrok URL
-    ngrokURL := os.Getenv("NGROK_URL")
-    if ngrokURL == "" {
-        panic("NGROK_URL is not set")
-    }
-
-    return Config{
-        TelegramToken: token,
-        InfuraKey: infuraKey,
-        NgrokURL: ngrokURL,
-    }
+	TelegramToken string `yaml:"telegramToken"`
+	// Bots are the bots hosted by the process, telegramToken runs a single bot if the list is empty and is unused otherwise
+	Bots      []BotConfig `yaml:"bots"`
+	InfuraKey string      `yaml:"infuraKey"`
+	// PublicURL is the base URL of the web server, the wallets and the Telegram webhook call it
+	PublicURL string `yaml:"publicURL"`
+	// DataDir holds the database
+	DataDir string `yaml:"dataDir"`
+	// Admins are the Telegram user IDs of the bot operators, they manage every group like its admins
+	Admins          []int64            `yaml:"admins"`
+	Backup          BackupConfig       `yaml:"backup"`
+	BotMode         string             `yaml:"botMode"` // polling | webhook
+	WebhookSecret   string             `yaml:"webhookSecret"`
+	HTTP            HTTPConfig         `yaml:"http"`
+	Verifier        VerifierConfig     `yaml:"verifier"`
+	Verification    VerificationConfig `yaml:"verification"`
+	ShutdownTimeout time.Duration      `yaml:"shutdownTimeout"`
+	Log             LogConfig          `yaml:"log"`
+	TracingExporter string             `yaml:"tracingExporter"` // none | otlp | stdout
+}
+
+// BotConfig is one of the bots hosted by the process. The bots share the web server, the verifier and the database,
+// each one keeps its groups in its own namespace of the database and serves its pages under its own path prefix.
+type BotConfig struct {
+	// Name is the namespace of the bot in the database, the bot with the empty name uses the data of a single bot setup
+	Name          string `yaml:"name"`
+	TelegramToken string `yaml:"telegramToken"` // TELEGRAM_TOKEN_<NAME> overrides it
+	// VerifierDID is the audience of the auth requests of the bot, verifier.did if empty
+	VerifierDID string `yaml:"verifierDID"`
+	// PathPrefix is put before the routes of the bot, e.g. /brand-a, one bot may have the empty prefix
+	PathPrefix string `yaml:"pathPrefix"`
+}
+
+// BotList returns the bots to start: the listed ones or the single bot of telegramToken
+func (c Config) BotList() []BotConfig {
+	if len(c.Bots) > 0 {
+		return c.Bots
+	}
+	return []BotConfig{{TelegramToken: c.TelegramToken}}
+}
+
+// BackupConfig configures the scheduled snapshots of the database
+type BackupConfig struct {
+	Dir       string        `yaml:"dir"`      // backups in dataDir by default
+	Interval  time.Duration `yaml:"interval"` // 0 disables the scheduled snapshots
+	Retention int           `yaml:"retention"`
+}
+
+// LogConfig configures the logger
+type LogConfig struct {
+	Level          slog.Level `yaml:"level"`
+	Format         string     `yaml:"format"`         // text | json
+	RedactPersonal bool       `yaml:"redactPersonal"` // usernames, names and user IDs are hidden in the logs
+}
+
+// HTTPConfig configures the web server
+type HTTPConfig struct {
+	Addr         string        `yaml:"addr"`
+	ReadTimeout  time.Duration `yaml:"readTimeout"`
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+	IdleTimeout  time.Duration `yaml:"idleTimeout"`
+	TLSCertFile  string        `yaml:"tlsCertFile"`
+	TLSKeyFile   string        `yaml:"tlsKeyFile"`
+	// MetricsToken is the bearer token of /metrics, the metrics aren't served without it
+	MetricsToken string `yaml:"metricsToken"`
+}
+
+// VerifierConfig configures the verification of the proofs
+type VerifierConfig struct {
+	// DID is the audience of the auth requests
+	DID       string           `yaml:"did"`
+	Resolvers []ResolverConfig `yaml:"resolvers"`
+}
+
+// ResolverConfig is a chain whose state contract the verifier reads
+type ResolverConfig struct {
+	Name            string `yaml:"name"` // e.g. polygon:amoy, the network part of the issuer DIDs
+	RPCURL          string `yaml:"rpcURL"`
+	ContractAddress string `yaml:"contractAddress"`
+}
+
+// VerificationConfig holds the defaults of the groups
+type VerificationConfig struct {
+	// Timeout is how long a new member has to pass the verification, unless the group sets its own
+	Timeout time.Duration `yaml:"timeout"`
+	// SessionTTL is how long finished and abandoned verification sessions are kept
+	SessionTTL time.Duration `yaml:"sessionTTL"`
+	// DefaultRestriction applies to the groups that didn't choose one: block, delete or empty to ask the admin
+	DefaultRestriction string `yaml:"defaultRestriction"`
+	// InviteLinkTTL is how long the single-use invite link a verified user receives stays valid
+	InviteLinkTTL time.Duration `yaml:"inviteLinkTTL"`
+}
+
+// Modes of receiving updates from Telegram
+const (
+	BotModePolling = "polling"
+	BotModeWebhook = "webhook"
+)
+
+// Restriction types of the new members
+const (
+	RestrictionBlock  = "block"
+	RestrictionDelete = "delete"
+)
+
+// Default returns the configuration used for the values missing in the file and the environment
+func Default() Config {
+	return Config{
+		DataDir: "./data",
+		Backup: BackupConfig{
+			Interval:  24 * time.Hour,
+			Retention: 7,
+		},
+		BotMode: BotModePolling,
+		HTTP: HTTPConfig{
+			Addr:         ":8080",
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 60 * time.Second, // FullVerify runs inside the callback request
+			IdleTimeout:  120 * time.Second,
+		},
+		Verifier: VerifierConfig{
+			DID: "did:polygonid:polygon:amoy:2qQ68JkRcf3xrHPQPWZei3YeVzHPP58wYNxx2mEouR",
+		},
+		Verification: VerificationConfig{
+			Timeout:       10 * time.Minute,
+			SessionTTL:    time.Hour,
+			InviteLinkTTL: time.Hour,
+		},
+		ShutdownTimeout: 15 * time.Second,
+		Log: LogConfig{
+			Level:          slog.LevelInfo,
+			Format:         logging.FormatText,
+			RedactPersonal: true,
+		},
+		TracingExporter: "none",
+	}
+}
+
+// defaultResolvers are used if the config doesn't list any, the Amoy RPC node needs the Infura key
+func defaultResolvers(infuraKey string) []ResolverConfig {
+	return []ResolverConfig{
+		{
+			Name:            "polygon:amoy",
+			RPCURL:          fmt.Sprintf("https://polygon-amoy.infura.io/v3/%s", infuraKey),
+			ContractAddress: "0x1a4cC30f2aA0377b0c3bc9848766D90cb4404124",
+		},
+		{
+			Name:            "privado:main",
+			RPCURL:          "https://rpc-mainnet.privado.id",
+			ContractAddress: "0x975556428F077dB5877Ea2474D783D6C69233742",
+		},
+	}
+}
+
+// FilePath returns the config file: CONFIG_FILE or DefaultFile
+func FilePath() string {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return path
+	}
+	return DefaultFile
+}
+
+// Load reads the config file, applies the environment variables (including a .env file) and validates the result.
+// An empty path reads CONFIG_FILE, or DefaultFile if it exists.
+func Load(path string) (Config, error) {
+	// Downloading environment variables from .env file, the variables already set win
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("error reading .env: %w", err)
+	}
+
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	optional := path == ""
+	if optional {
+		path = FilePath()
+	}
+	if err := readFile(path, &cfg); err != nil {
+		if !optional || !errors.Is(err, fs.ErrNotExist) {
+			return Config{}, err
+		}
+	}
+
+	var problems problems
+	applyEnv(&cfg, &problems)
+
+	if cfg.Backup.Dir == "" {
+		cfg.Backup.Dir = filepath.Join(cfg.DataDir, "backups")
+	}
+
+	if len(cfg.Verifier.Resolvers) == 0 {
+		if cfg.InfuraKey == "" {
+			problems.add("infuraKey (INFURA_KEY) is required by the default polygon:amoy resolver, set it or list the resolvers in the config file")
+		}
+		cfg.Verifier.Resolvers = defaultResolvers(cfg.InfuraKey)
+	}
+
+	cfg.validate(&problems)
+	if err := problems.err(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// readFile decodes the YAML file over the defaults, unknown keys are errors so typos don't go unnoticed
+func readFile(path string, cfg *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides the values of the file with the environment variables
+func applyEnv(cfg *Config, p *problems) {
+	envString("TELEGRAM_TOKEN", &cfg.TelegramToken)
+	for i := range cfg.Bots {
+		envString(botTokenVariable(cfg.Bots[i].Name), &cfg.Bots[i].TelegramToken)
+	}
+	envString("INFURA_KEY", &cfg.InfuraKey)
+	envString("NGROK_URL", &cfg.PublicURL) // the former name of PUBLIC_URL
+	envString("PUBLIC_URL", &cfg.PublicURL)
+	envString("DATA_DIR", &cfg.DataDir)
+	envInt64List(p, "ADMINS", &cfg.Admins)
+
+	envString("BACKUP_DIR", &cfg.Backup.Dir)
+	envDuration(p, "BACKUP_INTERVAL", &cfg.Backup.Interval)
+	envInt(p, "BACKUP_RETENTION", &cfg.Backup.Retention)
+
+	envString("BOT_MODE", &cfg.BotMode)
+	envString("WEBHOOK_SECRET", &cfg.WebhookSecret)
+
+	envString("HTTP_ADDR", &cfg.HTTP.Addr)
+	envDuration(p, "HTTP_READ_TIMEOUT", &cfg.HTTP.ReadTimeout)
+	envDuration(p, "HTTP_WRITE_TIMEOUT", &cfg.HTTP.WriteTimeout)
+	envDuration(p, "HTTP_IDLE_TIMEOUT", &cfg.HTTP.IdleTimeout)
+	envString("TLS_CERT_FILE", &cfg.HTTP.TLSCertFile)
+	envString("TLS_KEY_FILE", &cfg.HTTP.TLSKeyFile)
+	envString("METRICS_TOKEN", &cfg.HTTP.MetricsToken)
+
+	envString("VERIFIER_DID", &cfg.Verifier.DID)
+	envDuration(p, "VERIFICATION_TIMEOUT", &cfg.Verification.Timeout)
+	envDuration(p, "SESSION_TTL", &cfg.Verification.SessionTTL)
+	envString("DEFAULT_RESTRICTION", &cfg.Verification.DefaultRestriction)
+	envDuration(p, "INVITE_LINK_TTL", &cfg.Verification.InviteLinkTTL)
+
+	envDuration(p, "SHUTDOWN_TIMEOUT", &cfg.ShutdownTimeout)
+
+	if value := os.Getenv("LOG_LEVEL"); value != "" {
+		level, err := logging.ParseLevel(value)
+		if err != nil {
+			p.add("LOG_LEVEL must be 'debug', 'info', 'warn' or 'error'")
+		}
+		cfg.Log.Level = level
+	}
+	envString("LOG_FORMAT", &cfg.Log.Format)
+	if value := os.Getenv("LOG_REDACT_PERSONAL"); value != "" {
+		cfg.Log.RedactPersonal = value != "false"
+	}
+
+	envString("TRACING_EXPORTER", &cfg.TracingExporter)
+}
+
+// validate reports every invalid value, not only the first one
+func (cfg *Config) validate(p *problems) {
+	if len(cfg.Bots) == 0 {
+		if cfg.TelegramToken == "" {
+			p.add("telegramToken (TELEGRAM_TOKEN) is required")
+		}
+	} else {
+		cfg.validateBots(p)
+	}
+
+	if cfg.PublicURL == "" {
+		p.add("publicURL (PUBLIC_URL) is required")
+	} else if !isHTTPURL(cfg.PublicURL) {
+		p.add("publicURL must be an absolute http or https URL, got %q", cfg.PublicURL)
+	}
+
+	if cfg.DataDir == "" {
+		p.add("dataDir (DATA_DIR) must not be empty")
+	}
+	for _, id := range cfg.Admins {
+		if id <= 0 {
+			p.add("admins must be Telegram user IDs, got %d", id)
+		}
+	}
+
+	if cfg.Backup.Interval < 0 {
+		p.add("backup.interval (BACKUP_INTERVAL) must not be negative")
+	}
+	if cfg.Backup.Retention < 0 {
+		p.add("backup.retention (BACKUP_RETENTION) must not be negative")
+	}
+
+	switch cfg.BotMode {
+	case BotModePolling:
+	case BotModeWebhook:
+		// Telegram sends the secret back in every webhook request
+		if cfg.WebhookSecret == "" {
+			p.add("webhookSecret (WEBHOOK_SECRET) is required in webhook mode")
+		} else if !isValidWebhookSecret(cfg.WebhookSecret) {
+			p.add("webhookSecret must be 1-256 characters of A-Z, a-z, 0-9, _ and -")
+		}
+	default:
+		p.add("botMode (BOT_MODE) must be 'polling' or 'webhook', got %q", cfg.BotMode)
+	}
+
+	if cfg.HTTP.Addr == "" {
+		p.add("http.addr (HTTP_ADDR) must not be empty")
+	}
+	positive(p, "http.readTimeout (HTTP_READ_TIMEOUT)", cfg.HTTP.ReadTimeout)
+	positive(p, "http.writeTimeout (HTTP_WRITE_TIMEOUT)", cfg.HTTP.WriteTimeout)
+	positive(p, "http.idleTimeout (HTTP_IDLE_TIMEOUT)", cfg.HTTP.IdleTimeout)
+	if (cfg.HTTP.TLSCertFile == "") != (cfg.HTTP.TLSKeyFile == "") {
+		p.add("http.tlsCertFile (TLS_CERT_FILE) and http.tlsKeyFile (TLS_KEY_FILE) must be set together")
+	}
+
+	if !strings.HasPrefix(cfg.Verifier.DID, "did:") {
+		p.add("verifier.did (VERIFIER_DID) must be a DID, got %q", cfg.Verifier.DID)
+	}
+	names := map[string]bool{}
+	for i, r := range cfg.Verifier.Resolvers {
+		if r.Name == "" {
+			p.add("verifier.resolvers[%d].name is required", i)
+		} else if names[r.Name] {
+			p.add("verifier.resolvers[%d].name %q is listed twice", i, r.Name)
+		}
+		names[r.Name] = true
+		if !isHTTPURL(r.RPCURL) {
+			p.add("verifier.resolvers[%d].rpcURL must be an absolute http or https URL", i)
+		}
+		if !contractAddress.MatchString(r.ContractAddress) {
+			p.add("verifier.resolvers[%d].contractAddress must be a 0x-prefixed address, got %q", i, r.ContractAddress)
+		}
+	}
+
+	positive(p, "verification.timeout (VERIFICATION_TIMEOUT)", cfg.Verification.Timeout)
+	positive(p, "verification.sessionTTL (SESSION_TTL)", cfg.Verification.SessionTTL)
+	switch cfg.Verification.DefaultRestriction {
+	case "", RestrictionBlock, RestrictionDelete:
+	default:
+		p.add("verification.defaultRestriction (DEFAULT_RESTRICTION) must be 'block', 'delete' or empty, got %q", cfg.Verification.DefaultRestriction)
+	}
+	positive(p, "verification.inviteLinkTTL (INVITE_LINK_TTL)", cfg.Verification.InviteLinkTTL)
+
+	positive(p, "shutdownTimeout (SHUTDOWN_TIMEOUT)", cfg.ShutdownTimeout)
+
+	if cfg.Log.Format != logging.FormatText && cfg.Log.Format != logging.FormatJSON {
+		p.add("log.format (LOG_FORMAT) must be 'text' or 'json', got %q", cfg.Log.Format)
+	}
+
+	// Tracing is off unless an exporter is chosen, the OTLP exporter reads the OTEL_EXPORTER_OTLP_* variables
+	switch cfg.TracingExporter {
+	case "none", "otlp", "stdout":
+	default:
+		p.add("tracingExporter (TRACING_EXPORTER) must be 'none', 'otlp' or 'stdout', got %q", cfg.TracingExporter)
+	}
+}
+
+// validateBots checks that the bots don't share a token, a namespace or a path prefix
+func (cfg *Config) validateBots(p *problems) {
+	names := map[string]bool{}
+	prefixes := map[string]bool{}
+	tokens := map[string]bool{}
+	for i, bot := range cfg.Bots {
+		if bot.Name != "" && !botName.MatchString(bot.Name) {
+			p.add("bots[%d].name must be 1-64 characters of a-z, 0-9, _ and -, got %q", i, bot.Name)
+		} else if names[bot.Name] {
+			p.add("bots[%d].name %q is listed twice", i, bot.Name)
+		}
+		names[bot.Name] = true
+
+		if bot.TelegramToken == "" {
+			p.add("bots[%d].telegramToken (%s) is required", i, botTokenVariable(bot.Name))
+		} else if tokens[bot.TelegramToken] {
+			p.add("bots[%d].telegramToken is used by another bot", i)
+		}
+		tokens[bot.TelegramToken] = true
+
+		if bot.VerifierDID != "" && !strings.HasPrefix(bot.VerifierDID, "did:") {
+			p.add("bots[%d].verifierDID must be a DID, got %q", i, bot.VerifierDID)
+		}
+
+		if bot.PathPrefix != "" && !pathPrefix.MatchString(bot.PathPrefix) {
+			p.add("bots[%d].pathPrefix must look like /name, without a trailing slash, got %q", i, bot.PathPrefix)
+		} else if prefixes[bot.PathPrefix] {
+			p.add("bots[%d].pathPrefix %q is used by another bot", i, bot.PathPrefix)
+		}
+		prefixes[bot.PathPrefix] = true
+	}
+}
+
+// botTokenVariable returns the environment variable of the token of the bot, e.g. TELEGRAM_TOKEN_BRAND_A
+func botTokenVariable(name string) string {
+	if name == "" {
+		return "TELEGRAM_TOKEN"
+	}
+	return "TELEGRAM_TOKEN_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+var (
+	botName    = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+	pathPrefix = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+)
+
+var contractAddress = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// problems collects the validation errors of the config
+type problems []string
+
+func (p *problems) add(format string, args ...any) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(p, "\n  - "))
+}
+
+// positive reports a duration that is zero or negative
+func positive(p *problems, name string, value time.Duration) {
+	if value <= 0 {
+		p.add("%s must be a positive duration, got %s", name, value)
+	}
+}
+
+// isHTTPURL checks that the value is an absolute http(s) URL
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// isValidWebhookSecret checks the secret against the characters allowed by Telegram
+func isValidWebhookSecret(secret string) bool {
+	if len(secret) == 0 || len(secret) > 256 {
+		return false
+	}
+	for _, r := range secret {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
 }
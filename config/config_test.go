@@ -0,0 +1,179 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// minimalFile has only the required values
+const minimalFile = `
+telegramToken: "123:abc"
+publicURL: https://bot.example.com
+infuraKey: key
+`
+
+// clearEnv hides the variables of the environment the tests run in, an empty variable counts as unset
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		"CONFIG_FILE", "TELEGRAM_TOKEN", "INFURA_KEY", "NGROK_URL", "PUBLIC_URL", "DATA_DIR", "ADMINS",
+		"BACKUP_DIR", "BACKUP_INTERVAL", "BACKUP_RETENTION", "BOT_MODE", "WEBHOOK_SECRET",
+		"HTTP_ADDR", "HTTP_READ_TIMEOUT", "HTTP_WRITE_TIMEOUT", "HTTP_IDLE_TIMEOUT", "TLS_CERT_FILE", "TLS_KEY_FILE",
+		"METRICS_TOKEN", "VERIFIER_DID", "VERIFICATION_TIMEOUT", "SESSION_TTL", "DEFAULT_RESTRICTION",
+		"INVITE_LINK_TTL", "SHUTDOWN_TIMEOUT", "LOG_LEVEL", "LOG_FORMAT", "LOG_REDACT_PERSONAL", "TRACING_EXPORTER",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+// writeConfig writes the YAML to a config file in a temporary directory
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+	cfg, err := Load(writeConfig(t, minimalFile))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	defaults := Default()
+	tests := []struct {
+		name      string
+		got, want any
+	}{
+		{"dataDir", cfg.DataDir, defaults.DataDir},
+		{"backup.dir", cfg.Backup.Dir, filepath.Join(defaults.DataDir, "backups")},
+		{"backup.interval", cfg.Backup.Interval, defaults.Backup.Interval},
+		{"botMode", cfg.BotMode, BotModePolling},
+		{"http", cfg.HTTP, defaults.HTTP},
+		{"verification", cfg.Verification, defaults.Verification},
+		{"shutdownTimeout", cfg.ShutdownTimeout, defaults.ShutdownTimeout},
+		{"log", cfg.Log, defaults.Log},
+		{"tracingExporter", cfg.TracingExporter, "none"},
+		{"resolvers", len(cfg.Verifier.Resolvers), len(defaultResolvers("key"))},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadEnvOverride(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		env   map[string]string
+		check func(cfg Config) bool
+	}{
+		{
+			name:  "string over the file",
+			file:  minimalFile + "http:\n  addr: \":9000\"\n",
+			env:   map[string]string{"HTTP_ADDR": ":9100"},
+			check: func(cfg Config) bool { return cfg.HTTP.Addr == ":9100" },
+		},
+		{
+			name:  "duration over the default",
+			file:  minimalFile,
+			env:   map[string]string{"VERIFICATION_TIMEOUT": "3m"},
+			check: func(cfg Config) bool { return cfg.Verification.Timeout == 3*time.Minute },
+		},
+		{
+			name:  "required value from the environment only",
+			file:  "publicURL: https://bot.example.com\ninfuraKey: key\n",
+			env:   map[string]string{"TELEGRAM_TOKEN": "456:def"},
+			check: func(cfg Config) bool { return cfg.TelegramToken == "456:def" },
+		},
+		{
+			name:  "former name of PUBLIC_URL",
+			file:  "telegramToken: \"123:abc\"\ninfuraKey: key\n",
+			env:   map[string]string{"NGROK_URL": "https://old.example.com"},
+			check: func(cfg Config) bool { return cfg.PublicURL == "https://old.example.com" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := Load(writeConfig(t, tt.file))
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !tt.check(cfg) {
+				t.Errorf("the environment didn't override the config: %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		want []string // parts of the error message
+	}{
+		{
+			name: "unknown field",
+			file: minimalFile + "publicUrl: https://typo.example.com\n",
+			want: []string{"field publicUrl not found"},
+		},
+		{
+			name: "unknown nested field",
+			file: minimalFile + "verification:\n  timout: 5m\n",
+			want: []string{"field timout not found"},
+		},
+		{
+			name: "every problem at once",
+			file: minimalFile + "botMode: sideways\nverification:\n  timeout: -1m\nlog:\n  format: xml\n",
+			env:  map[string]string{"SHUTDOWN_TIMEOUT": "soon"},
+			want: []string{
+				"SHUTDOWN_TIMEOUT is not a valid duration",
+				"botMode (BOT_MODE) must be 'polling' or 'webhook'",
+				"verification.timeout (VERIFICATION_TIMEOUT) must be a positive duration",
+				"log.format (LOG_FORMAT) must be 'text' or 'json'",
+			},
+		},
+		{
+			name: "missing required values",
+			file: "infuraKey: key\n",
+			want: []string{
+				"telegramToken (TELEGRAM_TOKEN) is required",
+				"publicURL (PUBLIC_URL) is required",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			_, err := Load(writeConfig(t, tt.file))
+			if err == nil {
+				t.Fatal("Load succeeded, want an error")
+			}
+			for _, part := range tt.want {
+				if !strings.Contains(err.Error(), part) {
+					t.Errorf("error doesn't mention %q:\n%v", part, err)
+				}
+			}
+		})
+	}
+}
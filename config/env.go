@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envString overrides the value with the environment variable if it's set
+func envString(name string, target *string) {
+	if value := os.Getenv(name); value != "" {
+		*target = value
+	}
+}
+
+// envDuration parses a duration like "30s" from the environment variable
+func envDuration(p *problems, name string, target *time.Duration) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		p.add("%s is not a valid duration: %q", name, value)
+		return
+	}
+	*target = duration
+}
+
+// envInt parses a number from the environment variable
+func envInt(p *problems, name string, target *int) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		p.add("%s is not a valid number: %q", name, value)
+		return
+	}
+	*target = number
+}
+
+// envInt64List parses a comma separated list of IDs from the environment variable
+func envInt64List(p *problems, name string, target *[]int64) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+
+	var list []int64
+	for _, item := range strings.Split(value, ",") {
+		number, err := strconv.ParseInt(strings.TrimSpace(item), 10, 64)
+		if err != nil {
+			p.add("%s must be a comma separated list of IDs, got %q", name, value)
+			return
+		}
+		list = append(list, number)
+	}
+	*target = list
+}
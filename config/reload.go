@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Reload returns the config to switch to after the config was loaded again. The settings that can change
+// while the bot runs are taken from next: the verifier, the defaults of the groups, the admins and the log level.
+// The others keep their current values, their changes are returned so they can be reported as needing a restart.
+func (c Config) Reload(next Config) (Config, []string) {
+	reloaded := c
+	reloaded.InfuraKey = next.InfuraKey
+	reloaded.Admins = next.Admins
+	reloaded.Verifier = next.Verifier
+	reloaded.Verification = next.Verification
+	reloaded.Log.Level = next.Log.Level
+
+	var restart []string
+	if c.TelegramToken != next.TelegramToken {
+		restart = append(restart, "telegramToken")
+	}
+	if !reflect.DeepEqual(c.Bots, next.Bots) {
+		restart = append(restart, "bots")
+	}
+	if c.PublicURL != next.PublicURL {
+		restart = append(restart, "publicURL")
+	}
+	if c.DataDir != next.DataDir {
+		restart = append(restart, "dataDir")
+	}
+	if c.Backup != next.Backup {
+		restart = append(restart, "backup")
+	}
+	if c.BotMode != next.BotMode || c.WebhookSecret != next.WebhookSecret {
+		restart = append(restart, "botMode")
+	}
+	if c.HTTP != next.HTTP {
+		restart = append(restart, "http")
+	}
+	if c.ShutdownTimeout != next.ShutdownTimeout {
+		restart = append(restart, "shutdownTimeout")
+	}
+	if c.Log.Format != next.Log.Format || c.Log.RedactPersonal != next.Log.RedactPersonal {
+		restart = append(restart, "log")
+	}
+	if c.TracingExporter != next.TracingExporter {
+		restart = append(restart, "tracingExporter")
+	}
+	return reloaded, restart
+}
+
+// Equal reports whether the configs are the same, e.g. to skip a reload after an unrelated file event
+func (c Config) Equal(other Config) bool {
+	return reflect.DeepEqual(c, other)
+}
+
+// How long the watcher waits for the writes of an editor to settle
+const watchDelay = 500 * time.Millisecond
+
+// Watch calls changed after the config file is written, created or replaced, until stop is closed.
+// The directory is watched, because editors and Kubernetes config maps replace the file instead of writing to it.
+func Watch(path string, stop <-chan struct{}, changed func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating config watcher: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("error watching config directory: %w", err)
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		name := filepath.Clean(path)
+		// Several events of one save are handled once, after they settle
+		var timer *time.Timer
+		for {
+			select {
+			case <-stop:
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != name || event.Op == fsnotify.Chmod {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(watchDelay, changed)
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
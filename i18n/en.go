@@ -0,0 +1,269 @@
+package i18n
+
+// en is the complete catalog, the other catalogs translate its messages
+var en = map[string]string{
+	// Messages to every user
+	"start.hello":           "Hello, %s!\n\nIf you want to be verified, run the command /verify.\nIf you want to configure the bot to verify participants, run the command /setup.",
+	"welcome.requirement":   "verification",
+	"welcome.button":        "Start verification",
+	"welcome.message":       "Hi, @%s! Please pass the check \"%s\": click the button below and start the bot.",
+	"verify.not_pending":    "You are not awaiting verification in any group.",
+	"verify.intro":          "Hi, @%s! To remain in the group \"%s\", you need to complete the verification process.",
+	"verify.intro_join":     "Hi, @%s! To join the group \"%s\", you need to complete the verification process.",
+	"verify.intro_invite":   "Hi, @%s! To get an invite link to the group \"%s\", you need to complete the verification process.",
+	"verify.busy_join":      "Please finish your current verification first, then send the request to join the group \"%s\" again.",
+	"verify.busy_member":    "Please finish your current verification first, then join the group \"%s\" again.",
+	"verify.not_configured": "Verification is not configured for this group yet. Please contact the group administrator.",
+	"verify.request_failed": "Failed to generate verification request. Please try again later.",
+	"verify.button":         "Verify with Privado ID",
+	"verify.prompt":         "Please click the button below to pass the check \"%s\":",
+	"result.success":        "You have successfully passed verification and can stay in the group.",
+	"result.success_join":   "You have successfully passed verification, your request to join the group has been approved.",
+	"result.failure":        "You failed verification and were removed from the group.",
+	"result.failure_join":   "You failed verification, your request to join the group has been declined.",
+	"result.timeout":        "You did not complete the verification on time and were removed from the group.",
+	"result.timeout_join":   "You did not complete the verification on time, your request to join the group has been declined.",
+	"result.success_invite": "You have successfully passed verification.",
+	"result.failure_invite": "You failed verification, so you can't get an invite link to the group.",
+	"result.timeout_invite": "You did not complete the verification on time. Open the invite link again to start over.",
+
+	// Shared by the admin commands
+	"common.group_only":              "This command can only be used in group or supergroup chats.",
+	"common.private_only":            "This command can only be used in a private chat with me.",
+	"common.not_admin":               "You are not an administrator in this group.",
+	"common.no_group":                "You are not associated with any group. Use /setup first.",
+	"common.need_group_verification": "You need to specify a group for verification setup.",
+	"common.need_group_restriction":  "You need to specify a group for restriction setup.",
+	"common.need_setup_group":        "You need to set up a group for verification.",
+	"common.chat_info_failed":        "Failed to fetch chat information.",
+	"common.group_chat_failed":       "Failed to fetch the group chat. Please try again.",
+	"common.group_config_failed":     "Failed to fetch group configuration.",
+	"common.params_not_configured":   "Verification parameters are not configured for your group.",
+	"common.failed":                  "Failed: %v",
+	"common.not_set":                 "Not set",
+	"common.active":                  " (active)",
+	"common.none":                    "none",
+
+	// /setup and /check_admin
+	"setup.intro":                  "To set me up for verification in your group, please add me to the group as an administrator and call the /check_admin command in the group.",
+	"check_admin.continue_private": "Administrator, return to the private chat with me to continue configuring the settings",
+	"check_admin.bot_role_failed":  "I couldn't fetch my role in this group. Please make sure I am an administrator.",
+	"check_admin.bot_not_admin":    "I am not an administrator in the group '%s'. Please promote me to an administrator.",
+	"check_admin.user_role_failed": "I couldn't fetch your role in this group.",
+	"check_admin.user_not_admin":   "@%s, you are not an administrator in the group '%s'. You cannot configure me for this group.",
+	"check_admin.confirmed":        "I have confirmed your admin status and my role in the group '%s'. You can now proceed with the setup.",
+	"check_admin.add_params":       "To add verification parameters, call the command\n /add_verification_params",
+
+	// Verification parameters
+	"params.send_json":             "Please send verification parameters in JSON format. The 'name' and 'description' fields are optional and are shown to members. Example:\n\n%s",
+	"params.invalid_json":          "Invalid JSON format. Please ensure your parameters match the expected structure.",
+	"params.missing_fields":        "Missing required fields in JSON. Please include 'circuitId', 'id', and 'query'.",
+	"params.added":                 "JSON verification parameters have been added for the group.",
+	"params.another_added":         "Another verification parameter has been added.",
+	"params.set_for_group":         "Verification parameters have been successfully set for the group '%s'.",
+	"params.cleared":               "All verification parameters have been successfully cleared for this group.",
+	"params.none":                  "No verification parameters have been added yet. Use /add_verification_params to add one.",
+	"params.list_title":            "<b>Verification parameters for the group:</b>\n\n",
+	"params.type_line":             "<b>Type:</b> <code>%s</code>%s\n",
+	"params.name_line":             "<b>Name:</b> <code>%s</code>\n",
+	"params.format_error":          "Error formatting JSON\n\n",
+	"params.restriction_line":      "<b>Restriction type for new members:</b> ",
+	"params.config_failed":         "No verification parameters have been set for this group. Error fetching group configuration",
+	"params.not_set":               "No verification parameters have been set for this group.",
+	"params.only_one":              "Only one verification type is available. Switching is not possible.",
+	"params.invalid_selection":     "Invalid selection.",
+	"params.already_active":        "The selected verification type '%s' is already active.",
+	"params.activated":             "Verification type '%s' has been set as active.",
+	"params.select_active":         "Select the verification type to activate:",
+	"params.select":                "Select a verification parameter to manage:",
+	"params.none_left":             "No verification parameters left. Use /add_verification_params to add one.",
+	"params.gone":                  "This verification parameter has changed or no longer exists. Call /list_verification_params again.",
+	"params.list_header":           "Verification parameters:",
+	"params.item":                  "Parameter %s",
+	"params.choose_action":         "Choose an action:",
+	"params.button_edit":           "Edit",
+	"params.button_duplicate":      "Duplicate",
+	"params.button_delete":         "Delete",
+	"params.button_delete_confirm": "Yes, delete",
+	"params.button_cancel":         "Cancel",
+	"params.button_up":             "Move up",
+	"params.button_down":           "Move down",
+	"params.button_rename":         "Rename",
+	"params.button_description":    "Description",
+	"params.button_back":           "Back",
+	"params.send_new_json":         "Send the new JSON for parameter #%d. Current value:\n<pre>%s</pre>",
+	"params.duplicated":            "Parameter #%d has been duplicated.",
+	"params.confirm_delete":        "Delete parameter %s? This cannot be undone.",
+	"params.deleted":               "Parameter #%d has been deleted.",
+	"params.deleted_active":        "Parameter #%d has been deleted. It was the active one, new members are now verified with parameter #%d (%s).",
+	"params.moved_up":              "Parameter #%d has been moved up.",
+	"params.moved_down":            "Parameter #%d has been moved down.",
+	"params.send_name":             "Send a new name for parameter #%d, or '-' to remove the name.",
+	"params.send_description":      "Send a description of parameter #%d for members, or '-' to remove it.",
+	"params.update_failed":         "Failed to update parameter #%d: %v",
+	"params.updated":               "Parameter #%d has been updated.",
+	"params.name_too_long":         "The name is too long, please use at most %d characters.",
+	"params.rename_failed":         "Failed to rename parameter #%d: %v",
+	"params.renamed":               "Parameter #%d has been renamed.",
+	"params.description_too_long":  "The description is too long, please use at most %d characters.",
+	"params.describe_failed":       "Failed to update the description of parameter #%d: %v",
+	"params.described":             "The description of parameter #%d has been updated.",
+
+	// Presets
+	"presets.pick":         "Or pick a ready-made preset:",
+	"presets.gone":         "This preset is no longer available. Please call /add_verification_params again.",
+	"presets.build_failed": "Failed to build the verification parameters: %v",
+	"presets.save_failed":  "Failed to save the verification parameters. Please try again.",
+	"presets.added":        "Verification parameter \"%s\" has been added for the group.",
+
+	"presets.question_issuers":              "Send the DIDs of the trusted issuers separated by commas, or '-' to accept any issuer.",
+	"presets.age.title":                     "Age over N",
+	"presets.age.description":               "Members prove they are older than a given age with a KYCAgeCredential.",
+	"presets.age.question_age":              "What is the minimum age? (e.g. 18)",
+	"presets.age.name":                      "%d+ age check",
+	"presets.age.params_description":        "Prove that you are at least %d years old without revealing your birthday.",
+	"presets.country.title":                 "Country of residence",
+	"presets.country.description":           "Members prove they live in one of the listed countries with a KYCCountryOfResidenceCredential.",
+	"presets.country.question_codes":        "Send the allowed countries as ISO 3166-1 numeric codes separated by commas (e.g. 840, 804).",
+	"presets.country.name":                  "Country of residence check",
+	"presets.country.params_description":    "Prove that you live in one of the allowed countries without revealing which one.",
+	"presets.uniqueness.title":              "Proof of uniqueness",
+	"presets.uniqueness.description":        "Members prove they are a unique human with a proof of uniqueness credential.",
+	"presets.uniqueness.question_issuer":    "Send the DID of the trusted uniqueness issuer, or '-' to accept any issuer.",
+	"presets.uniqueness.question_context":   "Send the JSON-LD context of the credential, or '-' to use %s.",
+	"presets.uniqueness.question_type":      "Send the credential type, or '-' to use %s.",
+	"presets.uniqueness.name":               "Proof of uniqueness",
+	"presets.uniqueness.params_description": "Prove that you are a unique human without revealing who you are.",
+	"presets.membership.title":              "Membership credential (POAP-style)",
+	"presets.membership.description":        "Members prove they hold a credential of a given type, like an event attendance or a membership card.",
+	"presets.membership.question_context":   "Send the JSON-LD context URL of the credential schema.",
+	"presets.membership.question_type":      "Send the credential type (e.g. EventAttendance).",
+	"presets.membership.question_issuer":    "Send the DID of the issuer, or '-' to accept any issuer.",
+	"presets.membership.name":               "%s holder",
+	"presets.membership.params_description": "Prove that you hold a %s credential.",
+	"presets.error_required":                "This value is required.",
+	"presets.error_empty":                   "The answer is empty.",
+	"presets.error_age":                     "The age must be a number between %d and %d.",
+	"presets.error_no_country":              "At least one country code is required.",
+	"presets.error_country_code":            "%q is not an ISO 3166-1 numeric country code.",
+	"presets.error_not_did":                 "%q is not a DID.",
+	"presets.error_url":                     "%q is not a valid schema URL.",
+	"presets.error_credential_type":         "The credential type must be a single word.",
+
+	// Restriction type
+	"restriction.ask":           "To set restriction parameters for new subscribers",
+	"restriction.select":        "Select restriction type:",
+	"restriction.button_block":  "Block",
+	"restriction.button_delete": "Delete",
+	"restriction.set":           "Restriction type set to '%s'.",
+	"restriction.current":       "Current restriction type for the group '%s': %s.\n\nSelect a new restriction type:",
+	"restriction.already":       "The restriction type is already set to '%s'.",
+	"restriction.changed":       "Restriction type for group '%s' has been changed to '%s'.",
+
+	// /test_verification
+	"test.config_error":      "Verification configuration error. Please contact the group administrator.",
+	"test.button":            "Test verify (%s)",
+	"test.prompt":            "Please test the check \"%s\" by clicking the link below:",
+	"test.send_failed":       "Failed to send verification link. Please check your private messages.",
+	"test.sent":              "A verification link has been sent to your private messages. Please check your inbox.",
+	"test.token_file_failed": "Failed to create file with AuthToken.",
+	"test.current_params":    "Here is the current verification parameter being tested:\n<pre>%s</pre>\n",
+	"test.success":           "The test was successful. The parameters are configured correctly, the verification process is working.",
+
+	// Verified users
+	"verified.none":    "No verified users in the group '%s'.",
+	"verified.list":    "Verified users in the group '%s':\n\n",
+	"verified.deleted": "All verified users have been deleted for the group '%s'.",
+
+	// /api_key
+	"api_key.none":          "You don't have an API key.",
+	"api_key.revoke_failed": "Failed to revoke the API key. Please try again later.",
+	"api_key.revoked":       "Your API key has been revoked.",
+	"api_key.create_failed": "Failed to create an API key. Please try again later.",
+	"api_key.created":       "Your admin API key now has access to the group '%s':\n\n<code>%s</code>\n\nSend it in the 'Authorization: Bearer' header. The key is shown only once, a new /api_key replaces it and keeps the access to your other groups. Use /api_key revoke to delete it.",
+	"api_key.send_failed":   "Failed to send the API key. Please start a private chat with me first.",
+	"api_key.sent":          "I've sent the API key to you in a private chat.",
+
+	// /export_config and /import_config
+	"config.export_failed":   "Failed to export the group configuration.",
+	"config.export_caption":  "Verification config of the group '%s'. Send it with /import_config to apply it to another group.",
+	"config.send_failed":     "Failed to send the config. Please start a private chat with me first.",
+	"config.import_prompt":   "Please send the group config as JSON text or as the .json file you got from /export_config.",
+	"config.file_too_large":  "The file is too large for a group config.",
+	"config.download_failed": "Failed to download the file. Please try again with /import_config.",
+	"config.read_failed":     "Failed to read the file. Please try again with /import_config.",
+	"config.invalid_json":    "Invalid config JSON: %v",
+	"config.invalid":         "The config is not valid: %v",
+	"config.unchanged":       "The imported config is the same as the current one. Nothing to change.",
+	"config.button_apply":    "Apply",
+	"config.button_cancel":   "Cancel",
+	"config.apply_failed":    "Failed to apply the config.",
+	"config.applied":         "The imported config has been applied.",
+	"config.outdated":        "This preview is outdated, the config was not changed. Send the config again to see a new preview.",
+	"config.cancelled":       "Import cancelled. The current config was not changed.",
+	"config.preview":         "The following changes will be applied:\n\n%s\n\nApply the imported config?",
+	"config.param_added":     "+ param #%d added: %s",
+	"config.param_removed":   "- param #%d removed: %s",
+	"config.param_changed":   "~ param #%d changed: %s → %s",
+	"config.active_changed":  "~ active param: %s → %s",
+	"config.restriction":     "~ restriction type: %s → %s",
+	"config.timeout":         "~ verification timeout: %s → %s",
+	"config.language":        "~ language: %s → %s",
+	"config.template":        "~ %s message changed",
+	"config.timeout_default": "default (%s)",
+	"config.timeout_minutes": "%d min",
+
+	// /set_language
+	"language.current":     "Default language of the group: %s.\nAvailable languages: %s.\n\nMembers get the messages in the language of their Telegram app if the bot speaks it, the others get the default language. Use /set_language <code> to change it or /set_language default to reset it.",
+	"language.not_set":     "not set (%s)",
+	"language.set":         "The default language of the group has been set to %s.",
+	"language.reset":       "The default language of the group has been reset to %s.",
+	"language.unsupported": "Unsupported language '%s'. Available languages: %s.",
+	"language.failed":      "Failed to change the language of the group. Please try again later.",
+
+	// /set_message
+	"template.usage":          "Messages of the group:\n%s\n\nPlaceholders: %s.\n\nUse /set_message <message> to write a new text, or /set_message <message> <text> right away, e.g.\n/set_message welcome Hi {username}! Pass the check \"{requirement}\" before {deadline}.\n\nUse /set_message <message> default to restore the default message.",
+	"template.custom":         "custom",
+	"template.default":        "default",
+	"template.unknown":        "Unknown message '%s'. Messages: %s.",
+	"template.prompt":         "Send the new %s message. Placeholders: %s.\n\nCurrent message:\n%s",
+	"template.prompt_default": "Send the new %s message. Placeholders: %s.\n\nThe group uses the default message now.",
+	"template.invalid":        "The message is not valid: %v. Please send another text.",
+	"template.preview":        "Preview of the %s message:\n\n%s\n\nSave it?",
+	"template.button_save":    "Save",
+	"template.button_cancel":  "Cancel",
+	"template.saved":          "The %s message has been saved.",
+	"template.outdated":       "This preview is outdated, the message was not changed. Use /set_message to see a new preview.",
+	"template.cancelled":      "The %s message was not changed.",
+	"template.reset":          "The %s message has been reset to the default one.",
+	"template.save_failed":    "Failed to save the message. Please try again later.",
+
+	// /invite_link and the invite deep links
+	"invite.link":           "Share this link, people who open it and pass the verification get a single-use invite link to the group, valid for %[2]d min:\n\n%[1]s\n\nInvite links issued: %[3]d, used: %[4]d.\n\nUse /invite_link revoke to disable the link.",
+	"invite.none":           "The group has no invite link.",
+	"invite.revoked":        "The invite link has been disabled. Invite links already sent stay valid until they expire.",
+	"invite.revoke_failed":  "Failed to disable the invite link. Please try again later.",
+	"invite.create_failed":  "Failed to create the invite link. Please try again later.",
+	"invite.not_configured": "Set the verification params of the group first, e.g. with /add_verification_params.",
+	"invite.invalid":        "This invite link is not valid anymore. Please ask the group administrator for a new one.",
+	"invite.already_member": "You are already a member of the group \"%s\".",
+	"invite.busy":           "Please finish your current verification first, use /verify to get the link again.",
+	"invite.issued":         "Here is your invite link to the group. It works once and expires at %s.",
+	"invite.join_button":    "Join the group",
+	"invite.link_failed":    "Failed to create your invite link. Please contact the group administrator.",
+
+	// Deep links and /verify_link
+	"deeplink.invalid":     "This verification link is not valid. Please use the button in the group or call /verify.",
+	"deeplink.not_pending": "You are not awaiting verification in the group \"%s\".",
+	"deeplink.verify_link": "Share this link with the members who still have to pass the verification. It opens the bot and starts their verification right away:\n\n%s",
+
+	// Verification page
+	"verify_page.title":        "Verification",
+	"verify_page.instructions": "Scan the QR code with the Privado ID app, or open the request in the web wallet on this device.",
+	"verify_page.qr_alt":       "Verification QR code",
+	"verify_page.open_wallet":  "Open in wallet",
+	"verify_page.pending":      "Waiting for the proof...",
+	"verify_page.verified":     "Verification passed. You can return to Telegram.",
+	"verify_page.failed":       "Verification failed. Please return to Telegram and try again with /verify.",
+	"verify_page.not_found":    "Verification session not found or expired. Please call /verify in Telegram again.",
+}
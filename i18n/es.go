@@ -0,0 +1,269 @@
+package i18n
+
+// es is the Spanish catalog
+var es = map[string]string{
+	// Messages to every user
+	"start.hello":           "¡Hola, %s!\n\nSi quieres verificarte, ejecuta el comando /verify.\nSi quieres configurar el bot para verificar a los participantes, ejecuta el comando /setup.",
+	"welcome.requirement":   "verificación",
+	"welcome.button":        "Iniciar verificación",
+	"welcome.message":       "¡Hola, @%s! Supera la comprobación \"%s\": pulsa el botón de abajo e inicia el bot.",
+	"verify.not_pending":    "No tienes ninguna verificación pendiente en ningún grupo.",
+	"verify.intro":          "¡Hola, @%s! Para permanecer en el grupo \"%s\", tienes que completar la verificación.",
+	"verify.intro_join":     "¡Hola, @%s! Para unirte al grupo \"%s\", tienes que completar la verificación.",
+	"verify.intro_invite":   "¡Hola, @%s! Para obtener un enlace de invitación al grupo \"%s\", tienes que completar la verificación.",
+	"verify.busy_join":      "Primero termina tu verificación actual y luego vuelve a enviar la solicitud para unirte al grupo \"%s\".",
+	"verify.busy_member":    "Primero termina tu verificación actual y luego vuelve a unirte al grupo \"%s\".",
+	"verify.not_configured": "La verificación aún no está configurada en este grupo. Contacta con el administrador del grupo.",
+	"verify.request_failed": "No se pudo generar la solicitud de verificación. Inténtalo de nuevo más tarde.",
+	"verify.button":         "Verificar con Privado ID",
+	"verify.prompt":         "Pulsa el botón de abajo para superar la comprobación \"%s\":",
+	"result.success":        "Has superado la verificación y puedes permanecer en el grupo.",
+	"result.success_join":   "Has superado la verificación, tu solicitud para unirte al grupo ha sido aprobada.",
+	"result.failure":        "No has superado la verificación y has sido expulsado del grupo.",
+	"result.failure_join":   "No has superado la verificación, tu solicitud para unirte al grupo ha sido rechazada.",
+	"result.timeout":        "No completaste la verificación a tiempo y has sido expulsado del grupo.",
+	"result.timeout_join":   "No completaste la verificación a tiempo, tu solicitud para unirte al grupo ha sido rechazada.",
+	"result.success_invite": "Has completado la verificación con éxito.",
+	"result.failure_invite": "No superaste la verificación, así que no puedes obtener un enlace de invitación al grupo.",
+	"result.timeout_invite": "No completaste la verificación a tiempo. Abre el enlace de invitación de nuevo para volver a empezar.",
+
+	// Shared by the admin commands
+	"common.group_only":              "Este comando solo se puede usar en grupos y supergrupos.",
+	"common.private_only":            "Este comando solo se puede usar en un chat privado conmigo.",
+	"common.not_admin":               "No eres administrador de este grupo.",
+	"common.no_group":                "No estás asociado a ningún grupo. Usa /setup primero.",
+	"common.need_group_verification": "Tienes que indicar un grupo para configurar la verificación.",
+	"common.need_group_restriction":  "Tienes que indicar un grupo para configurar la restricción.",
+	"common.need_setup_group":        "Tienes que configurar un grupo para la verificación.",
+	"common.chat_info_failed":        "No se pudo obtener la información del chat.",
+	"common.group_chat_failed":       "No se pudo obtener el chat del grupo. Inténtalo de nuevo.",
+	"common.group_config_failed":     "No se pudo obtener la configuración del grupo.",
+	"common.params_not_configured":   "Los parámetros de verificación no están configurados para tu grupo.",
+	"common.failed":                  "Error: %v",
+	"common.not_set":                 "Sin definir",
+	"common.active":                  " (activo)",
+	"common.none":                    "ninguno",
+
+	// /setup and /check_admin
+	"setup.intro":                  "Para configurarme en tu grupo, añádeme al grupo como administrador y ejecuta el comando /check_admin en el grupo.",
+	"check_admin.continue_private": "Administrador, vuelve al chat privado conmigo para continuar con la configuración",
+	"check_admin.bot_role_failed":  "No pude obtener mi rol en este grupo. Asegúrate de que soy administrador.",
+	"check_admin.bot_not_admin":    "No soy administrador en el grupo '%s'. Por favor, hazme administrador.",
+	"check_admin.user_role_failed": "No pude obtener tu rol en este grupo.",
+	"check_admin.user_not_admin":   "@%s, no eres administrador en el grupo '%s'. No puedes configurarme para este grupo.",
+	"check_admin.confirmed":        "He confirmado tu estado de administrador y mi rol en el grupo '%s'. Ya puedes continuar con la configuración.",
+	"check_admin.add_params":       "Para añadir parámetros de verificación, ejecuta el comando\n /add_verification_params",
+
+	// Verification parameters
+	"params.send_json":             "Envía los parámetros de verificación en formato JSON. Los campos 'name' y 'description' son opcionales y se muestran a los miembros. Ejemplo:\n\n%s",
+	"params.invalid_json":          "Formato JSON no válido. Asegúrate de que los parámetros tienen la estructura esperada.",
+	"params.missing_fields":        "Faltan campos obligatorios en el JSON. Incluye 'circuitId', 'id' y 'query'.",
+	"params.added":                 "Se han añadido los parámetros de verificación JSON al grupo.",
+	"params.another_added":         "Se ha añadido otro parámetro de verificación.",
+	"params.set_for_group":         "Los parámetros de verificación se han configurado correctamente para el grupo '%s'.",
+	"params.cleared":               "Se han eliminado todos los parámetros de verificación de este grupo.",
+	"params.none":                  "Aún no se han añadido parámetros de verificación. Usa /add_verification_params para añadir uno.",
+	"params.list_title":            "<b>Parámetros de verificación del grupo:</b>\n\n",
+	"params.type_line":             "<b>Tipo:</b> <code>%s</code>%s\n",
+	"params.name_line":             "<b>Nombre:</b> <code>%s</code>\n",
+	"params.format_error":          "Error al formatear el JSON\n\n",
+	"params.restriction_line":      "<b>Tipo de restricción para nuevos miembros:</b> ",
+	"params.config_failed":         "No se han configurado parámetros de verificación para este grupo. Error al obtener la configuración del grupo",
+	"params.not_set":               "No se han configurado parámetros de verificación para este grupo.",
+	"params.only_one":              "Solo hay un tipo de verificación disponible. No es posible cambiarlo.",
+	"params.invalid_selection":     "Selección no válida.",
+	"params.already_active":        "El tipo de verificación seleccionado '%s' ya está activo.",
+	"params.activated":             "El tipo de verificación '%s' se ha activado.",
+	"params.select_active":         "Selecciona el tipo de verificación que quieres activar:",
+	"params.select":                "Selecciona un parámetro de verificación para gestionarlo:",
+	"params.none_left":             "No quedan parámetros de verificación. Usa /add_verification_params para añadir uno.",
+	"params.gone":                  "Este parámetro de verificación ha cambiado o ya no existe. Ejecuta /list_verification_params de nuevo.",
+	"params.list_header":           "Parámetros de verificación:",
+	"params.item":                  "Parámetro %s",
+	"params.choose_action":         "Elige una acción:",
+	"params.button_edit":           "Editar",
+	"params.button_duplicate":      "Duplicar",
+	"params.button_delete":         "Eliminar",
+	"params.button_delete_confirm": "Sí, eliminar",
+	"params.button_cancel":         "Cancelar",
+	"params.button_up":             "Subir",
+	"params.button_down":           "Bajar",
+	"params.button_rename":         "Renombrar",
+	"params.button_description":    "Descripción",
+	"params.button_back":           "Atrás",
+	"params.send_new_json":         "Envía el nuevo JSON para el parámetro #%d. Valor actual:\n<pre>%s</pre>",
+	"params.duplicated":            "Se ha duplicado el parámetro #%d.",
+	"params.confirm_delete":        "¿Eliminar el parámetro %s? Esta acción no se puede deshacer.",
+	"params.deleted":               "Se ha eliminado el parámetro #%d.",
+	"params.deleted_active":        "Se ha eliminado el parámetro #%d. Era el activo, ahora los nuevos miembros se verifican con el parámetro #%d (%s).",
+	"params.moved_up":              "Se ha subido el parámetro #%d.",
+	"params.moved_down":            "Se ha bajado el parámetro #%d.",
+	"params.send_name":             "Envía un nuevo nombre para el parámetro #%d, o '-' para quitar el nombre.",
+	"params.send_description":      "Envía una descripción del parámetro #%d para los miembros, o '-' para quitarla.",
+	"params.update_failed":         "No se pudo actualizar el parámetro #%d: %v",
+	"params.updated":               "Se ha actualizado el parámetro #%d.",
+	"params.name_too_long":         "El nombre es demasiado largo, usa como máximo %d caracteres.",
+	"params.rename_failed":         "No se pudo renombrar el parámetro #%d: %v",
+	"params.renamed":               "Se ha renombrado el parámetro #%d.",
+	"params.description_too_long":  "La descripción es demasiado larga, usa como máximo %d caracteres.",
+	"params.describe_failed":       "No se pudo actualizar la descripción del parámetro #%d: %v",
+	"params.described":             "Se ha actualizado la descripción del parámetro #%d.",
+
+	// Presets
+	"presets.pick":         "O elige una plantilla predefinida:",
+	"presets.gone":         "Esta plantilla ya no está disponible. Ejecuta /add_verification_params de nuevo.",
+	"presets.build_failed": "No se pudieron crear los parámetros de verificación: %v",
+	"presets.save_failed":  "No se pudieron guardar los parámetros de verificación. Inténtalo de nuevo.",
+	"presets.added":        "Se ha añadido el parámetro de verificación \"%s\" al grupo.",
+
+	"presets.question_issuers":              "Envía los DID de los emisores de confianza separados por comas, o '-' para aceptar cualquier emisor.",
+	"presets.age.title":                     "Mayor de N años",
+	"presets.age.description":               "Los miembros demuestran que superan una edad dada con una KYCAgeCredential.",
+	"presets.age.question_age":              "¿Cuál es la edad mínima? (p. ej., 18)",
+	"presets.age.name":                      "Verificación de edad %d+",
+	"presets.age.params_description":        "Demuestra que tienes al menos %d años sin revelar tu fecha de nacimiento.",
+	"presets.country.title":                 "País de residencia",
+	"presets.country.description":           "Los miembros demuestran que viven en uno de los países indicados con una KYCCountryOfResidenceCredential.",
+	"presets.country.question_codes":        "Envía los países permitidos como códigos numéricos ISO 3166-1 separados por comas (p. ej., 840, 804).",
+	"presets.country.name":                  "Verificación del país de residencia",
+	"presets.country.params_description":    "Demuestra que vives en uno de los países permitidos sin revelar en cuál.",
+	"presets.uniqueness.title":              "Prueba de unicidad",
+	"presets.uniqueness.description":        "Los miembros demuestran que son un ser humano único con una credencial de prueba de unicidad.",
+	"presets.uniqueness.question_issuer":    "Envía el DID del emisor de unicidad de confianza, o '-' para aceptar cualquier emisor.",
+	"presets.uniqueness.question_context":   "Envía el contexto JSON-LD de la credencial, o '-' para usar %s.",
+	"presets.uniqueness.question_type":      "Envía el tipo de credencial, o '-' para usar %s.",
+	"presets.uniqueness.name":               "Prueba de unicidad",
+	"presets.uniqueness.params_description": "Demuestra que eres un ser humano único sin revelar quién eres.",
+	"presets.membership.title":              "Credencial de membresía (estilo POAP)",
+	"presets.membership.description":        "Los miembros demuestran que tienen una credencial de un tipo dado, como la asistencia a un evento o una tarjeta de socio.",
+	"presets.membership.question_context":   "Envía la URL del contexto JSON-LD del esquema de la credencial.",
+	"presets.membership.question_type":      "Envía el tipo de credencial (p. ej., EventAttendance).",
+	"presets.membership.question_issuer":    "Envía el DID del emisor, o '-' para aceptar cualquier emisor.",
+	"presets.membership.name":               "Titular de %s",
+	"presets.membership.params_description": "Demuestra que tienes una credencial %s.",
+	"presets.error_required":                "Este valor es obligatorio.",
+	"presets.error_empty":                   "La respuesta está vacía.",
+	"presets.error_age":                     "La edad debe ser un número entre %d y %d.",
+	"presets.error_no_country":              "Se necesita al menos un código de país.",
+	"presets.error_country_code":            "%q no es un código numérico de país ISO 3166-1.",
+	"presets.error_not_did":                 "%q no es un DID.",
+	"presets.error_url":                     "%q no es una URL de esquema válida.",
+	"presets.error_credential_type":         "El tipo de credencial debe ser una sola palabra.",
+
+	// Restriction type
+	"restriction.ask":           "Para configurar la restricción de los nuevos miembros",
+	"restriction.select":        "Selecciona el tipo de restricción:",
+	"restriction.button_block":  "Bloquear",
+	"restriction.button_delete": "Eliminar",
+	"restriction.set":           "Tipo de restricción establecido en '%s'.",
+	"restriction.current":       "Tipo de restricción actual del grupo '%s': %s.\n\nSelecciona un nuevo tipo de restricción:",
+	"restriction.already":       "El tipo de restricción ya es '%s'.",
+	"restriction.changed":       "El tipo de restricción del grupo '%s' se ha cambiado a '%s'.",
+
+	// /test_verification
+	"test.config_error":      "Error en la configuración de la verificación. Contacta con el administrador del grupo.",
+	"test.button":            "Probar verificación (%s)",
+	"test.prompt":            "Prueba la comprobación \"%s\" pulsando el enlace de abajo:",
+	"test.send_failed":       "No se pudo enviar el enlace de verificación. Revisa tus mensajes privados.",
+	"test.sent":              "Te he enviado un enlace de verificación por mensaje privado. Revisa tu bandeja de entrada.",
+	"test.token_file_failed": "No se pudo crear el archivo con el AuthToken.",
+	"test.current_params":    "Este es el parámetro de verificación que se está probando:\n<pre>%s</pre>\n",
+	"test.success":           "La prueba ha sido un éxito. Los parámetros están bien configurados y la verificación funciona.",
+
+	// Verified users
+	"verified.none":    "No hay usuarios verificados en el grupo '%s'.",
+	"verified.list":    "Usuarios verificados del grupo '%s':\n\n",
+	"verified.deleted": "Se han eliminado todos los usuarios verificados del grupo '%s'.",
+
+	// /api_key
+	"api_key.none":          "No tienes ninguna clave de API.",
+	"api_key.revoke_failed": "No se pudo revocar la clave de API. Inténtalo de nuevo más tarde.",
+	"api_key.revoked":       "Se ha revocado tu clave de API.",
+	"api_key.create_failed": "No se pudo crear la clave de API. Inténtalo de nuevo más tarde.",
+	"api_key.created":       "Tu clave de API de administrador ya tiene acceso al grupo '%s':\n\n<code>%s</code>\n\nEnvíala en la cabecera 'Authorization: Bearer'. La clave solo se muestra una vez, un nuevo /api_key la sustituye y mantiene el acceso a tus otros grupos. Usa /api_key revoke para eliminarla.",
+	"api_key.send_failed":   "No se pudo enviar la clave de API. Inicia primero un chat privado conmigo.",
+	"api_key.sent":          "Te he enviado la clave de API por chat privado.",
+
+	// /export_config and /import_config
+	"config.export_failed":   "No se pudo exportar la configuración del grupo.",
+	"config.export_caption":  "Configuración de verificación del grupo '%s'. Envíala con /import_config para aplicarla a otro grupo.",
+	"config.send_failed":     "No se pudo enviar la configuración. Inicia primero un chat privado conmigo.",
+	"config.import_prompt":   "Envía la configuración del grupo como texto JSON o como el archivo .json que obtuviste con /export_config.",
+	"config.file_too_large":  "El archivo es demasiado grande para una configuración de grupo.",
+	"config.download_failed": "No se pudo descargar el archivo. Inténtalo de nuevo con /import_config.",
+	"config.read_failed":     "No se pudo leer el archivo. Inténtalo de nuevo con /import_config.",
+	"config.invalid_json":    "JSON de configuración no válido: %v",
+	"config.invalid":         "La configuración no es válida: %v",
+	"config.unchanged":       "La configuración importada es igual a la actual. No hay nada que cambiar.",
+	"config.button_apply":    "Aplicar",
+	"config.button_cancel":   "Cancelar",
+	"config.apply_failed":    "No se pudo aplicar la configuración.",
+	"config.applied":         "Se ha aplicado la configuración importada.",
+	"config.outdated":        "Esta vista previa está desactualizada, la configuración no se ha cambiado. Envía la configuración de nuevo para ver una vista previa nueva.",
+	"config.cancelled":       "Importación cancelada. La configuración actual no ha cambiado.",
+	"config.preview":         "Se aplicarán los siguientes cambios:\n\n%s\n\n¿Aplicar la configuración importada?",
+	"config.param_added":     "+ parámetro #%d añadido: %s",
+	"config.param_removed":   "- parámetro #%d eliminado: %s",
+	"config.param_changed":   "~ parámetro #%d cambiado: %s → %s",
+	"config.active_changed":  "~ parámetro activo: %s → %s",
+	"config.restriction":     "~ tipo de restricción: %s → %s",
+	"config.timeout":         "~ tiempo de verificación: %s → %s",
+	"config.language":        "~ idioma: %s → %s",
+	"config.template":        "~ mensaje %s cambiado",
+	"config.timeout_default": "por defecto (%s)",
+	"config.timeout_minutes": "%d min",
+
+	// /set_language
+	"language.current":     "Idioma predeterminado del grupo: %s.\nIdiomas disponibles: %s.\n\nLos miembros reciben los mensajes en el idioma de su aplicación de Telegram si el bot lo habla, el resto en el idioma predeterminado. Usa /set_language <código> para cambiarlo o /set_language default para restablecerlo.",
+	"language.not_set":     "sin definir (%s)",
+	"language.set":         "El idioma predeterminado del grupo ahora es %s.",
+	"language.reset":       "El idioma predeterminado del grupo se ha restablecido a %s.",
+	"language.unsupported": "El idioma '%s' no está disponible. Idiomas disponibles: %s.",
+	"language.failed":      "No se pudo cambiar el idioma del grupo. Inténtalo de nuevo más tarde.",
+
+	// /set_message
+	"template.usage":          "Mensajes del grupo:\n%s\n\nMarcadores: %s.\n\nUsa /set_message <mensaje> para escribir un texto nuevo, o directamente /set_message <mensaje> <texto>, por ejemplo\n/set_message welcome ¡Hola, {username}! Supera la comprobación \"{requirement}\" antes de {deadline}.\n\nUsa /set_message <mensaje> default para restaurar el mensaje predeterminado.",
+	"template.custom":         "personalizado",
+	"template.default":        "predeterminado",
+	"template.unknown":        "Mensaje desconocido '%s'. Mensajes: %s.",
+	"template.prompt":         "Envía el nuevo mensaje %s. Marcadores: %s.\n\nMensaje actual:\n%s",
+	"template.prompt_default": "Envía el nuevo mensaje %s. Marcadores: %s.\n\nAhora el grupo usa el mensaje predeterminado.",
+	"template.invalid":        "El mensaje no es válido: %v. Envía otro texto.",
+	"template.preview":        "Vista previa del mensaje %s:\n\n%s\n\n¿Guardarlo?",
+	"template.button_save":    "Guardar",
+	"template.button_cancel":  "Cancelar",
+	"template.saved":          "Se ha guardado el mensaje %s.",
+	"template.outdated":       "Esta vista previa está desactualizada, el mensaje no se ha cambiado. Usa /set_message para ver una vista previa nueva.",
+	"template.cancelled":      "El mensaje %s no ha cambiado.",
+	"template.reset":          "Se ha restaurado el mensaje %s predeterminado.",
+	"template.save_failed":    "No se pudo guardar el mensaje. Inténtalo de nuevo más tarde.",
+
+	// /invite_link and the invite deep links
+	"invite.link":           "Comparte este enlace: quienes lo abran y superen la verificación recibirán un enlace de invitación de un solo uso al grupo, válido durante %[2]d min:\n\n%[1]s\n\nInvitaciones emitidas: %[3]d, usadas: %[4]d.\n\nUsa /invite_link revoke para desactivar el enlace.",
+	"invite.none":           "El grupo no tiene enlace de invitación.",
+	"invite.revoked":        "El enlace de invitación ha sido desactivado. Las invitaciones ya enviadas siguen siendo válidas hasta que caduquen.",
+	"invite.revoke_failed":  "No se pudo desactivar el enlace de invitación. Inténtalo de nuevo más tarde.",
+	"invite.create_failed":  "No se pudo crear el enlace de invitación. Inténtalo de nuevo más tarde.",
+	"invite.not_configured": "Primero configura los parámetros de verificación del grupo, por ejemplo con /add_verification_params.",
+	"invite.invalid":        "Este enlace de invitación ya no es válido. Pide uno nuevo al administrador del grupo.",
+	"invite.already_member": "Ya eres miembro del grupo \"%s\".",
+	"invite.busy":           "Primero termina tu verificación actual, usa /verify para recibir el enlace de nuevo.",
+	"invite.issued":         "Aquí tienes tu enlace de invitación al grupo. Funciona una sola vez y caduca el %s.",
+	"invite.join_button":    "Unirse al grupo",
+	"invite.link_failed":    "No se pudo crear tu enlace de invitación. Contacta con el administrador del grupo.",
+
+	// Deep links and /verify_link
+	"deeplink.invalid":     "Este enlace de verificación no es válido. Usa el botón del grupo o ejecuta /verify.",
+	"deeplink.not_pending": "No tienes ninguna verificación pendiente en el grupo \"%s\".",
+	"deeplink.verify_link": "Comparte este enlace con los miembros que aún tienen que superar la verificación. Abre el bot e inicia su verificación de inmediato:\n\n%s",
+
+	// Verification page
+	"verify_page.title":        "Verificación",
+	"verify_page.instructions": "Escanea el código QR con la app Privado ID, o abre la solicitud en la billetera web en este dispositivo.",
+	"verify_page.qr_alt":       "Código QR de verificación",
+	"verify_page.open_wallet":  "Abrir en la billetera",
+	"verify_page.pending":      "Esperando la prueba...",
+	"verify_page.verified":     "Verificación superada. Puedes volver a Telegram.",
+	"verify_page.failed":       "La verificación ha fallado. Vuelve a Telegram e inténtalo de nuevo con /verify.",
+	"verify_page.not_found":    "La sesión de verificación no existe o ha caducado. Ejecuta /verify en Telegram de nuevo.",
+}
@@ -0,0 +1,76 @@
+// Package i18n translates the messages of the bot. The catalogs map message keys to fmt format strings,
+// English is complete and the other languages fall back to it for the messages they miss.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Default is the language of the users and groups without a supported language
+const Default = "en"
+
+// catalogs holds the messages of every supported language
+var catalogs = map[string]map[string]string{
+	"en": en,
+	"uk": uk,
+	"es": es,
+}
+
+// names are the names of the languages in the languages themselves
+var names = map[string]string{
+	"en": "English",
+	"uk": "Українська",
+	"es": "Español",
+}
+
+// Languages returns the codes of the supported languages
+func Languages() []string {
+	languages := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// IsSupported reports whether there is a catalog for the language code
+func IsSupported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Match returns the supported language of a Telegram language_code such as "uk" or "es-419",
+// or an empty string if there is no catalog for it
+func Match(languageCode string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(languageCode), "-")
+	if IsSupported(lang) {
+		return lang
+	}
+	return ""
+}
+
+// Name returns the name of the language in the language itself, e.g. "Español"
+func Name(lang string) string {
+	if name, ok := names[lang]; ok {
+		return name
+	}
+	return lang
+}
+
+// T returns the message in the language formatted with the args, messages missing in the catalog are taken from English
+func T(lang, key string, args ...any) string {
+	format, ok := catalogs[lang][key]
+	if !ok {
+		format, ok = en[key]
+	}
+	if !ok {
+		// A missing key is a bug, showing it is better than an empty message
+		return key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,74 @@
+package i18n
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"en", "en"},
+		{"uk", "uk"},
+		{"es-419", "es"},
+		{"EN-us", "en"},
+		{"de", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.code); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		key  string
+		args []any
+		want string
+	}{
+		{"formatted", "en", "start.hello", []any{"Ann"}, fmt.Sprintf(en["start.hello"], "Ann")},
+		{"translated", "uk", "verify.button", nil, uk["verify.button"]},
+		{"unknown language falls back to English", "de", "verify.button", nil, en["verify.button"]},
+		{"missing key shows the key", "es", "no.such_key", nil, "no.such_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := T(tt.lang, tt.key, tt.args...); got != tt.want {
+				t.Errorf("T(%q, %q) = %q, want %q", tt.lang, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+// verbs matches the fmt verbs of a message, the translations must use the same ones in the same order
+var verbs = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestCatalogsMatchEnglish(t *testing.T) {
+	for _, lang := range Languages() {
+		catalog := catalogs[lang]
+		for key, format := range en {
+			translated, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s: %q is missing", lang, key)
+				continue
+			}
+			if got, want := fmt.Sprint(verbs.FindAllString(translated, -1)), fmt.Sprint(verbs.FindAllString(format, -1)); got != want {
+				t.Errorf("%s: %q has verbs %s, English has %s", lang, key, got, want)
+			}
+		}
+		for key := range catalog {
+			if _, ok := en[key]; !ok {
+				t.Errorf("%s: %q is not in the English catalog", lang, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package i18n_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+	"github.com/ArtemHvozdov/tg-auth-bot/presets"
+)
+
+// TestPresetsKeys checks that the English catalog has the texts of the presets,
+// TestCatalogsMatchEnglish checks the other catalogs have them too
+func TestPresetsKeys(t *testing.T) {
+	// Answers every prompt rejects in one way or another
+	invalidAnswers := []string{"", presets.SkipAnswer, "not valid", ",", "1000"}
+
+	for _, preset := range presets.All() {
+		// The texts of the preset and the name and description of the params it builds
+		var keys []string
+		for _, name := range []string{"title", "description", "name", "params_description"} {
+			keys = append(keys, "presets."+preset.Key+"."+name)
+		}
+		for _, prompt := range preset.Prompts {
+			keys = append(keys, prompt.Question)
+
+			for _, text := range invalidAnswers {
+				_, err := prompt.Answer(text)
+				var answerErr *presets.AnswerError
+				if errors.As(err, &answerErr) {
+					keys = append(keys, answerErr.Key)
+				} else if err != nil {
+					t.Errorf("%s: answer %q is rejected with %v, want an AnswerError", preset.Key, text, err)
+				}
+			}
+		}
+
+		for _, key := range keys {
+			if i18n.T(i18n.Default, key) == key {
+				t.Errorf("%s: %q is missing", preset.Key, key)
+			}
+		}
+	}
+}
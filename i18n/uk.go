@@ -0,0 +1,269 @@
+package i18n
+
+// uk is the Ukrainian catalog
+var uk = map[string]string{
+	// Messages to every user
+	"start.hello":           "Привіт, %s!\n\nЯкщо ви хочете пройти верифікацію, виконайте команду /verify.\nЯкщо ви хочете налаштувати бота для перевірки учасників, виконайте команду /setup.",
+	"welcome.requirement":   "верифікація",
+	"welcome.button":        "Почати верифікацію",
+	"welcome.message":       "Привіт, @%s! Будь ласка, пройдіть перевірку \"%s\": натисніть кнопку нижче та запустіть бота.",
+	"verify.not_pending":    "Ви не очікуєте верифікації в жодній групі.",
+	"verify.intro":          "Привіт, @%s! Щоб залишитися в групі \"%s\", вам потрібно пройти верифікацію.",
+	"verify.intro_join":     "Привіт, @%s! Щоб приєднатися до групи \"%s\", вам потрібно пройти верифікацію.",
+	"verify.intro_invite":   "Привіт, @%s! Щоб отримати посилання-запрошення до групи \"%s\", вам потрібно пройти верифікацію.",
+	"verify.busy_join":      "Спочатку завершіть поточну верифікацію, потім надішліть запит на вступ до групи \"%s\" ще раз.",
+	"verify.busy_member":    "Спочатку завершіть поточну верифікацію, потім приєднайтеся до групи \"%s\" ще раз.",
+	"verify.not_configured": "Верифікацію для цієї групи ще не налаштовано. Будь ласка, зверніться до адміністратора групи.",
+	"verify.request_failed": "Не вдалося створити запит на верифікацію. Будь ласка, спробуйте пізніше.",
+	"verify.button":         "Пройти верифікацію з Privado ID",
+	"verify.prompt":         "Натисніть кнопку нижче, щоб пройти перевірку \"%s\":",
+	"result.success":        "Ви успішно пройшли верифікацію і можете залишитися в групі.",
+	"result.success_join":   "Ви успішно пройшли верифікацію, ваш запит на вступ до групи схвалено.",
+	"result.failure":        "Ви не пройшли верифікацію і були видалені з групи.",
+	"result.failure_join":   "Ви не пройшли верифікацію, ваш запит на вступ до групи відхилено.",
+	"result.timeout":        "Ви не пройшли верифікацію вчасно і були видалені з групи.",
+	"result.timeout_join":   "Ви не пройшли верифікацію вчасно, ваш запит на вступ до групи відхилено.",
+	"result.success_invite": "Ви успішно пройшли верифікацію.",
+	"result.failure_invite": "Ви не пройшли верифікацію, тому не можете отримати посилання-запрошення до групи.",
+	"result.timeout_invite": "Ви не пройшли верифікацію вчасно. Відкрийте посилання-запрошення ще раз, щоб почати заново.",
+
+	// Shared by the admin commands
+	"common.group_only":              "Цю команду можна використовувати лише в групах і супергрупах.",
+	"common.private_only":            "Цю команду можна використовувати лише в особистому чаті зі мною.",
+	"common.not_admin":               "Ви не є адміністратором цієї групи.",
+	"common.no_group":                "Ви не пов'язані з жодною групою. Спочатку виконайте /setup.",
+	"common.need_group_verification": "Потрібно вказати групу для налаштування верифікації.",
+	"common.need_group_restriction":  "Потрібно вказати групу для налаштування обмежень.",
+	"common.need_setup_group":        "Потрібно налаштувати групу для верифікації.",
+	"common.chat_info_failed":        "Не вдалося отримати інформацію про чат.",
+	"common.group_chat_failed":       "Не вдалося отримати груповий чат. Будь ласка, спробуйте ще раз.",
+	"common.group_config_failed":     "Не вдалося отримати конфігурацію групи.",
+	"common.params_not_configured":   "Параметри верифікації для вашої групи не налаштовано.",
+	"common.failed":                  "Помилка: %v",
+	"common.not_set":                 "Не встановлено",
+	"common.active":                  " (активний)",
+	"common.none":                    "немає",
+
+	// /setup and /check_admin
+	"setup.intro":                  "Щоб налаштувати мене для верифікації у вашій групі, додайте мене до групи як адміністратора та викличте в групі команду /check_admin.",
+	"check_admin.continue_private": "Адміністраторе, поверніться до особистого чату зі мною, щоб продовжити налаштування",
+	"check_admin.bot_role_failed":  "Я не зміг отримати свою роль у цій групі. Переконайтеся, що я адміністратор.",
+	"check_admin.bot_not_admin":    "Я не адміністратор у групі '%s'. Будь ласка, призначте мене адміністратором.",
+	"check_admin.user_role_failed": "Я не зміг отримати вашу роль у цій групі.",
+	"check_admin.user_not_admin":   "@%s, ви не адміністратор у групі '%s'. Ви не можете налаштовувати мене для цієї групи.",
+	"check_admin.confirmed":        "Я підтвердив ваш статус адміністратора та свою роль у групі '%s'. Тепер можна продовжити налаштування.",
+	"check_admin.add_params":       "Щоб додати параметри верифікації, викличте команду\n /add_verification_params",
+
+	// Verification parameters
+	"params.send_json":             "Надішліть параметри верифікації у форматі JSON. Поля 'name' та 'description' необов'язкові й показуються учасникам. Приклад:\n\n%s",
+	"params.invalid_json":          "Неправильний формат JSON. Переконайтеся, що параметри відповідають очікуваній структурі.",
+	"params.missing_fields":        "У JSON бракує обов'язкових полів. Додайте 'circuitId', 'id' та 'query'.",
+	"params.added":                 "Параметри верифікації JSON додано для групи.",
+	"params.another_added":         "Додано ще один параметр верифікації.",
+	"params.set_for_group":         "Параметри верифікації успішно встановлено для групи '%s'.",
+	"params.cleared":               "Усі параметри верифікації для цієї групи успішно видалено.",
+	"params.none":                  "Параметри верифікації ще не додано. Скористайтеся /add_verification_params, щоб додати.",
+	"params.list_title":            "<b>Параметри верифікації групи:</b>\n\n",
+	"params.type_line":             "<b>Тип:</b> <code>%s</code>%s\n",
+	"params.name_line":             "<b>Назва:</b> <code>%s</code>\n",
+	"params.format_error":          "Помилка форматування JSON\n\n",
+	"params.restriction_line":      "<b>Тип обмеження для нових учасників:</b> ",
+	"params.config_failed":         "Для цієї групи не встановлено параметрів верифікації. Помилка отримання конфігурації групи",
+	"params.not_set":               "Для цієї групи не встановлено параметрів верифікації.",
+	"params.only_one":              "Доступний лише один тип верифікації. Перемикання неможливе.",
+	"params.invalid_selection":     "Неправильний вибір.",
+	"params.already_active":        "Вибраний тип верифікації '%s' уже активний.",
+	"params.activated":             "Тип верифікації '%s' зроблено активним.",
+	"params.select_active":         "Виберіть тип верифікації, який потрібно активувати:",
+	"params.select":                "Виберіть параметр верифікації для керування:",
+	"params.none_left":             "Параметрів верифікації не залишилося. Скористайтеся /add_verification_params, щоб додати.",
+	"params.gone":                  "Цей параметр верифікації змінився або більше не існує. Викличте /list_verification_params ще раз.",
+	"params.list_header":           "Параметри верифікації:",
+	"params.item":                  "Параметр %s",
+	"params.choose_action":         "Виберіть дію:",
+	"params.button_edit":           "Змінити",
+	"params.button_duplicate":      "Дублювати",
+	"params.button_delete":         "Видалити",
+	"params.button_delete_confirm": "Так, видалити",
+	"params.button_cancel":         "Скасувати",
+	"params.button_up":             "Вгору",
+	"params.button_down":           "Вниз",
+	"params.button_rename":         "Перейменувати",
+	"params.button_description":    "Опис",
+	"params.button_back":           "Назад",
+	"params.send_new_json":         "Надішліть новий JSON для параметра #%d. Поточне значення:\n<pre>%s</pre>",
+	"params.duplicated":            "Параметр #%d продубльовано.",
+	"params.confirm_delete":        "Видалити параметр %s? Цю дію не можна скасувати.",
+	"params.deleted":               "Параметр #%d видалено.",
+	"params.deleted_active":        "Параметр #%d видалено. Він був активним, тепер нові учасники проходять верифікацію за параметром #%d (%s).",
+	"params.moved_up":              "Параметр #%d переміщено вгору.",
+	"params.moved_down":            "Параметр #%d переміщено вниз.",
+	"params.send_name":             "Надішліть нову назву для параметра #%d або '-', щоб прибрати назву.",
+	"params.send_description":      "Надішліть опис параметра #%d для учасників або '-', щоб прибрати його.",
+	"params.update_failed":         "Не вдалося оновити параметр #%d: %v",
+	"params.updated":               "Параметр #%d оновлено.",
+	"params.name_too_long":         "Назва задовга, використайте не більше %d символів.",
+	"params.rename_failed":         "Не вдалося перейменувати параметр #%d: %v",
+	"params.renamed":               "Параметр #%d перейменовано.",
+	"params.description_too_long":  "Опис задовгий, використайте не більше %d символів.",
+	"params.describe_failed":       "Не вдалося оновити опис параметра #%d: %v",
+	"params.described":             "Опис параметра #%d оновлено.",
+
+	// Presets
+	"presets.pick":         "Або виберіть готовий шаблон:",
+	"presets.gone":         "Цей шаблон більше не доступний. Будь ласка, викличте /add_verification_params ще раз.",
+	"presets.build_failed": "Не вдалося створити параметри верифікації: %v",
+	"presets.save_failed":  "Не вдалося зберегти параметри верифікації. Будь ласка, спробуйте ще раз.",
+	"presets.added":        "Параметр верифікації \"%s\" додано для групи.",
+
+	"presets.question_issuers":              "Надішліть DID довірених емітентів через кому або '-', щоб приймати будь-якого емітента.",
+	"presets.age.title":                     "Вік від N",
+	"presets.age.description":               "Учасники доводять, що вони старші за вказаний вік, за допомогою KYCAgeCredential.",
+	"presets.age.question_age":              "Який мінімальний вік? (наприклад, 18)",
+	"presets.age.name":                      "Перевірка віку %d+",
+	"presets.age.params_description":        "Доведіть, що вам щонайменше %d років, не розкриваючи дату народження.",
+	"presets.country.title":                 "Країна проживання",
+	"presets.country.description":           "Учасники доводять, що живуть в одній із вказаних країн, за допомогою KYCCountryOfResidenceCredential.",
+	"presets.country.question_codes":        "Надішліть дозволені країни як числові коди ISO 3166-1 через кому (наприклад, 840, 804).",
+	"presets.country.name":                  "Перевірка країни проживання",
+	"presets.country.params_description":    "Доведіть, що ви живете в одній із дозволених країн, не розкриваючи, в якій саме.",
+	"presets.uniqueness.title":              "Доказ унікальності",
+	"presets.uniqueness.description":        "Учасники доводять, що вони унікальна людина, за допомогою облікових даних унікальності.",
+	"presets.uniqueness.question_issuer":    "Надішліть DID довіреного емітента унікальності або '-', щоб приймати будь-якого емітента.",
+	"presets.uniqueness.question_context":   "Надішліть JSON-LD контекст облікових даних або '-', щоб використати %s.",
+	"presets.uniqueness.question_type":      "Надішліть тип облікових даних або '-', щоб використати %s.",
+	"presets.uniqueness.name":               "Доказ унікальності",
+	"presets.uniqueness.params_description": "Доведіть, що ви унікальна людина, не розкриваючи, хто ви.",
+	"presets.membership.title":              "Облікові дані членства (на зразок POAP)",
+	"presets.membership.description":        "Учасники доводять, що мають облікові дані вказаного типу, наприклад відвідування події або членський квиток.",
+	"presets.membership.question_context":   "Надішліть URL JSON-LD контексту схеми облікових даних.",
+	"presets.membership.question_type":      "Надішліть тип облікових даних (наприклад, EventAttendance).",
+	"presets.membership.question_issuer":    "Надішліть DID емітента або '-', щоб приймати будь-якого емітента.",
+	"presets.membership.name":               "Власник %s",
+	"presets.membership.params_description": "Доведіть, що у вас є облікові дані %s.",
+	"presets.error_required":                "Це значення обов'язкове.",
+	"presets.error_empty":                   "Відповідь порожня.",
+	"presets.error_age":                     "Вік має бути числом від %d до %d.",
+	"presets.error_no_country":              "Потрібен щонайменше один код країни.",
+	"presets.error_country_code":            "%q не є числовим кодом країни ISO 3166-1.",
+	"presets.error_not_did":                 "%q не є DID.",
+	"presets.error_url":                     "%q не є коректним URL схеми.",
+	"presets.error_credential_type":         "Тип облікових даних має бути одним словом.",
+
+	// Restriction type
+	"restriction.ask":           "Щоб налаштувати обмеження для нових учасників",
+	"restriction.select":        "Виберіть тип обмеження:",
+	"restriction.button_block":  "Блокувати",
+	"restriction.button_delete": "Видаляти",
+	"restriction.set":           "Тип обмеження встановлено: '%s'.",
+	"restriction.current":       "Поточний тип обмеження для групи '%s': %s.\n\nВиберіть новий тип обмеження:",
+	"restriction.already":       "Тип обмеження вже встановлено: '%s'.",
+	"restriction.changed":       "Тип обмеження для групи '%s' змінено на '%s'.",
+
+	// /test_verification
+	"test.config_error":      "Помилка конфігурації верифікації. Будь ласка, зверніться до адміністратора групи.",
+	"test.button":            "Тестова верифікація (%s)",
+	"test.prompt":            "Перевірте роботу перевірки \"%s\", натиснувши посилання нижче:",
+	"test.send_failed":       "Не вдалося надіслати посилання для верифікації. Перевірте особисті повідомлення.",
+	"test.sent":              "Посилання для верифікації надіслано вам в особисті повідомлення. Будь ласка, перевірте їх.",
+	"test.token_file_failed": "Не вдалося створити файл з AuthToken.",
+	"test.current_params":    "Поточний параметр верифікації, що тестується:\n<pre>%s</pre>\n",
+	"test.success":           "Тест пройшов успішно. Параметри налаштовано правильно, верифікація працює.",
+
+	// Verified users
+	"verified.none":    "У групі '%s' немає верифікованих користувачів.",
+	"verified.list":    "Верифіковані користувачі групи '%s':\n\n",
+	"verified.deleted": "Усіх верифікованих користувачів групи '%s' видалено.",
+
+	// /api_key
+	"api_key.none":          "У вас немає API-ключа.",
+	"api_key.revoke_failed": "Не вдалося відкликати API-ключ. Будь ласка, спробуйте пізніше.",
+	"api_key.revoked":       "Ваш API-ключ відкликано.",
+	"api_key.create_failed": "Не вдалося створити API-ключ. Будь ласка, спробуйте пізніше.",
+	"api_key.created":       "Ваш адміністраторський API-ключ тепер має доступ до групи '%s':\n\n<code>%s</code>\n\nПередавайте його в заголовку 'Authorization: Bearer'. Ключ показується лише один раз, нова команда /api_key замінює його і зберігає доступ до інших ваших груп. Скористайтеся /api_key revoke, щоб видалити його.",
+	"api_key.send_failed":   "Не вдалося надіслати API-ключ. Спершу почніть особистий чат зі мною.",
+	"api_key.sent":          "Я надіслав вам API-ключ в особистому чаті.",
+
+	// /export_config and /import_config
+	"config.export_failed":   "Не вдалося експортувати конфігурацію групи.",
+	"config.export_caption":  "Конфігурація верифікації групи '%s'. Надішліть її з /import_config, щоб застосувати до іншої групи.",
+	"config.send_failed":     "Не вдалося надіслати конфігурацію. Спершу почніть особистий чат зі мною.",
+	"config.import_prompt":   "Надішліть конфігурацію групи як текст JSON або як файл .json, отриманий з /export_config.",
+	"config.file_too_large":  "Файл завеликий для конфігурації групи.",
+	"config.download_failed": "Не вдалося завантажити файл. Спробуйте ще раз з /import_config.",
+	"config.read_failed":     "Не вдалося прочитати файл. Спробуйте ще раз з /import_config.",
+	"config.invalid_json":    "Неправильний JSON конфігурації: %v",
+	"config.invalid":         "Конфігурація некоректна: %v",
+	"config.unchanged":       "Імпортована конфігурація збігається з поточною. Змінювати нічого.",
+	"config.button_apply":    "Застосувати",
+	"config.button_cancel":   "Скасувати",
+	"config.apply_failed":    "Не вдалося застосувати конфігурацію.",
+	"config.applied":         "Імпортовану конфігурацію застосовано.",
+	"config.outdated":        "Цей попередній перегляд застарів, конфігурацію не змінено. Надішліть конфігурацію ще раз, щоб побачити новий перегляд.",
+	"config.cancelled":       "Імпорт скасовано. Поточну конфігурацію не змінено.",
+	"config.preview":         "Буде застосовано такі зміни:\n\n%s\n\nЗастосувати імпортовану конфігурацію?",
+	"config.param_added":     "+ параметр #%d додано: %s",
+	"config.param_removed":   "- параметр #%d видалено: %s",
+	"config.param_changed":   "~ параметр #%d змінено: %s → %s",
+	"config.active_changed":  "~ активний параметр: %s → %s",
+	"config.restriction":     "~ тип обмеження: %s → %s",
+	"config.timeout":         "~ час на верифікацію: %s → %s",
+	"config.language":        "~ мова: %s → %s",
+	"config.template":        "~ змінено повідомлення %s",
+	"config.timeout_default": "за замовчуванням (%s)",
+	"config.timeout_minutes": "%d хв",
+
+	// /set_language
+	"language.current":     "Мова групи за замовчуванням: %s.\nДоступні мови: %s.\n\nУчасники отримують повідомлення мовою свого застосунку Telegram, якщо бот її підтримує, решта — мовою за замовчуванням. Скористайтеся /set_language <код>, щоб змінити її, або /set_language default, щоб скинути.",
+	"language.not_set":     "не встановлено (%s)",
+	"language.set":         "Мову групи за замовчуванням змінено на %s.",
+	"language.reset":       "Мову групи за замовчуванням скинуто на %s.",
+	"language.unsupported": "Мова '%s' не підтримується. Доступні мови: %s.",
+	"language.failed":      "Не вдалося змінити мову групи. Будь ласка, спробуйте пізніше.",
+
+	// /set_message
+	"template.usage":          "Повідомлення групи:\n%s\n\nПідстановки: %s.\n\nСкористайтеся /set_message <повідомлення>, щоб написати новий текст, або одразу /set_message <повідомлення> <текст>, наприклад\n/set_message welcome Привіт, {username}! Пройдіть перевірку \"{requirement}\" до {deadline}.\n\nСкористайтеся /set_message <повідомлення> default, щоб повернути стандартне повідомлення.",
+	"template.custom":         "власне",
+	"template.default":        "стандартне",
+	"template.unknown":        "Невідоме повідомлення '%s'. Повідомлення: %s.",
+	"template.prompt":         "Надішліть нове повідомлення %s. Підстановки: %s.\n\nПоточне повідомлення:\n%s",
+	"template.prompt_default": "Надішліть нове повідомлення %s. Підстановки: %s.\n\nЗараз група використовує стандартне повідомлення.",
+	"template.invalid":        "Повідомлення некоректне: %v. Будь ласка, надішліть інший текст.",
+	"template.preview":        "Попередній перегляд повідомлення %s:\n\n%s\n\nЗберегти його?",
+	"template.button_save":    "Зберегти",
+	"template.button_cancel":  "Скасувати",
+	"template.saved":          "Повідомлення %s збережено.",
+	"template.outdated":       "Цей попередній перегляд застарів, повідомлення не змінено. Використайте /set_message, щоб побачити новий перегляд.",
+	"template.cancelled":      "Повідомлення %s не змінено.",
+	"template.reset":          "Для повідомлення %s повернуто стандартний текст.",
+	"template.save_failed":    "Не вдалося зберегти повідомлення. Будь ласка, спробуйте пізніше.",
+
+	// /invite_link and the invite deep links
+	"invite.link":           "Поширте це посилання: ті, хто відкриє його та пройде верифікацію, отримають одноразове посилання-запрошення до групи, дійсне %[2]d хв:\n\n%[1]s\n\nВидано запрошень: %[3]d, використано: %[4]d.\n\nВикористайте /invite_link revoke, щоб вимкнути посилання.",
+	"invite.none":           "У групи немає посилання-запрошення.",
+	"invite.revoked":        "Посилання-запрошення вимкнено. Вже надіслані запрошення діють, доки не сплине їхній термін.",
+	"invite.revoke_failed":  "Не вдалося вимкнути посилання-запрошення. Будь ласка, спробуйте пізніше.",
+	"invite.create_failed":  "Не вдалося створити посилання-запрошення. Будь ласка, спробуйте пізніше.",
+	"invite.not_configured": "Спочатку задайте параметри верифікації групи, наприклад командою /add_verification_params.",
+	"invite.invalid":        "Це посилання-запрошення більше не дійсне. Попросіть адміністратора групи про нове.",
+	"invite.already_member": "Ви вже учасник групи \"%s\".",
+	"invite.busy":           "Спочатку завершіть поточну верифікацію, використайте /verify, щоб отримати посилання ще раз.",
+	"invite.issued":         "Ось ваше посилання-запрошення до групи. Воно спрацює один раз і дійсне до %s.",
+	"invite.join_button":    "Приєднатися до групи",
+	"invite.link_failed":    "Не вдалося створити ваше посилання-запрошення. Зверніться до адміністратора групи.",
+
+	// Deep links and /verify_link
+	"deeplink.invalid":     "Це посилання для верифікації недійсне. Скористайтеся кнопкою в групі або викличте /verify.",
+	"deeplink.not_pending": "Ви не очікуєте на верифікацію в групі \"%s\".",
+	"deeplink.verify_link": "Поширте це посилання серед учасників, які ще мають пройти верифікацію. Воно відкриває бота й одразу розпочинає їхню верифікацію:\n\n%s",
+
+	// Verification page
+	"verify_page.title":        "Верифікація",
+	"verify_page.instructions": "Відскануйте QR-код у застосунку Privado ID або відкрийте запит у веб-гаманці на цьому пристрої.",
+	"verify_page.qr_alt":       "QR-код верифікації",
+	"verify_page.open_wallet":  "Відкрити в гаманці",
+	"verify_page.pending":      "Очікуємо на доказ...",
+	"verify_page.verified":     "Верифікацію пройдено. Можете повернутися до Telegram.",
+	"verify_page.failed":       "Верифікацію не пройдено. Поверніться до Telegram і спробуйте ще раз за допомогою /verify.",
+	"verify_page.not_found":    "Сесію верифікації не знайдено або вона закінчилася. Викличте /verify у Telegram ще раз.",
+}
@@ -0,0 +1,173 @@
+// Package logging builds the slog logger of the application. Secrets are always redacted from the records,
+// personal data (usernames, names and user IDs) unless it's turned off in the config.
+package logging
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Formats of the log output
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options configure the logger
+type Options struct {
+	Level  slog.Leveler // a *slog.LevelVar lets the level change at runtime
+	Format string       // text | json
+	// RedactPersonal hides usernames and names and replaces user IDs with pseudonyms
+	RedactPersonal bool
+}
+
+const redacted = "[REDACTED]"
+
+// Attribute keys whose values are secrets
+var secretKeys = map[string]bool{
+	"token":         true,
+	"auth_token":    true,
+	"jwz":           true,
+	"secret":        true,
+	"api_key":       true,
+	"authorization": true,
+	"password":      true,
+}
+
+// Attribute keys whose values are personal data
+var personalKeys = map[string]bool{
+	"username":   true,
+	"first_name": true,
+	"last_name":  true,
+	"phone":      true,
+}
+
+// Secrets found inside of messages and values
+var secretPatterns = []*regexp.Regexp{
+	// JWZ and JWT tokens start with a base64url encoded JSON header
+	regexp.MustCompile(`eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]*\.?[A-Za-z0-9_-]*`),
+	// Admin API keys
+	regexp.MustCompile(`tgab_[0-9a-f]{16,}`),
+	// Telegram bot tokens, also inside of the Bot API URLs
+	regexp.MustCompile(`\d{6,}:[A-Za-z0-9_-]{30,}`),
+	// Infura project keys in the RPC URLs
+	regexp.MustCompile(`(infura\.io/v3/)[0-9A-Za-z]+`),
+}
+
+// New creates the logger writing to w
+func New(w io.Writer, opts Options) *slog.Logger {
+	r := newRedactor(opts.RedactPersonal)
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level, ReplaceAttr: r.replaceAttr}
+
+	if opts.Format == FormatJSON {
+		return slog.New(slog.NewJSONHandler(w, handlerOpts))
+	}
+	return slog.New(slog.NewTextHandler(w, handlerOpts))
+}
+
+// ParseLevel parses debug, info, warn or error
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", value)
+	}
+	return level, nil
+}
+
+// redactor removes the secrets and the personal data from the records
+type redactor struct {
+	personal bool
+	// key of the user ID pseudonyms, they are stable until the restart and can't be reversed without it
+	pseudonymKey []byte
+}
+
+func newRedactor(personal bool) *redactor {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("logging: can't generate the pseudonym key: %v", err))
+	}
+	return &redactor{personal: personal, pseudonymKey: key}
+}
+
+func (r *redactor) replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	// The time and the level are kept as they are
+	if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+		return a
+	}
+
+	key := strings.ToLower(a.Key)
+
+	if secretKeys[key] {
+		return slog.String(a.Key, redacted)
+	}
+
+	if r.personal {
+		if personalKeys[key] {
+			return slog.String(a.Key, redacted)
+		}
+		if key == "user_id" {
+			return slog.String(a.Key, r.pseudonym(a.Value))
+		}
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Scrub(a.Value.String()))
+	case slog.KindAny:
+		// Errors and structs are rendered as text, so the secrets inside of them can be found
+		return slog.String(a.Key, Scrub(fmt.Sprintf("%+v", a.Value.Any())))
+	}
+	return a
+}
+
+// pseudonym replaces a user ID with a keyed hash, the records of a user can still be correlated
+func (r *redactor) pseudonym(value slog.Value) string {
+	var id string
+	switch value.Kind() {
+	case slog.KindInt64:
+		id = strconv.FormatInt(value.Int64(), 10)
+	default:
+		id = value.String()
+	}
+
+	mac := hmac.New(sha256.New, r.pseudonymKey)
+	mac.Write([]byte(id))
+	return "u_" + hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+// Scrub removes the tokens, keys and other secrets from the text
+func Scrub(text string) string {
+	for _, pattern := range secretPatterns {
+		if pattern.NumSubexp() > 0 {
+			text = pattern.ReplaceAllString(text, "${1}"+redacted)
+		} else {
+			text = pattern.ReplaceAllString(text, redacted)
+		}
+	}
+	return text
+}
+
+type contextKey struct{}
+
+// WithAttrs returns a context carrying the correlation IDs of a request, such as the request and session IDs
+func WithAttrs(ctx context.Context, args ...any) context.Context {
+	attrs, _ := ctx.Value(contextKey{}).([]any)
+	return context.WithValue(ctx, contextKey{}, append(attrs[:len(attrs):len(attrs)], args...))
+}
+
+// FromContext returns the logger with the correlation IDs of the context added
+func FromContext(ctx context.Context, logger *slog.Logger) *slog.Logger {
+	if attrs, ok := ctx.Value(contextKey{}).([]any); ok {
+		return logger.With(attrs...)
+	}
+	return logger
+}
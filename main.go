@@ -1,20 +1,25 @@
 package main
 
 import (
-	//"fmt"
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	//"strconv"
 	//"sync"
-	//"time"
-
-	//"gopkg.in/telebot.v3"
 
+	"github.com/ArtemHvozdov/tg-auth-bot/auth"
 	"github.com/ArtemHvozdov/tg-auth-bot/bot"
+	"github.com/ArtemHvozdov/tg-auth-bot/bot/handlers"
+	"github.com/ArtemHvozdov/tg-auth-bot/logging"
+	"github.com/ArtemHvozdov/tg-auth-bot/tracing"
 	"github.com/ArtemHvozdov/tg-auth-bot/web"
+	"github.com/ArtemHvozdov/tg-auth-bot/webhooks"
 
 	//"test-bot/auth"
 	//"test-bot/web"
@@ -24,43 +29,168 @@ import (
 
 func main() {
 
-	cfg := config.LoadConfig() // Loading the configuration from a file or environment variables
+	// Subcommands (backup, export, import) work on the database without starting the bot
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
+	// Loading the configuration from the config file and the environment variables
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// One logger for all packages, the standard log package writes to it as well.
+	// The level can be changed by a reload.
+	logLevel := &slog.LevelVar{}
+	logLevel.Set(cfg.Log.Level)
+	logger := logging.New(os.Stderr, logging.Options{
+		Level:          logLevel,
+		Format:         cfg.Log.Format,
+		RedactPersonal: cfg.Log.RedactPersonal,
+	})
+	slog.SetDefault(logger)
+	auth.SetLogger(logger)
+	storage_db.SetLogger(logger)
+	handlers.SetLogger(logger)
+	bot.SetLogger(logger)
+	web.SetLogger(logger)
+	webhooks.SetLogger(logger)
+
+	// The verifier, the defaults of the groups and the admins can be reloaded without a restart
+	configReloader := &reloader{logLevel: logLevel}
+	if err := configReloader.apply(cfg); err != nil {
+		fatal("Failed to apply configuration", "error", err)
+	}
+
+	// Spans of the verifications, the exporter flushes them on shutdown
+	stopTracing, err := tracing.Setup(context.Background(), cfg.TracingExporter)
+	if err != nil {
+		fatal("Failed to set up tracing", "error", err)
+	}
 
 	// Initialize the database
-	dataDir := "./data"
-	dbPath := dataDir + "/tg-bot.db"
+	dataDir := cfg.DataDir
+	dbPath := filepath.Join(dataDir, dbFileName)
 
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
-		log.Fatalf("Ошибка при создании папки %s: %v", dataDir, err)
+		fatal("Failed to create data directory", "dir", dataDir, "error", err)
 	}
 
-	err := storage_db.InitDB(dbPath)
+	err = storage_db.InitDB(dbPath)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		fatal("Failed to initialize database", "error", err)
 	}
-	defer storage_db.CloseDB() // Ensure the database is closed on shutdown
+
+	// Periodic snapshots of the database
+	stopBackups := storage_db.StartBackupScheduler(cfg.Backup.Dir, cfg.Backup.Interval, cfg.Backup.Retention)
+
+	// Delivery of the verification events to the outgoing webhooks of the groups
+	stopWebhooks := webhooks.Start()
 
 	// Create a channel to handle OS signals for graceful shutdown
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
-	// We launch the Telegram bot in a separate goroutine
-	go func() {
-		err := bot.StartBot(cfg)
+	// SIGHUP and changes of the config file reload it
+	stopReload := make(chan struct{})
+	configReloader.watch(stopReload)
+
+	// The bots register their routes on the web server, so they're created first.
+	// They share the verifier and the database, each one keeps its groups in its own namespace.
+	var bots []*bot.Instance
+	for _, botCfg := range cfg.BotList() {
+		store, err := storage_db.Open(botCfg.Name)
 		if err != nil {
-			log.Fatalf("Failed to start bot: %v", err)
+			fatal("Failed to open bot storage", "bot", botCfg.Name, "error", err)
 		}
-	}()
+
+		instance, err := bot.NewBot(cfg, botCfg, store)
+		if err != nil {
+			fatal("Failed to create bot", "bot", botCfg.Name, "error", err)
+		}
+		bots = append(bots, instance)
+	}
+
+	server := web.NewServer(cfg.HTTP)
+
+	// Errors of the bot or the server also trigger the shutdown
+	failed := make(chan error, 2)
+
+	// We launch the Telegram bots in separate goroutines
+	for _, instance := range bots {
+		go instance.Start()
+	}
 
 	go func ()  {
 		// Run webserver
-		web.StartServer()
+		if err := server.Run(); err != nil {
+			failed <- fmt.Errorf("web server: %w", err)
+		}
 	}()
 
 	// Wait for termination signal
-	<-stop
-	log.Println("Shutting down gracefully...")
+	select {
+	case sig := <-stop:
+		slog.Info("Shutting down gracefully", "signal", sig.String())
+	case err := <-failed:
+		slog.Error("Shutting down after error", "error", err)
+	}
+	close(stopReload)
+
+	shutdown(cfg.ShutdownTimeout, bots, server, stopBackups, stopWebhooks, stopTracing)
+}
+
+// shutdown stops the parts of the application in order: no new updates, no new callbacks,
+// flush the pending store events, outgoing webhooks and spans and finally close the database
+func shutdown(timeout time.Duration, bots []*bot.Instance, server *web.Server, stopBackups func(), stopWebhooks func(), stopTracing func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// 1. Stop receiving Telegram updates and cancel the verification timeouts, their members stay pending.
+	// The scheduled deletions of bot messages are dropped, they only call Telegram.
+	for _, instance := range bots {
+		instance.Stop()
+		if err := instance.StopTimeouts(ctx); err != nil {
+			slog.Warn("Verification timeouts were not finished", "bot", instance.Tenant.Name, "error", err)
+		}
+	}
+
+	// 2. Stop accepting requests and wait for in-flight callbacks
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("Web server shutdown error", "error", err)
+	}
+
+	// 3. No more snapshots while the database is closing
+	stopBackups()
+
+	// 4. Let the change listeners handle the events produced by the last callbacks
+	storage_db.CloseChanges()
+	for _, instance := range bots {
+		if err := instance.WaitForEvents(ctx); err != nil {
+			slog.Warn("Pending events were not flushed", "bot", instance.Tenant.Name, "error", err)
+		}
+	}
+
+	// 5. Stop the webhook deliveries, the ones that didn't finish are kept as dead letters
+	stopWebhooks()
+
+	// 6. Export the spans of the last verifications
+	if err := stopTracing(ctx); err != nil {
+		slog.Warn("Pending spans were not exported", "error", err)
+	}
+
+	// 7. Close the database
+	if err := storage_db.CloseDB(); err != nil {
+		slog.Error("Error closing database", "error", err)
+	}
+
+	slog.Info("Shutdown complete")
+}
 
-	// // Run webserver
-	// web.StartServer()
-}
\ No newline at end of file
+// fatal logs the error and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,119 @@
+// Package metrics holds the Prometheus metrics of the bot, they are served by the web package on /metrics
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Namespace of all metric names
+const namespace = "tgauth"
+
+// Reasons of the verification failures
+const (
+	ReasonProofFailed     = "proof_failed"
+	ReasonInternalError   = "internal_error"
+	ReasonSessionNotFound = "session_not_found"
+	ReasonSessionFinal    = "session_final"
+	ReasonBadRequest      = "bad_request"
+)
+
+var (
+	// Joins counts the new members that have to pass the verification
+	Joins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "member_joins_total",
+		Help:      "New members that have to pass the verification.",
+	}, []string{"group"})
+
+	// VerificationAttempts counts the started verifications (/verify)
+	VerificationAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "verification_attempts_total",
+		Help:      "Verification requests sent to members.",
+	}, []string{"group"})
+
+	// VerificationSuccesses counts the accepted proofs
+	VerificationSuccesses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "verification_successes_total",
+		Help:      "Proofs that passed the verification.",
+	}, []string{"group"})
+
+	// VerificationFailures counts the rejected callbacks by reason
+	VerificationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "verification_failures_total",
+		Help:      "Callbacks that didn't pass the verification, by reason.",
+	}, []string{"group", "reason"})
+
+	// VerificationTimeouts counts the members removed because they didn't verify in time
+	VerificationTimeouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "verification_timeouts_total",
+		Help:      "Members removed because they didn't pass the verification in time.",
+	}, []string{"group"})
+
+	// CallbackDuration measures the whole handling of the wallet callback
+	CallbackDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "callback_duration_seconds",
+		Help:      "Duration of the wallet callback handling, by result.",
+		Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 20, 30, 60},
+	}, []string{"result"})
+
+	// FullVerifyDuration measures the proof verification
+	FullVerifyDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "full_verify_duration_seconds",
+		Help:      "Duration of the FullVerify proof verification, by result.",
+		Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 20, 30, 60},
+	}, []string{"result"})
+
+	// ResolverErrors counts the failed state resolver RPC calls
+	ResolverErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "resolver_errors_total",
+		Help:      "Failed state resolver calls, by resolver.",
+	}, []string{"resolver"})
+
+	// TelegramErrors counts the failed Telegram Bot API calls
+	TelegramErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "telegram_api_errors_total",
+		Help:      "Failed Telegram Bot API calls, by method.",
+	}, []string{"method"})
+)
+
+// Group returns the group label value
+func Group(groupID int64) string {
+	return strconv.FormatInt(groupID, 10)
+}
+
+// TelegramTransport counts the failed Telegram Bot API calls passing through it
+type TelegramTransport struct {
+	Base http.RoundTripper
+}
+
+func (t TelegramTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	// The method is the last part of the path: /bot<token>/<method>, file downloads would add a label per file
+	method := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+	if strings.HasPrefix(req.URL.Path, "/file/") {
+		method = "file"
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode >= 400 {
+		TelegramErrors.WithLabelValues(method).Inc()
+	}
+	return resp, err
+}
@@ -0,0 +1,275 @@
+package presets
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+
+	circuits "github.com/iden3/go-circuits/v2"
+)
+
+// Schemas used by the presets
+const (
+	kycContext        = "https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v4.jsonld"
+	uniquenessContext = "https://raw.githubusercontent.com/anima-protocol/claims-polygonid/main/schemas/json-ld/pou-v1.json-ld"
+	uniquenessType    = "AnimaProofOfUniqueness"
+)
+
+// SkipAnswer is the answer that accepts the default value of a prompt
+const SkipAnswer = "-"
+
+// Prompt is a question the admin answers to parametrize a preset
+type Prompt struct {
+	Question     string // i18n key of the question
+	QuestionArgs []any
+	Default      string // Used when the admin answers SkipAnswer, empty means the answer is required
+	Validate     func(answer string) error
+}
+
+// Preset is a ready-made verification that produces normal VerificationParams.
+// Its texts are in the i18n catalogs under presets.<key>.
+type Preset struct {
+	Key     string
+	Prompts []Prompt
+	Build   func(lang string, answers []string) (storage_db.VerificationParams, error)
+}
+
+// AnswerError rejects an answer to a prompt, the message is looked up in the language of the admin
+type AnswerError struct {
+	Key  string // i18n key of the message
+	Args []any
+}
+
+func (e *AnswerError) Error() string {
+	return e.Text(i18n.Default)
+}
+
+// Text returns the message in the language
+func (e *AnswerError) Text(lang string) string {
+	return i18n.T(lang, e.Key, e.Args...)
+}
+
+// answerError returns an AnswerError with the message of the key
+func answerError(key string, args ...any) error {
+	return &AnswerError{Key: key, Args: args}
+}
+
+// catalogue is the list of built-in presets in the order they are shown to admins
+var catalogue = []Preset{
+	{
+		Key: "age",
+		Prompts: []Prompt{
+			{Question: "presets.age.question_age", Validate: validateAge},
+			allowedIssuersPrompt,
+		},
+		Build: buildAge,
+	},
+	{
+		Key: "country",
+		Prompts: []Prompt{
+			{Question: "presets.country.question_codes", Validate: validateCountryCodes},
+			allowedIssuersPrompt,
+		},
+		Build: buildCountry,
+	},
+	{
+		Key: "uniqueness",
+		Prompts: []Prompt{
+			{Question: "presets.uniqueness.question_issuer", Default: "*", Validate: validateIssuers},
+			{Question: "presets.uniqueness.question_context", QuestionArgs: []any{uniquenessContext}, Default: uniquenessContext, Validate: validateURL},
+			{Question: "presets.uniqueness.question_type", QuestionArgs: []any{uniquenessType}, Default: uniquenessType, Validate: validateCredentialType},
+		},
+		Build: buildUniqueness,
+	},
+	{
+		Key: "membership",
+		Prompts: []Prompt{
+			{Question: "presets.membership.question_context", Validate: validateURL},
+			{Question: "presets.membership.question_type", Validate: validateCredentialType},
+			{Question: "presets.membership.question_issuer", Default: "*", Validate: validateIssuers},
+		},
+		Build: buildMembership,
+	},
+}
+
+// allowedIssuersPrompt asks for the trusted issuers of the credential
+var allowedIssuersPrompt = Prompt{
+	Question: "presets.question_issuers",
+	Default:  "*",
+	Validate: validateIssuers,
+}
+
+// All returns the built-in presets
+func All() []Preset {
+	return catalogue
+}
+
+// Get returns the preset with the given key
+func Get(key string) (Preset, bool) {
+	for _, preset := range catalogue {
+		if preset.Key == key {
+			return preset, true
+		}
+	}
+	return Preset{}, false
+}
+
+// Title returns the name of the preset on its button
+func (p Preset) Title(lang string) string {
+	return i18n.T(lang, "presets."+p.Key+".title")
+}
+
+// Description tells the admin what members prove with the preset
+func (p Preset) Description(lang string) string {
+	return i18n.T(lang, "presets."+p.Key+".description")
+}
+
+// Text returns the question in the language
+func (p Prompt) Text(lang string) string {
+	return i18n.T(lang, p.Question, p.QuestionArgs...)
+}
+
+// Answer resolves the admin's answer to a prompt, applying the default and validation
+func (p Prompt) Answer(text string) (string, error) {
+	answer := strings.TrimSpace(text)
+	if answer == SkipAnswer {
+		if p.Default == "" {
+			return "", answerError("presets.error_required")
+		}
+		answer = p.Default
+	}
+
+	if answer == "" {
+		return "", answerError("presets.error_empty")
+	}
+
+	if p.Validate != nil {
+		if err := p.Validate(answer); err != nil {
+			return "", err
+		}
+	}
+	return answer, nil
+}
+
+// newParams returns params for the signature circuit with the common query fields
+func newParams(context, credentialType, issuers string) storage_db.VerificationParams {
+	return storage_db.VerificationParams{
+		CircuitID: string(circuits.AtomicQuerySigV2CircuitID),
+		ID:        1,
+		Query: map[string]interface{}{
+			"allowedIssuers": splitList(issuers),
+			"context":        context,
+			"type":           credentialType,
+		},
+	}
+}
+
+func buildAge(lang string, answers []string) (storage_db.VerificationParams, error) {
+	age, err := strconv.Atoi(answers[0])
+	if err != nil {
+		return storage_db.VerificationParams{}, err
+	}
+
+	// The birthday limit is computed from the age for each auth request, so it moves forward with the date
+	params := newParams(kycContext, "KYCAgeCredential", answers[1])
+	params.MinAge = age
+	params.Name = i18n.T(lang, "presets.age.name", age)
+	params.Description = i18n.T(lang, "presets.age.params_description", age)
+	return params, nil
+}
+
+func buildCountry(lang string, answers []string) (storage_db.VerificationParams, error) {
+	var codes []int
+	for _, code := range splitList(answers[0]) {
+		value, err := strconv.Atoi(code)
+		if err != nil {
+			return storage_db.VerificationParams{}, err
+		}
+		codes = append(codes, value)
+	}
+
+	params := newParams(kycContext, "KYCCountryOfResidenceCredential", answers[1])
+	params.Query["credentialSubject"] = map[string]interface{}{
+		"countryCode": map[string]interface{}{
+			"$in": codes,
+		},
+	}
+	params.Name = i18n.T(lang, "presets.country.name")
+	params.Description = i18n.T(lang, "presets.country.params_description")
+	return params, nil
+}
+
+func buildUniqueness(lang string, answers []string) (storage_db.VerificationParams, error) {
+	params := newParams(answers[1], answers[2], answers[0])
+	params.Name = i18n.T(lang, "presets.uniqueness.name")
+	params.Description = i18n.T(lang, "presets.uniqueness.params_description")
+	return params, nil
+}
+
+func buildMembership(lang string, answers []string) (storage_db.VerificationParams, error) {
+	params := newParams(answers[0], answers[1], answers[2])
+	params.Name = i18n.T(lang, "presets.membership.name", answers[1])
+	params.Description = i18n.T(lang, "presets.membership.params_description", answers[1])
+	return params, nil
+}
+
+// splitList splits a comma separated answer into trimmed non-empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func validateAge(answer string) error {
+	age, err := strconv.Atoi(answer)
+	if err != nil || age < 1 || age > 120 {
+		return answerError("presets.error_age", 1, 120)
+	}
+	return nil
+}
+
+func validateCountryCodes(answer string) error {
+	codes := splitList(answer)
+	if len(codes) == 0 {
+		return answerError("presets.error_no_country")
+	}
+
+	for _, code := range codes {
+		value, err := strconv.Atoi(code)
+		if err != nil || value < 1 || value > 999 {
+			return answerError("presets.error_country_code", code)
+		}
+	}
+	return nil
+}
+
+func validateIssuers(answer string) error {
+	for _, issuer := range splitList(answer) {
+		if issuer != "*" && !strings.HasPrefix(issuer, "did:") {
+			return answerError("presets.error_not_did", issuer)
+		}
+	}
+	return nil
+}
+
+func validateURL(answer string) error {
+	parsed, err := url.Parse(answer)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http" && parsed.Scheme != "ipfs") {
+		return answerError("presets.error_url", answer)
+	}
+	return nil
+}
+
+func validateCredentialType(answer string) error {
+	if strings.ContainsAny(answer, " \t\n,") {
+		return answerError("presets.error_credential_type")
+	}
+	return nil
+}
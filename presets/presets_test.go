@@ -0,0 +1,136 @@
+package presets
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+)
+
+func TestPromptAnswer(t *testing.T) {
+	optional := Prompt{Question: "?", Default: "*", Validate: validateIssuers}
+	required := Prompt{Question: "?", Validate: validateAge}
+
+	tests := []struct {
+		name    string
+		prompt  Prompt
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{"trimmed answer", required, "  18 \n", "18", false},
+		{"skip uses the default", optional, SkipAnswer, "*", false},
+		{"skip of a required value", required, SkipAnswer, "", true},
+		{"empty answer", optional, "   ", "", true},
+		{"invalid answer", required, "eighteen", "", true},
+		{"valid list", optional, "did:a, did:b", "did:a, did:b", false},
+		{"invalid item of a list", optional, "did:a, issuer", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.prompt.Answer(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Answer(%q) error = %v, want error %v", tt.text, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Answer(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPresetsBuild(t *testing.T) {
+	tests := []struct {
+		key      string
+		answers  []string
+		wantType string
+	}{
+		{"age", []string{"18", "*"}, "KYCAgeCredential"},
+		{"country", []string{"840, 804", "did:polygonid:issuer"}, "KYCCountryOfResidenceCredential"},
+		{"uniqueness", []string{"*", uniquenessContext, uniquenessType}, uniquenessType},
+		{"membership", []string{"ipfs://schema", "EventAttendance", "did:polygonid:issuer"}, "EventAttendance"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			preset, ok := Get(tt.key)
+			if !ok {
+				t.Fatalf("Get(%q) found nothing", tt.key)
+			}
+			if len(tt.answers) != len(preset.Prompts) {
+				t.Fatalf("%d answers for %d prompts", len(tt.answers), len(preset.Prompts))
+			}
+
+			// The answers go through the prompts like the ones of an admin
+			for i, text := range tt.answers {
+				answer, err := preset.Prompts[i].Answer(text)
+				if err != nil {
+					t.Fatalf("prompt %d rejected %q: %v", i, text, err)
+				}
+				tt.answers[i] = answer
+			}
+
+			params, err := preset.Build(i18n.Default, tt.answers)
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			if params.Query["type"] != tt.wantType {
+				t.Errorf("type = %v, want %s", params.Query["type"], tt.wantType)
+			}
+			if issuers, ok := params.Query["allowedIssuers"].([]string); !ok || len(issuers) == 0 {
+				t.Errorf("allowedIssuers = %v, want a non-empty list", params.Query["allowedIssuers"])
+			}
+			if params.Name == "" || params.Description == "" {
+				t.Errorf("name = %q, description = %q, want both", params.Name, params.Description)
+			}
+		})
+	}
+
+	if _, ok := Get("unknown"); ok {
+		t.Error("Get(\"unknown\") found a preset")
+	}
+}
+
+func TestBuildAgeMinAge(t *testing.T) {
+	params, err := buildAge(i18n.Default, []string{"18", "*"})
+	if err != nil {
+		t.Fatalf("buildAge: %v", err)
+	}
+
+	// The params keep the age, a birthday limit in the query would stop moving with the date
+	if params.MinAge != 18 {
+		t.Errorf("MinAge = %d, want 18", params.MinAge)
+	}
+	if subject, ok := params.Query["credentialSubject"]; ok {
+		t.Errorf("credentialSubject = %v, want none", subject)
+	}
+}
+
+func TestPromptDefaultsAreValid(t *testing.T) {
+	for _, preset := range All() {
+		for i, prompt := range preset.Prompts {
+			if prompt.Default == "" {
+				continue
+			}
+			if _, err := prompt.Answer(SkipAnswer); err != nil {
+				t.Errorf("%s prompt %d: default %q is rejected: %v", preset.Key, i, prompt.Default, err)
+			}
+		}
+	}
+}
+
+func TestAnswerErrorText(t *testing.T) {
+	_, err := Prompt{Validate: validateAge}.Answer("200")
+
+	var answerErr *AnswerError
+	if !errors.As(err, &answerErr) {
+		t.Fatalf("Answer error = %v, want an AnswerError", err)
+	}
+	if got, want := answerErr.Text("uk"), i18n.T("uk", "presets.error_age", 1, 120); got != want {
+		t.Errorf("Text(\"uk\") = %q, want %q", got, want)
+	}
+	if got, want := err.Error(), i18n.T(i18n.Default, "presets.error_age", 1, 120); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
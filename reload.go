@@ -0,0 +1,87 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/auth"
+	"github.com/ArtemHvozdov/tg-auth-bot/bot/handlers"
+	"github.com/ArtemHvozdov/tg-auth-bot/config"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+)
+
+// reloader applies the config to the packages and reloads it on SIGHUP or when the config file changes.
+// Verification sessions are kept, callbacks in flight finish with the verifier they started with.
+type reloader struct {
+	mu       sync.Mutex
+	cfg      config.Config
+	logLevel *slog.LevelVar
+}
+
+// apply sets up the packages with the reloadable settings of the config
+func (r *reloader) apply(cfg config.Config) error {
+	if err := auth.Configure(cfg); err != nil {
+		return err
+	}
+	handlers.SetBotAdmins(cfg.Admins)
+	handlers.SetInviteLinkTTL(cfg.Verification.InviteLinkTTL)
+	storage_db.SetDefaults(cfg.Verification.Timeout, cfg.Verification.DefaultRestriction)
+	r.logLevel.Set(cfg.Log.Level)
+
+	r.cfg = cfg
+	return nil
+}
+
+// reload loads the config again, an invalid config keeps the current one
+func (r *reloader) reload(trigger string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	log := slog.With("trigger", trigger)
+
+	next, err := config.Load("")
+	if err != nil {
+		log.Error("Configuration not reloaded", "error", err)
+		return
+	}
+
+	reloaded, restart := r.cfg.Reload(next)
+	if len(restart) > 0 {
+		log.Warn("Changed settings need a restart", "settings", restart)
+	}
+	if reloaded.Equal(r.cfg) {
+		log.Info("Configuration unchanged")
+		return
+	}
+
+	if err := r.apply(reloaded); err != nil {
+		log.Error("Configuration not reloaded", "error", err)
+		return
+	}
+	log.Info("Configuration reloaded", "resolvers", len(reloaded.Verifier.Resolvers), "log_level", reloaded.Log.Level.String())
+}
+
+// watch reloads the config on SIGHUP and on changes of the config file until stop is closed
+func (r *reloader) watch(stop <-chan struct{}) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	if err := config.Watch(config.FilePath(), stop, func() { r.reload("file") }); err != nil {
+		slog.Warn("Config file is not watched, send SIGHUP to reload it", "error", err)
+	}
+
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-hup:
+				r.reload("SIGHUP")
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
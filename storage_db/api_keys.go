@@ -0,0 +1,155 @@
+package storage_db
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// apiKeyPrefix makes the keys easy to recognize, e.g. by secret scanners
+const apiKeyPrefix = "tgab_"
+
+// APIKey is the admin REST API key of a group admin, only the hash of the key is stored
+type APIKey struct {
+	UserID    int64     `json:"userId"`
+	KeyHash   string    `json:"keyHash"`
+	GroupIDs  []int64   `json:"groupIds"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// HasGroup reports whether the key grants access to the group
+func (k APIKey) HasGroup(groupID int64) bool {
+	return slices.Contains(k.GroupIDs, groupID)
+}
+
+// hashAPIKey returns the hex SHA-256 of the key
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAPIKey generates a new API key, it works once SaveAPIKey stored it
+func NewAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating API key: %w", err)
+	}
+	return apiKeyPrefix + hex.EncodeToString(b), nil
+}
+
+// SaveAPIKey makes the key the API key of the admin and grants it access to the group.
+// The previous key of the admin stops working, the groups it granted are kept.
+func (s *Store) SaveAPIKey(userID int64, groupID int64, key string) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "AdminAPIKeys")
+		if bucket == nil {
+			return fmt.Errorf("bucket AdminAPIKeys not found")
+		}
+
+		var apiKey APIKey
+		if data := bucket.Get(itob(userID)); data != nil {
+			if err := json.Unmarshal(data, &apiKey); err != nil {
+				return fmt.Errorf("error parsing JSON: %w", err)
+			}
+		}
+
+		apiKey.UserID = userID
+		apiKey.KeyHash = hashAPIKey(key)
+		apiKey.CreatedAt = time.Now()
+		if !apiKey.HasGroup(groupID) {
+			apiKey.GroupIDs = append(apiKey.GroupIDs, groupID)
+		}
+
+		encoded, err := json.Marshal(apiKey)
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+
+		return bucket.Put(itob(userID), encoded)
+	})
+}
+
+// RevokeAPIKey deletes the API key of the admin
+func (s *Store) RevokeAPIKey(userID int64) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "AdminAPIKeys")
+		if bucket == nil {
+			return fmt.Errorf("bucket AdminAPIKeys not found")
+		}
+
+		if bucket.Get(itob(userID)) == nil {
+			return fmt.Errorf("API key of user %d %w", userID, ErrNotFound)
+		}
+
+		return bucket.Delete(itob(userID))
+	})
+}
+
+// RemoveAPIKeyGroup takes the access to the group away from the API key of the admin
+func (s *Store) RemoveAPIKeyGroup(userID int64, groupID int64) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "AdminAPIKeys")
+		if bucket == nil {
+			return fmt.Errorf("bucket AdminAPIKeys not found")
+		}
+
+		data := bucket.Get(itob(userID))
+		if data == nil {
+			return nil
+		}
+
+		var apiKey APIKey
+		if err := json.Unmarshal(data, &apiKey); err != nil {
+			return fmt.Errorf("error parsing JSON: %w", err)
+		}
+
+		apiKey.GroupIDs = slices.DeleteFunc(apiKey.GroupIDs, func(id int64) bool { return id == groupID })
+
+		encoded, err := json.Marshal(apiKey)
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+
+		return bucket.Put(itob(userID), encoded)
+	})
+}
+
+// FindAPIKey returns the API key record matching the key
+func (s *Store) FindAPIKey(key string) (APIKey, error) {
+	var found APIKey
+	hash := []byte(hashAPIKey(key))
+
+	err := db.View(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "AdminAPIKeys")
+		if bucket == nil {
+			return fmt.Errorf("bucket AdminAPIKeys not found")
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var apiKey APIKey
+			if err := json.Unmarshal(v, &apiKey); err != nil {
+				return fmt.Errorf("error parsing JSON: %w", err)
+			}
+
+			if subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), hash) == 1 {
+				found = apiKey
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	if found.UserID == 0 {
+		return APIKey{}, fmt.Errorf("API key %w", ErrNotFound)
+	}
+	return found, nil
+}
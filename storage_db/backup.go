@@ -0,0 +1,165 @@
+package storage_db
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	backupPrefix     = "tg-bot-"
+	backupSuffix     = ".db"
+	backupTimeFormat = "20060102-150405"
+)
+
+// BackupTo writes a consistent snapshot of the database to w.
+// The snapshot is taken inside a read transaction, so the bot keeps working while it runs.
+func BackupTo(w io.Writer) (int64, error) {
+	if db == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+
+	var written int64
+	err := db.View(func(tx *bolt.Tx) error {
+		n, err := tx.WriteTo(w)
+		written = n
+		return err
+	})
+
+	return written, err
+}
+
+// CreateBackup writes a timestamped snapshot file to dir and returns its path
+func CreateBackup(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("error creating backup dir %s: %w", dir, err)
+	}
+
+	name := backupPrefix + time.Now().UTC().Format(backupTimeFormat) + backupSuffix
+	path := filepath.Join(dir, name)
+
+	// Write to a temporary file first so a crash never leaves a truncated snapshot behind
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("error creating backup file: %w", err)
+	}
+
+	if _, err := BackupTo(file); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("error writing backup: %w", err)
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("error syncing backup: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("error closing backup: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("error renaming backup: %w", err)
+	}
+
+	return path, nil
+}
+
+// ListBackups returns the snapshot files in dir, oldest first
+func ListBackups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, name))
+	}
+
+	// The timestamp format sorts lexicographically
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// PruneBackups removes the oldest snapshots in dir, keeping at most retention files
+func PruneBackups(dir string, retention int) error {
+	if retention <= 0 {
+		return nil
+	}
+
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return err
+	}
+
+	for len(backups) > retention {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("error removing old backup %s: %w", backups[0], err)
+		}
+		logger.Info("Removed old backup", "path", backups[0])
+		backups = backups[1:]
+	}
+
+	return nil
+}
+
+// StartBackupScheduler writes a snapshot to dir every interval and keeps the last retention files.
+// It returns a function that stops the scheduler.
+func StartBackupScheduler(dir string, interval time.Duration, retention int) func() {
+	stop := make(chan struct{})
+
+	if interval <= 0 {
+		logger.Info("Scheduled backups are disabled")
+		return func() {}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				path, err := CreateBackup(dir)
+				if err != nil {
+					logger.Error("Scheduled backup failed", "error", err)
+					continue
+				}
+				logger.Info("Backup snapshot written", "path", path)
+
+				if err := PruneBackups(dir, retention); err != nil {
+					logger.Error("Error pruning backups", "error", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	logger.Info("Scheduled backups", "interval", interval, "dir", dir, "retention", retention)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stop) })
+	}
+}
@@ -0,0 +1,201 @@
+package storage_db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Struct for the exported state of one group
+type GroupExport struct {
+	GroupID       int64                   `json:"groupId"`
+	ExportedAt    time.Time               `json:"exportedAt"`
+	Config        GroupVerificationConfig `json:"config"`
+	VerifiedUsers []VerifiedUser          `json:"verifiedUsers"`
+}
+
+// ExportGroup collects the group's verification config and verified users in one read transaction
+func (s *Store) ExportGroup(groupID int64) (GroupExport, error) {
+	export := GroupExport{
+		GroupID:       groupID,
+		ExportedAt:    time.Now().UTC(),
+		VerifiedUsers: []VerifiedUser{},
+	}
+
+	var hasConfig bool
+
+	err := db.View(func(tx *bolt.Tx) error {
+		paramsBucket := s.bucket(tx, "VerificationParamsStore")
+		if paramsBucket == nil {
+			return fmt.Errorf("bucket VerificationParamsStore not found")
+		}
+
+		if data := paramsBucket.Get(itob(groupID)); data != nil {
+			if err := json.Unmarshal(data, &export.Config); err != nil {
+				return fmt.Errorf("error parsing group config: %w", err)
+			}
+			hasConfig = true
+		}
+
+		usersBucket := s.bucket(tx, "VerifiedUsersList")
+		if usersBucket == nil {
+			return fmt.Errorf("bucket VerifiedUsersList not found")
+		}
+
+		groupBucket := usersBucket.Bucket(itob(groupID))
+		if groupBucket == nil {
+			return nil
+		}
+
+		return groupBucket.ForEach(func(k, v []byte) error {
+			if v == nil {
+				return nil
+			}
+
+			var user VerifiedUser
+			if err := json.Unmarshal(v, &user); err != nil {
+				return fmt.Errorf("error decoding verified user %d: %w", btoi(k), err)
+			}
+
+			export.VerifiedUsers = append(export.VerifiedUsers, user)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return GroupExport{}, err
+	}
+
+	if !hasConfig && len(export.VerifiedUsers) == 0 {
+		return GroupExport{}, fmt.Errorf("group ID %v not found", groupID)
+	}
+
+	return export, nil
+}
+
+// ImportGroup writes an exported group into the database, replacing its config and verified users.
+// If targetGroupID is 0 the group ID from the export is used.
+func (s *Store) ImportGroup(export GroupExport, targetGroupID int64) error {
+	groupID := targetGroupID
+	if groupID == 0 {
+		groupID = export.GroupID
+	}
+	if groupID == 0 {
+		return fmt.Errorf("group ID is not set")
+	}
+
+	if err := ValidateGroupConfig(export.Config); err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		paramsBucket := s.bucket(tx, "VerificationParamsStore")
+		if paramsBucket == nil {
+			return fmt.Errorf("bucket VerificationParamsStore not found")
+		}
+
+		encoded, err := json.Marshal(export.Config)
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+
+		if err := paramsBucket.Put(itob(groupID), encoded); err != nil {
+			return err
+		}
+
+		usersBucket := s.bucket(tx, "VerifiedUsersList")
+		if usersBucket == nil {
+			return fmt.Errorf("bucket VerifiedUsersList not found")
+		}
+
+		// Replace the existing list of verified users
+		if usersBucket.Bucket(itob(groupID)) != nil {
+			if err := usersBucket.DeleteBucket(itob(groupID)); err != nil {
+				return err
+			}
+		}
+
+		if len(export.VerifiedUsers) == 0 {
+			return nil
+		}
+
+		groupBucket, err := usersBucket.CreateBucket(itob(groupID))
+		if err != nil {
+			return err
+		}
+
+		for _, user := range export.VerifiedUsers {
+			data, err := json.Marshal(user)
+			if err != nil {
+				return err
+			}
+
+			if err := groupBucket.Put(itob(user.User.ID), data); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ValidateGroupConfig checks that an imported config is consistent
+func ValidateGroupConfig(config GroupVerificationConfig) error {
+	for i, params := range config.VerificationParams {
+		if err := ValidateVerificationParams(params); err != nil {
+			return fmt.Errorf("verification params #%d: %w", i+1, err)
+		}
+	}
+
+	if len(config.VerificationParams) == 0 {
+		if config.ActiveIndex != -1 && config.ActiveIndex != 0 {
+			return fmt.Errorf("active index %d is out of range", config.ActiveIndex)
+		}
+	} else if config.ActiveIndex < 0 || config.ActiveIndex >= len(config.VerificationParams) {
+		return fmt.Errorf("active index %d is out of range", config.ActiveIndex)
+	}
+
+	if config.RestrictionType != "" && !IsValidRestrictionType(config.RestrictionType) {
+		return fmt.Errorf("unknown restriction type %q", config.RestrictionType)
+	}
+
+	if config.VerificationTimeout < 0 {
+		return fmt.Errorf("verification timeout must not be negative")
+	}
+
+	if config.Language != "" && !i18n.IsSupported(config.Language) {
+		return fmt.Errorf("unsupported language %q, use one of %s", config.Language, strings.Join(i18n.Languages(), ", "))
+	}
+
+	for kind, text := range config.Templates {
+		if !IsTemplateKind(kind) {
+			return fmt.Errorf("unknown message %q, use one of %s", kind, strings.Join(TemplateKinds, ", "))
+		}
+		if err := ValidateMessageTemplate(text); err != nil {
+			return fmt.Errorf("%s message: %w", kind, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateVerificationParams checks that the params have the fields the verifier needs
+func ValidateVerificationParams(params VerificationParams) error {
+	if params.CircuitID == "" || params.ID == 0 || params.Query == nil {
+		return fmt.Errorf("'circuitId', 'id' and 'query' are required")
+	}
+	if params.MinAge < 0 {
+		return fmt.Errorf("'minAge' must not be negative")
+	}
+	return nil
+}
+
+// IsValidRestrictionType reports whether the bot knows how to apply the restriction type
+func IsValidRestrictionType(restrictionType string) bool {
+	return restrictionType == "block" || restrictionType == "delete"
+}
@@ -0,0 +1,101 @@
+package storage_db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Reasons of the verification failures
+const (
+	FailureProof   = "proof_failed"
+	FailureTimeout = "timeout"
+)
+
+// Number of failures kept for each group
+const failuresPerGroup = 50
+
+// VerificationFailure is a member who didn't pass the verification and was removed from the group
+type VerificationFailure struct {
+	GroupID  int64     `json:"groupId"`
+	UserID   int64     `json:"userId"`
+	Username string    `json:"username"`
+	Reason   string    `json:"reason"`
+	FailedAt time.Time `json:"failedAt"`
+}
+
+// AddVerificationFailure records a failure, only the latest failures of each group are kept
+func (s *Store) AddVerificationFailure(failure VerificationFailure) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "VerificationFailures")
+		if bucket == nil {
+			return fmt.Errorf("bucket VerificationFailures not found")
+		}
+
+		groupBucket, err := bucket.CreateBucketIfNotExists(itob(failure.GroupID))
+		if err != nil {
+			return err
+		}
+
+		id, err := groupBucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		encoded, err := json.Marshal(failure)
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+
+		if err := groupBucket.Put(itob(int64(id)), encoded); err != nil {
+			return err
+		}
+
+		// Keys grow with the sequence, so the oldest failures come first
+		var keys [][]byte
+		cursor := groupBucket.Cursor()
+		for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+
+		for i := 0; i < len(keys)-failuresPerGroup; i++ {
+			if err := groupBucket.Delete(keys[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetRecentFailures returns the failures of the group, newest first
+func (s *Store) GetRecentFailures(groupID int64) ([]VerificationFailure, error) {
+	failures := []VerificationFailure{}
+
+	err := db.View(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "VerificationFailures")
+		if bucket == nil {
+			return fmt.Errorf("bucket VerificationFailures not found")
+		}
+
+		groupBucket := bucket.Bucket(itob(groupID))
+		if groupBucket == nil {
+			return nil
+		}
+
+		cursor := groupBucket.Cursor()
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+			var failure VerificationFailure
+			if err := json.Unmarshal(v, &failure); err != nil {
+				return fmt.Errorf("error parsing JSON: %w", err)
+			}
+			failures = append(failures, failure)
+		}
+
+		return nil
+	})
+
+	return failures, err
+}
@@ -0,0 +1,230 @@
+package storage_db
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// InvitePolicy lets users who pass the verification of the group get an invite link to it.
+// The token is part of the deep link of the bot, so the ID of the group isn't exposed.
+type InvitePolicy struct {
+	Token     string    `json:"token"`
+	GroupID   int64     `json:"groupId"`
+	CreatedBy int64     `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// InviteLink is a single-use invite link the bot created for a verified user
+type InviteLink struct {
+	GroupID   int64     `json:"groupId"`
+	UserID    int64     `json:"userId"`
+	Username  string    `json:"username"`
+	Link      string    `json:"link"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	UsedAt    time.Time `json:"usedAt"` // zero until the user joins the group
+}
+
+// Usable reports whether the link can still be used to join the group
+func (l InviteLink) Usable(now time.Time) bool {
+	return l.UsedAt.IsZero() && now.Before(l.ExpiresAt)
+}
+
+// CreateInvitePolicy returns the invite token of the group, it's created if the group has none
+func (s *Store) CreateInvitePolicy(groupID int64, createdBy int64) (InvitePolicy, error) {
+	if policy, err := s.GetGroupInvitePolicy(groupID); err == nil {
+		return policy, nil
+	}
+
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return InvitePolicy{}, fmt.Errorf("error generating invite token: %w", err)
+	}
+	policy := InvitePolicy{
+		Token:     hex.EncodeToString(b),
+		GroupID:   groupID,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "InvitePolicies")
+		if bucket == nil {
+			return fmt.Errorf("bucket InvitePolicies not found")
+		}
+
+		encoded, err := json.Marshal(policy)
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+
+		return bucket.Put([]byte(policy.Token), encoded)
+	})
+	if err != nil {
+		return InvitePolicy{}, err
+	}
+
+	return policy, nil
+}
+
+// GetInvitePolicy returns the invite policy of the token
+func (s *Store) GetInvitePolicy(token string) (InvitePolicy, error) {
+	var policy InvitePolicy
+
+	err := db.View(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "InvitePolicies")
+		if bucket == nil {
+			return fmt.Errorf("bucket InvitePolicies not found")
+		}
+
+		data := bucket.Get([]byte(token))
+		if data == nil {
+			return fmt.Errorf("invite token %w", ErrNotFound)
+		}
+
+		return json.Unmarshal(data, &policy)
+	})
+
+	return policy, err
+}
+
+// GetGroupInvitePolicy returns the invite policy of the group
+func (s *Store) GetGroupInvitePolicy(groupID int64) (InvitePolicy, error) {
+	var found InvitePolicy
+
+	err := db.View(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "InvitePolicies")
+		if bucket == nil {
+			return fmt.Errorf("bucket InvitePolicies not found")
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var policy InvitePolicy
+			if err := json.Unmarshal(v, &policy); err != nil {
+				return fmt.Errorf("error parsing JSON: %w", err)
+			}
+
+			if policy.GroupID == groupID {
+				found = policy
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return InvitePolicy{}, err
+	}
+
+	if found.Token == "" {
+		return InvitePolicy{}, fmt.Errorf("invite policy of group %d %w", groupID, ErrNotFound)
+	}
+	return found, nil
+}
+
+// RevokeInvitePolicy deletes the invite token of the group, the links already issued keep working until they expire
+func (s *Store) RevokeInvitePolicy(groupID int64) error {
+	policy, err := s.GetGroupInvitePolicy(groupID)
+	if err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "InvitePolicies")
+		if bucket == nil {
+			return fmt.Errorf("bucket InvitePolicies not found")
+		}
+
+		return bucket.Delete([]byte(policy.Token))
+	})
+}
+
+// SaveInviteLink stores the invite link of the user, it replaces their previous link to the group
+func (s *Store) SaveInviteLink(link InviteLink) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "InviteLinks")
+		if bucket == nil {
+			return fmt.Errorf("bucket InviteLinks not found")
+		}
+
+		groupBucket, err := bucket.CreateBucketIfNotExists(itob(link.GroupID))
+		if err != nil {
+			return err
+		}
+
+		encoded, err := json.Marshal(link)
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+
+		return groupBucket.Put(itob(link.UserID), encoded)
+	})
+}
+
+// GetInviteLink returns the latest invite link of the user to the group
+func (s *Store) GetInviteLink(groupID, userID int64) (InviteLink, error) {
+	var link InviteLink
+
+	err := db.View(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "InviteLinks")
+		if bucket == nil {
+			return fmt.Errorf("bucket InviteLinks not found")
+		}
+
+		groupBucket := bucket.Bucket(itob(groupID))
+		if groupBucket == nil {
+			return fmt.Errorf("invite link of user %d %w", userID, ErrNotFound)
+		}
+
+		data := groupBucket.Get(itob(userID))
+		if data == nil {
+			return fmt.Errorf("invite link of user %d %w", userID, ErrNotFound)
+		}
+
+		return json.Unmarshal(data, &link)
+	})
+
+	return link, err
+}
+
+// MarkInviteLinkUsed records that the user joined the group with their invite link
+func (s *Store) MarkInviteLinkUsed(groupID, userID int64) error {
+	link, err := s.GetInviteLink(groupID, userID)
+	if err != nil {
+		return err
+	}
+
+	link.UsedAt = time.Now()
+	return s.SaveInviteLink(link)
+}
+
+// ListInviteLinks returns the invite links issued for the group
+func (s *Store) ListInviteLinks(groupID int64) ([]InviteLink, error) {
+	var links []InviteLink
+
+	err := db.View(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "InviteLinks")
+		if bucket == nil {
+			return fmt.Errorf("bucket InviteLinks not found")
+		}
+
+		groupBucket := bucket.Bucket(itob(groupID))
+		if groupBucket == nil {
+			return nil
+		}
+
+		return groupBucket.ForEach(func(k, v []byte) error {
+			var link InviteLink
+			if err := json.Unmarshal(v, &link); err != nil {
+				return fmt.Errorf("error parsing JSON: %w", err)
+			}
+			links = append(links, link)
+			return nil
+		})
+	})
+
+	return links, err
+}
@@ -1,12 +1,16 @@
 package storage_db
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
-	"log"
+	"log/slog"
 	"sync"
+	"time"
 
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
 	bolt "go.etcd.io/bbolt"
 	"gopkg.in/telebot.v3"
 )
@@ -14,43 +18,52 @@ import (
 var (
 	db         *bolt.DB
 	DataMutex    sync.Mutex
-	DataChanges = make(chan UserChangeEvent, 100)
 
+	// logger is replaced by main with SetLogger
+	logger = slog.Default()
 )
 
+// SetLogger sets the logger of the storage
+func SetLogger(l *slog.Logger) {
+	logger = l.With("component", "storage")
+}
+
+// ErrNotFound is wrapped by the errors about a missing group config, params or verified user
+var ErrNotFound = errors.New("not found")
+
+// ErrStaleUser is returned when the user record holds another join than the one being updated
+var ErrStaleUser = errors.New("user record holds another join")
+
 // InitDB initializes the BoltDB database
 func InitDB(dbPath string) error {
 	var err error
-	log.Println("Opening database...")
+	logger.Info("Opening database", "path", dbPath)
 
-	db, err = bolt.Open(dbPath, 0600, nil)
+	// Fail fast instead of blocking forever if another process holds the file lock
+	db, err = bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 1 * time.Second})
 	if err != nil {
 		return err
 	}
-	log.Println("Database opened successfully")
+	logger.Info("Database opened successfully")
 
-	// Create the main bucket if it doesn't exist
+	// The buckets of the bots are created by Open, only the shared ones are created here
 	return db.Update(func(tx *bolt.Tx) error {
-		log.Println("Creating buckets if not exists...")
-
-		buckets := []string{
-			"UserStore",
-			"VerificationParamsStore",
-			"GroupSetupState",
-			"VerifiedUsersList",
-		}
-
-		for _, bucket := range buckets {
-			_, err := tx.CreateBucketIfNotExists([]byte(bucket))
-			if err != nil {
-				return fmt.Errorf("ошибка при создании bucket %s: %w", bucket, err)
-			}
+		_, err := tx.CreateBucketIfNotExists([]byte("Health"))
+		if err != nil {
+			return fmt.Errorf("ошибка при создании bucket Health: %w", err)
 		}
-		log.Println("Buckets created successfully")
 		return nil
 	})	
 }
 
+// InitDBReadOnly opens the BoltDB database without changing it, e.g. to read a backup snapshot
+func InitDBReadOnly(dbPath string) error {
+	var err error
+	logger.Info("Opening database read-only", "path", dbPath)
+
+	db, err = bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+	return err
+}
 
 // CloseDB closes the BoltDB database
 func CloseDB() error {
@@ -60,6 +73,26 @@ func CloseDB() error {
 	return nil
 }
 
+// CheckDB checks that the database is open and writable, the time of the check is written to the Health bucket
+func CheckDB() error {
+	if db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte("Health"))
+		if bucket == nil {
+			return fmt.Errorf("bucket Health not found")
+		}
+
+		checkedAt, err := time.Now().MarshalText()
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte("lastCheck"), checkedAt)
+	})
+}
+
 // Custoom structs
 
 // Struct for the user verification
@@ -75,12 +108,22 @@ type UserVerification struct {
 	VerifyMsg *VerifyMsg
 	AuthToken string
 	Role string
+	JoinedAt time.Time // when the member joined the group, the verification timeout counts from it
+	LanguageCode string // Telegram language_code of the member, the bot messages are sent in this language
+	JoinRequest bool // the user asked to join with a join request and isn't in the group until it's approved
+	Invite bool // the user started the bot with an invite deep link and gets an invite link once verified
+}
+
+// IsJoin reports whether the record holds the pending join of the group at joinedAt
+func (u *UserVerification) IsJoin(groupID int64, joinedAt time.Time) bool {
+	return u.IsPending && u.GroupID == groupID && u.JoinedAt.Equal(joinedAt)
 }
 
 // UserChangeEvent - user data change event structure for the channel
 type UserChangeEvent struct {
 	UserID int64              // ID user
 	Data   *UserVerification  // New dara by user
+	Context context.Context   // context of the change, carries its trace; nil if there is none
 }
 
 // Struct for the verification message
@@ -94,6 +137,42 @@ type GroupVerificationConfig struct {
 	VerificationParams []VerificationParams
 	ActiveIndex		int
 	RestrictionType string // block | delete
+	VerificationTimeout int // minutes, 0 means DefaultVerificationTimeout
+	Language string // language of the members without a supported one, empty means i18n.Default
+	Templates map[string]string `json:",omitempty"` // custom messages by TemplateKinds, the others are the default ones
+}
+
+// Defaults of the groups that didn't set their own, main sets them from the config with SetDefaults
+var (
+	defaultsMutex              sync.RWMutex
+	defaultVerificationTimeout = 10 * time.Minute
+	defaultRestrictionType     = ""
+)
+
+// SetDefaults sets the verification timeout and the restriction type of the groups that didn't choose them.
+// It's called again when the config is reloaded.
+func SetDefaults(verificationTimeout time.Duration, restrictionType string) {
+	defaultsMutex.Lock()
+	defer defaultsMutex.Unlock()
+
+	defaultVerificationTimeout = verificationTimeout
+	defaultRestrictionType = restrictionType
+}
+
+// DefaultVerificationTimeout is how long a new member has to pass verification if the group didn't set it
+func DefaultVerificationTimeout() time.Duration {
+	defaultsMutex.RLock()
+	defer defaultsMutex.RUnlock()
+
+	return defaultVerificationTimeout
+}
+
+// defaultRestriction is the restriction type of the groups that didn't choose one
+func defaultRestriction() string {
+	defaultsMutex.RLock()
+	defer defaultsMutex.RUnlock()
+
+	return defaultRestrictionType
 }
 
 // Struct for the parametrs of verification
@@ -101,6 +180,25 @@ type VerificationParams struct {
 	CircuitID        string                 `json:"circuitId"`
 	ID               uint32                 `json:"id"`
 	Query            map[string]interface{} `json:"query"`
+	Name             string                 `json:"name,omitempty"`
+	Description      string                 `json:"description,omitempty"`
+	MinAge           int                    `json:"minAge,omitempty"` // the birthday limit of the query is computed from it for each request
+}
+
+// Type returns the credential type requested by the query, or "unknown" if it is missing
+func (p VerificationParams) Type() string {
+	if queryType, ok := p.Query["type"].(string); ok && queryType != "" {
+		return queryType
+	}
+	return "unknown"
+}
+
+// DisplayName returns the label shown to members, falling back to the credential type
+func (p VerificationParams) DisplayName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Type()
 }
 
 // Struct for the verified user
@@ -120,12 +218,12 @@ type User struct {
 // Functions for the UserStore
 
 // AddOrUpdateUser - adds a new user or updates an existing one
-func AddOrUpdateUser(userID int64, user *UserVerification) error {
+func (s *Store) AddOrUpdateUser(userID int64, user *UserVerification) error {
 	DataMutex.Lock()
 	defer DataMutex.Unlock()
 
 	err := db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("UserStore"))
+		bucket := s.bucket(tx, "UserStore")
 		if bucket == nil {
 			return fmt.Errorf("bucket %s not found", []byte("UserStore"))
 		}
@@ -142,23 +240,62 @@ func AddOrUpdateUser(userID int64, user *UserVerification) error {
 
 	if err == nil {
 		// Отправляем событие в канал
-		DataChanges <- UserChangeEvent{
+		s.publishChange(UserChangeEvent{
 			UserID: userID,
 			Data:   user,
-		}
+		})
 	}
 
 	return err
 }
 
+// SaveVerifiedMember stores the record of a member who passed the verification before joining, without a change event.
+// The result was handled when the verification passed, the record only lets the member post.
+func (s *Store) SaveVerifiedMember(user UserVerification) error {
+	user.IsPending = false
+	user.Verified = true
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "UserStore")
+		if bucket == nil {
+			return fmt.Errorf("bucket %s not found", []byte("UserStore"))
+		}
+
+		data, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(itob(user.UserID), data)
+	})
+}
+
 // UpdateField - updates specified user fields
-func UpdateField(userID int64, updateFunc func(*UserVerification)) error {
-	log.Println("UpdateField DB is called")
+func (s *Store) UpdateField(userID int64, updateFunc func(*UserVerification)) error {
+	return s.UpdateFieldContext(context.Background(), userID, updateFunc)
+}
 
+// UpdateFieldContext updates the user like UpdateField, the change event carries ctx to the listener
+func (s *Store) UpdateFieldContext(ctx context.Context, userID int64, updateFunc func(*UserVerification)) error {
+	return s.updateUser(ctx, userID, nil, updateFunc)
+}
+
+// UpdatePendingUser updates the user like UpdateFieldContext while the record holds the pending join of the group
+// at joinedAt, otherwise it returns ErrStaleUser. The result of a verification must not change a later join.
+func (s *Store) UpdatePendingUser(ctx context.Context, userID int64, groupID int64, joinedAt time.Time, updateFunc func(*UserVerification)) error {
+	return s.updateUser(ctx, userID, func(user *UserVerification) error {
+		if !user.IsJoin(groupID, joinedAt) {
+			return ErrStaleUser
+		}
+		return nil
+	}, updateFunc)
+}
+
+// updateUser applies updateFunc to the user in one transaction if check, when given, accepts the stored record
+func (s *Store) updateUser(ctx context.Context, userID int64, check func(*UserVerification) error, updateFunc func(*UserVerification)) error {
 	var user *UserVerification
 
 	err := db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("UserStore"))
+		bucket := s.bucket(tx, "UserStore")
 		if bucket == nil {
 			return fmt.Errorf("bucket %s not found", []byte("UserStore"))
 		}
@@ -166,7 +303,7 @@ func UpdateField(userID int64, updateFunc func(*UserVerification)) error {
 		// Getting current user data
 		data := bucket.Get(itob(userID))
 		if data == nil {
-			return fmt.Errorf("User %d not found", userID)
+			return fmt.Errorf("User %d %w", userID, ErrNotFound)
 		}
 
 		user = &UserVerification{}
@@ -174,6 +311,12 @@ func UpdateField(userID int64, updateFunc func(*UserVerification)) error {
 			return err
 		}
 
+		if check != nil {
+			if err := check(user); err != nil {
+				return err
+			}
+		}
+
 		// Update user data using the passed function
 		updateFunc(user)
 
@@ -187,26 +330,28 @@ func UpdateField(userID int64, updateFunc func(*UserVerification)) error {
 	})
 
 	if err == nil {
-		log.Println("UpdateField DB sending event to the channel")
 		// Sending an event to a channel
-		DataChanges <- UserChangeEvent{
-			UserID: userID,
-			Data:   user,
-		}
-		log.Println("UpdateField DB logs: info updated user:") 
-		log.Println("Name:", user.Username)
-		log.Println("IsPending:", user.IsPending)
-		log.Println("Verified:", user.Verified)
-		log.Println("User role:", user.Role)
+		s.publishChange(UserChangeEvent{
+			UserID:  userID,
+			Data:    user,
+			Context: ctx,
+		})
+		logger.Debug("User updated",
+			"user_id", userID,
+			"group_id", user.GroupID,
+			"pending", user.IsPending,
+			"verified", user.Verified,
+			"role", user.Role,
+		)
 	}
 
 	return err
 }
 
 // DeleteUser - removes a user from the repository
-func DeleteUser(userID int64) error {
+func (s *Store) DeleteUser(userID int64) error {
 	err := db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("UserStore"))
+		bucket := s.bucket(tx, "UserStore")
 		if bucket == nil {
 			return fmt.Errorf("bucket %s not found", []byte("UserStore"))
 		}
@@ -219,14 +364,14 @@ func DeleteUser(userID int64) error {
 }
 
 // GetUser - returns user data
-func GetUser(userID int64) (*UserVerification, error) {
+func (s *Store) GetUser(userID int64) (*UserVerification, error) {
 	DataMutex.Lock()
 	defer DataMutex.Unlock()
 
 	var user UserVerification
 
 	err := db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("UserStore"))
+		bucket := s.bucket(tx, "UserStore")
 		if bucket == nil {
 			return fmt.Errorf("bucket %s not found", []byte("UserStore"))
 		}
@@ -246,20 +391,47 @@ func GetUser(userID int64) (*UserVerification, error) {
 	return &user, nil
 }
 
+// GetPendingUsers returns the members of the group that haven't finished the verification yet
+func (s *Store) GetPendingUsers(groupID int64) ([]UserVerification, error) {
+	users := []UserVerification{}
+
+	err := db.View(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "UserStore")
+		if bucket == nil {
+			return fmt.Errorf("bucket %s not found", []byte("UserStore"))
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var user UserVerification
+			if err := json.Unmarshal(v, &user); err != nil {
+				logger.Error("Error decoding user", "user_id", btoi(k), "error", err)
+				return nil
+			}
+
+			if user.GroupID == groupID && user.IsPending {
+				users = append(users, user)
+			}
+			return nil
+		})
+	})
+
+	return users, err
+}
+
 // Method for add verification message
-func AddVerificationMsg(userID int64, msgID int, msg *telebot.Message) error {
+func (s *Store) AddVerificationMsg(userID int64, msgID int, msg *telebot.Message) error {
 	return db.Update(func(tx *bolt.Tx) error {
 		// Open bucket UserStore
-		bucket := tx.Bucket([]byte("UserStore"))
+		bucket := s.bucket(tx, "UserStore")
 		if bucket == nil {
-			log.Println("bucket UserStore not found")
+			logger.Error("Bucket UserStore not found")
 			return nil
 		}
 
 		// Get user data from the database
 		data := bucket.Get(itob(userID))
 		if data == nil {
-			log.Println("user ID not found")
+			logger.Warn("User not found for the verification message", "user_id", userID)
 			return nil
 		}
 
@@ -290,28 +462,28 @@ func AddVerificationMsg(userID int64, msgID int, msg *telebot.Message) error {
 	})
 }
 
-func DeleteVerifyMessage(bot *telebot.Bot, userID int64) error {
+func (s *Store) DeleteVerifyMessage(bot *telebot.Bot, userID int64) error {
 	// Get user data from the database
-	user, err := GetUser(userID)
+	user, err := s.GetUser(userID)
 	if err != nil {
-		log.Printf("Failed to get user %d: %v", userID, err)
+		logger.Error("Failed to get user", "user_id", userID, "error", err)
 		return err
 	}
 
 	// Check if there is a verification message to delete
-	if user.VerifyMsg.MsgId == 0 && user.VerifyMsg.Msg == nil {
-		log.Printf("No verification message to delete for user %d", userID)
+	if user.VerifyMsg == nil || (user.VerifyMsg.MsgId == 0 && user.VerifyMsg.Msg == nil) {
+		logger.Debug("No verification message to delete", "user_id", userID)
 		return nil
 	}
 
 	// Delete the verification message
 	err = bot.Delete(user.VerifyMsg.Msg)
 	if err != nil {
-		log.Printf("Failed to delete verification message for user %d: %v", userID, err)
+		logger.Error("Failed to delete verification message", "user_id", userID, "error", err)
 		return err
 	}
 
-	log.Printf("Verification message deleted for user %d: message ID %d", userID, user.VerifyMsg.MsgId)
+	logger.Debug("Verification message deleted", "user_id", userID, "message_id", user.VerifyMsg.MsgId)
 
 	// Upadate user data in the database
 	user.VerifyMsg.MsgId = 0
@@ -322,11 +494,11 @@ func DeleteVerifyMessage(bot *telebot.Bot, userID int64) error {
 
 
 // GetUserGroupID return user's GroupID
-func GetUserGroupID(userID int64) (int64, error) {
+func (s *Store) GetUserGroupID(userID int64) (int64, error) {
 	var groupID int64
 
 	err := db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("UserStore"))
+		bucket := s.bucket(tx, "UserStore")
 		if bucket == nil {
 			return fmt.Errorf("bucket UserStore not found")
 		}
@@ -356,9 +528,9 @@ func GetUserGroupID(userID int64) (int64, error) {
 // Functions for the VerificationParamsStore
 
 // Add restriction type to group
-func AddRestrictionType(groupID int64, restrictionType string) error {
+func (s *Store) AddRestrictionType(groupID int64, restrictionType string) error {
 	return db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("VerificationParamsStore"))
+		bucket := s.bucket(tx, "VerificationParamsStore")
 		if bucket == nil {
 			return fmt.Errorf("bucket VerificationParamsStore not found")
 		}
@@ -387,11 +559,11 @@ func AddRestrictionType(groupID int64, restrictionType string) error {
 }
 
 // Get restriction type from group
-func GetRestrictionType(groupID int64) (string, error) {
+func (s *Store) GetRestrictionType(groupID int64) (string, error) {
 	var restrictionType string
 
 	err := db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("VerificationParamsStore"))
+		bucket := s.bucket(tx, "VerificationParamsStore")
 		if bucket == nil {
 			return fmt.Errorf("bucket VerificationParamsStore not found")
 		}
@@ -411,23 +583,72 @@ func GetRestrictionType(groupID int64) (string, error) {
 		return nil
 	})
 
+	// The groups that didn't choose a restriction type get the default one
+	if err == nil && restrictionType == "" {
+		restrictionType = defaultRestriction()
+	}
+
 	return restrictionType, err
 }
 
+// SetGroupLanguage sets the default language of the group, an empty language resets it
+func (s *Store) SetGroupLanguage(groupID int64, language string) error {
+	if language != "" && !i18n.IsSupported(language) {
+		return fmt.Errorf("unsupported language %q", language)
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "VerificationParamsStore")
+		if bucket == nil {
+			return fmt.Errorf("bucket VerificationParamsStore not found")
+		}
+
+		data := bucket.Get(itob(groupID))
+		var groupConfig GroupVerificationConfig
+
+		if data != nil {
+			if err := json.Unmarshal(data, &groupConfig); err != nil {
+				return fmt.Errorf("error parsing JSON: %w", err)
+			}
+		} else {
+			// A new config has no active params yet
+			groupConfig.ActiveIndex = -1
+		}
+
+		groupConfig.Language = language
+
+		encoded, err := json.Marshal(groupConfig)
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+
+		return bucket.Put(itob(groupID), encoded)
+	})
+}
+
+// GetGroupLanguage returns the default language of the group, or an empty string if the group didn't set one
+func (s *Store) GetGroupLanguage(groupID int64) string {
+	groupConfig, err := s.GetGroupConfigParams(groupID)
+	if err != nil {
+		return ""
+	}
+	return groupConfig.Language
+}
+
 // GetVerificationType gets value "type" from VerificationParam
-func GetVerificationType(groupID int64) (string, error) {
+func (s *Store) GetVerificationType(groupID int64) (string, error) {
 	var verificationType string
 
 	err := db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("VerificationParamsStore"))
+		bucket := s.bucket(tx, "VerificationParamsStore")
 		if bucket == nil {
-			log.Println("bucket VerificationParamsStore not found")
+			logger.Error("Bucket VerificationParamsStore not found")
 			return nil
 		}
 
 		groupData := bucket.Get(itob(groupID))
 		if groupData == nil {
-			log.Println("group ID not found")
+			logger.Debug("Group config not found", "group_id", groupID)
 			return nil
 		}
 
@@ -437,18 +658,18 @@ func GetVerificationType(groupID int64) (string, error) {
 		}
 
 		if len(configGroupParams.VerificationParams) == 0 {
-			log.Println("no verification parameters found")
+			logger.Debug("No verification parameters found", "group_id", groupID)
 			return nil
 		}
 
 		activeIndex := configGroupParams.ActiveIndex
-		if activeIndex >= len(configGroupParams.VerificationParams) {
-			log.Println("active index out of range")
+		if activeIndex < 0 || activeIndex >= len(configGroupParams.VerificationParams) {
+			logger.Warn("Active index out of range", "group_id", groupID, "index", activeIndex)
 			return nil
 		}
 
 		params := configGroupParams.VerificationParams[activeIndex]
-		verificationType = params.Query["type"].(string)
+		verificationType = params.Type()
 		return nil
 	})
 
@@ -460,9 +681,9 @@ func GetVerificationType(groupID int64) (string, error) {
 }
 
 // SaveVerificationParams save parametrs veriofication to DB
-func SaveVerificationParams(groupID int64, params VerificationParams) error {
+func (s *Store) SaveVerificationParams(groupID int64, params VerificationParams) error {
 	return db.Update(func(tx *bolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists([]byte("VerificationParamsStore"))
+		bucket, err := s.createBucket(tx, "VerificationParamsStore")
 		if err != nil {
 			return err
 		}
@@ -500,10 +721,41 @@ func SaveVerificationParams(groupID int64, params VerificationParams) error {
 	})
 }
 
+// SaveGroupConfig replaces the whole verification config of the group
+func (s *Store) SaveGroupConfig(groupID int64, groupConfig GroupVerificationConfig) error {
+	if err := ValidateGroupConfig(groupConfig); err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "VerificationParamsStore")
+		if bucket == nil {
+			return fmt.Errorf("bucket VerificationParamsStore not found")
+		}
+
+		encoded, err := json.Marshal(groupConfig)
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+
+		return bucket.Put(itob(groupID), encoded)
+	})
+}
+
+// GetVerificationTimeout returns how long new members of the group have to pass verification
+func (s *Store) GetVerificationTimeout(groupID int64) time.Duration {
+	groupConfig, err := s.GetGroupConfigParams(groupID)
+	if err != nil || groupConfig.VerificationTimeout <= 0 {
+		return DefaultVerificationTimeout()
+	}
+
+	return time.Duration(groupConfig.VerificationTimeout) * time.Minute
+}
+
 // Delete all verification params for the group using groupID
-func DeleteAllVerificationParams(groupID int64) error {
+func (s *Store) DeleteAllVerificationParams(groupID int64) error {
 	return db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("VerificationParamsStore"))
+		bucket := s.bucket(tx, "VerificationParamsStore")
 		if bucket == nil {
 			return fmt.Errorf("bucket VerificationParamsStore not found")
 		}
@@ -513,18 +765,18 @@ func DeleteAllVerificationParams(groupID int64) error {
 }
 
 // Ger active verification params
-func GetActiveVerificationParams(groupID int64) (VerificationParams, error) {
+func (s *Store) GetActiveVerificationParams(groupID int64) (VerificationParams, error) {
 	var params VerificationParams
 
 	err := db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("VerificationParamsStore"))
+		bucket := s.bucket(tx, "VerificationParamsStore")
 		if bucket == nil {
 			return fmt.Errorf("bucket VerificationParamsStore not found")
 		}
 
 		groupData := bucket.Get([]byte(itob(groupID)))
 		if groupData == nil {
-			return fmt.Errorf("group ID %v not found", groupID)
+			return fmt.Errorf("group ID %v %w", groupID, ErrNotFound)
 		}
 
 		var groupConfig GroupVerificationConfig
@@ -532,8 +784,8 @@ func GetActiveVerificationParams(groupID int64) (VerificationParams, error) {
 			return err
 		}
 
-		if groupConfig.ActiveIndex == -1 {
-			return fmt.Errorf("no active verification params found")
+		if groupConfig.ActiveIndex < 0 || groupConfig.ActiveIndex >= len(groupConfig.VerificationParams) {
+			return fmt.Errorf("active verification params %w", ErrNotFound)
 		}
 
 		params = groupConfig.VerificationParams[groupConfig.ActiveIndex]
@@ -549,18 +801,18 @@ func GetActiveVerificationParams(groupID int64) (VerificationParams, error) {
 
 
 // Get group config parametrs
-func GetGroupConfigParams(groupID int64) (GroupVerificationConfig, error) {
+func (s *Store) GetGroupConfigParams(groupID int64) (GroupVerificationConfig, error) {
 	var groupConfig GroupVerificationConfig
 
 	err := db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("VerificationParamsStore"))
+		bucket := s.bucket(tx, "VerificationParamsStore")
 		if bucket == nil {
 			return fmt.Errorf("bucket VerificationParamsStore not found")
 		}
 
 		groupData := bucket.Get([]byte(itob(groupID)))
 		if groupData == nil {
-			return fmt.Errorf("group ID %v not found", groupID)
+			return fmt.Errorf("group ID %v %w", groupID, ErrNotFound)
 		}
 
 		if err := json.Unmarshal(groupData, &groupConfig); err != nil {
@@ -577,10 +829,29 @@ func GetGroupConfigParams(groupID int64) (GroupVerificationConfig, error) {
 	return groupConfig, nil
 }
 
+// ListConfiguredGroups returns the IDs of the groups that have a verification config
+func (s *Store) ListConfiguredGroups() ([]int64, error) {
+	var groupIDs []int64
+
+	err := db.View(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "VerificationParamsStore")
+		if bucket == nil {
+			return fmt.Errorf("bucket VerificationParamsStore not found")
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			groupIDs = append(groupIDs, btoi(k))
+			return nil
+		})
+	})
+
+	return groupIDs, err
+}
+
 // SetActiveVerificationParams set active verification params
-func SetActiveVerificationParams(groupID int64, index int) error {
+func (s *Store) SetActiveVerificationParams(groupID int64, index int) error {
 	return db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("VerificationParamsStore"))
+		bucket := s.bucket(tx, "VerificationParamsStore")
 		if bucket == nil {
 			return fmt.Errorf("bucket VerificationParamsStore not found")
 		}
@@ -594,7 +865,11 @@ func SetActiveVerificationParams(groupID int64, index int) error {
 				return err
 			}
 		} else {
-			return fmt.Errorf("group ID %v not found", groupID)
+			return fmt.Errorf("group ID %v %w", groupID, ErrNotFound)
+		}
+
+		if err := checkParamsIndex(&groupConfig, index); err != nil {
+			return err
 		}
 
 		// Set new active index
@@ -610,13 +885,171 @@ func SetActiveVerificationParams(groupID int64, index int) error {
 	})
 }
 
+// updateGroupConfig loads the group config, applies updateFunc and saves it back in one transaction
+func (s *Store) updateGroupConfig(groupID int64, updateFunc func(*GroupVerificationConfig) error) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "VerificationParamsStore")
+		if bucket == nil {
+			return fmt.Errorf("bucket VerificationParamsStore not found")
+		}
+
+		groupData := bucket.Get(itob(groupID))
+		if groupData == nil {
+			return fmt.Errorf("group ID %v %w", groupID, ErrNotFound)
+		}
+
+		var groupConfig GroupVerificationConfig
+		if err := json.Unmarshal(groupData, &groupConfig); err != nil {
+			return err
+		}
+
+		if err := updateFunc(&groupConfig); err != nil {
+			return err
+		}
+
+		updatedData, err := json.Marshal(groupConfig)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(itob(groupID), updatedData)
+	})
+}
+
+// checkParamsIndex returns an error if index does not point to existing params
+func checkParamsIndex(groupConfig *GroupVerificationConfig, index int) error {
+	if index < 0 || index >= len(groupConfig.VerificationParams) {
+		return fmt.Errorf("verification params #%d %w", index+1, ErrNotFound)
+	}
+	return nil
+}
+
+// UpdateVerificationParams replaces the params at index
+func (s *Store) UpdateVerificationParams(groupID int64, index int, params VerificationParams) error {
+	return s.updateGroupConfig(groupID, func(groupConfig *GroupVerificationConfig) error {
+		if err := checkParamsIndex(groupConfig, index); err != nil {
+			return err
+		}
+
+		groupConfig.VerificationParams[index] = params
+		return nil
+	})
+}
+
+// DuplicateVerificationParams inserts a copy of the params at index right after it
+func (s *Store) DuplicateVerificationParams(groupID int64, index int) error {
+	return s.updateGroupConfig(groupID, func(groupConfig *GroupVerificationConfig) error {
+		if err := checkParamsIndex(groupConfig, index); err != nil {
+			return err
+		}
+
+		// Deep copy the query so the two entries don't share maps
+		data, err := json.Marshal(groupConfig.VerificationParams[index])
+		if err != nil {
+			return err
+		}
+		var duplicate VerificationParams
+		if err := json.Unmarshal(data, &duplicate); err != nil {
+			return err
+		}
+		if duplicate.Name != "" {
+			duplicate.Name += " (copy)"
+		}
+
+		params := groupConfig.VerificationParams
+		params = append(params[:index+1], append([]VerificationParams{duplicate}, params[index+1:]...)...)
+		groupConfig.VerificationParams = params
+
+		// Keep the same params active
+		if groupConfig.ActiveIndex > index {
+			groupConfig.ActiveIndex++
+		}
+		return nil
+	})
+}
+
+// DeleteVerificationParams removes the params at index.
+// If they were the active ones, the first params left become active and activeDeleted is true.
+func (s *Store) DeleteVerificationParams(groupID int64, index int) (activeDeleted bool, err error) {
+	err = s.updateGroupConfig(groupID, func(groupConfig *GroupVerificationConfig) error {
+		if err := checkParamsIndex(groupConfig, index); err != nil {
+			return err
+		}
+
+		groupConfig.VerificationParams = append(groupConfig.VerificationParams[:index], groupConfig.VerificationParams[index+1:]...)
+		activeDeleted = groupConfig.ActiveIndex == index
+
+		switch {
+		case len(groupConfig.VerificationParams) == 0:
+			groupConfig.ActiveIndex = -1 // No active params
+		case groupConfig.ActiveIndex > index:
+			groupConfig.ActiveIndex--
+		case activeDeleted:
+			// The active params were deleted, fall back to the first ones
+			groupConfig.ActiveIndex = 0
+		}
+		return nil
+	})
+	return activeDeleted, err
+}
+
+// MoveVerificationParams swaps the params at index with its neighbour; offset is -1 (up) or 1 (down)
+func (s *Store) MoveVerificationParams(groupID int64, index int, offset int) error {
+	return s.updateGroupConfig(groupID, func(groupConfig *GroupVerificationConfig) error {
+		if err := checkParamsIndex(groupConfig, index); err != nil {
+			return err
+		}
+
+		target := index + offset
+		if target < 0 || target >= len(groupConfig.VerificationParams) {
+			return fmt.Errorf("verification params #%d cannot be moved further", index+1)
+		}
+
+		params := groupConfig.VerificationParams
+		params[index], params[target] = params[target], params[index]
+
+		// The active index follows the params it points to
+		switch groupConfig.ActiveIndex {
+		case index:
+			groupConfig.ActiveIndex = target
+		case target:
+			groupConfig.ActiveIndex = index
+		}
+		return nil
+	})
+}
+
+// RenameVerificationParams sets the display name of the params at index, an empty name clears it
+func (s *Store) RenameVerificationParams(groupID int64, index int, name string) error {
+	return s.updateGroupConfig(groupID, func(groupConfig *GroupVerificationConfig) error {
+		if err := checkParamsIndex(groupConfig, index); err != nil {
+			return err
+		}
+
+		groupConfig.VerificationParams[index].Name = name
+		return nil
+	})
+}
+
+// DescribeVerificationParams sets the description of the params at index, an empty description clears it
+func (s *Store) DescribeVerificationParams(groupID int64, index int, description string) error {
+	return s.updateGroupConfig(groupID, func(groupConfig *GroupVerificationConfig) error {
+		if err := checkParamsIndex(groupConfig, index); err != nil {
+			return err
+		}
+
+		groupConfig.VerificationParams[index].Description = description
+		return nil
+	})
+}
+
 // ========================
 // Functions for the GroupSetupState
 
 // AddAdminUser - adds a new admin user to the GroupSetupState
-func AddAdminUser(userID, groupID int64) error {
+func (s *Store) AddAdminUser(userID, groupID int64) error {
 	return db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("GroupSetupState"))
+		bucket := s.bucket(tx, "GroupSetupState")
 		if bucket == nil {
 			return fmt.Errorf("DB logs: bucket GroupSetupState not found")
 		}
@@ -627,11 +1060,11 @@ func AddAdminUser(userID, groupID int64) error {
 }
 
 // GetIdGroupFromGroupSetapState - returns the group ID by the admin user ID
-func GetIdGroupFromGroupSetupState(userID int64) (int64, error) {
+func (s *Store) GetIdGroupFromGroupSetupState(userID int64) (int64, error) {
 	var groupID int64
 
 	err := db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("GroupSetupState"))
+		bucket := s.bucket(tx, "GroupSetupState")
 		if bucket == nil {
 			return fmt.Errorf("DB logs: bucket GroupSetupState not found")
 		}
@@ -654,25 +1087,19 @@ func GetIdGroupFromGroupSetupState(userID int64) (int64, error) {
 // Functions for the VerifiedUsersList
 
 // AddVerifiedUser - add user to verified list in database
-func AddVerifiedUser(groupID int64, userID int64, userName string, VerifiedToken string, typeVerification string, authToken string) {
+func (s *Store) AddVerifiedUser(groupID int64, userID int64, userName string, VerifiedToken string, typeVerification string, authToken string) {
 	db.Update(func(tx *bolt.Tx) error {
-		log.Println("AddVerifiedUser DB is called")
-		log.Println("Agruments func AddVerifiedUser:")
-		log.Println("groupID:", groupID)
-		log.Println("userID:", userID)
-		log.Println("userName:", userName)
-		log.Println("VerifiedToken:", VerifiedToken)
-		log.Println("typeVerification:", typeVerification)
-		if authToken != "" {
-			log.Println("authToken: there is it! All OK!",)
-			// Opitional
-			// log.Println("authToken:", authToken)
-		} else {
-			log.Println("authToken: parameter is empty")
-		}
+		// The tokens are never logged, only whether the admin token is kept
+		logger.Debug("Adding verified user",
+			"group_id", groupID,
+			"user_id", userID,
+			"username", userName,
+			"verification_type", typeVerification,
+			"has_auth_token", authToken != "",
+		)
 
 		// Get the VerifiedUsersList bucket
-		bucket := tx.Bucket([]byte("VerifiedUsersList"))
+		bucket := s.bucket(tx, "VerifiedUsersList")
 
 		// Create a new VerifiedUser object
 		user := User{
@@ -729,75 +1156,74 @@ func AddVerifiedUser(groupID int64, userID int64, userName string, VerifiedToken
 			return err
 		}
 
-		log.Printf("AddVerifiedUser logs: User ID %d added/updated in group %d with data: %s", userID, groupID, string(data)) // LOG
+		logger.Info("Verified user added", "group_id", groupID, "user_id", userID)
 
 		return nil
 	})
 }
 
 // RemoveVerifiedUser - removes a user from VerifiedUsersList by group ID and user ID in database
-func RemoveVerifiedUser(groupID int64, userID int64) {
+func (s *Store) RemoveVerifiedUser(groupID int64, userID int64) {
 	if db == nil {
-		log.Println("ERROR: Database not initialized")
+		logger.Error("Database not initialized")
 		return
 	}
 
 	err := db.Update(func(tx *bolt.Tx) error {
-		log.Println("RemoveVerifiedUser DB is called")
-		log.Printf("Arguments func RemoveVerifiedUser: groupID=%d, userID=%d", groupID, userID)
+		logger.Debug("Removing verified user", "group_id", groupID, "user_id", userID)
 
-		bucket := tx.Bucket([]byte("VerifiedUsersList"))
+		bucket := s.bucket(tx, "VerifiedUsersList")
 		if bucket == nil {
-			log.Println("ERROR: VerifiedUsersList bucket not found")
+			logger.Error("Bucket VerifiedUsersList not found")
 			return nil
 		}
 
 		groupBucket := bucket.Bucket(itob(groupID))
 		if groupBucket == nil {
-			log.Printf("Group %d not found in VerifiedUsersList", groupID)
+			logger.Debug("Group not found in VerifiedUsersList", "group_id", groupID)
 			return nil
 		}
 
 		// Check if the user exists in the group's list
 		userData := groupBucket.Get(itob(userID))
 		if userData == nil {
-			log.Printf("User %d not found in group %d", userID, groupID)
+			logger.Debug("Verified user not found", "group_id", groupID, "user_id", userID)
 			return nil
 		}
 
 		// Remove the user from the group
 		err := groupBucket.Delete(itob(userID))
 		if err != nil {
-			log.Printf("ERROR: Failed to remove user %d from group %d: %v", userID, groupID, err)
+			logger.Error("Failed to remove verified user", "group_id", groupID, "user_id", userID, "error", err)
 			return err
 		}
-		log.Printf("User %d removed from group %d", userID, groupID)
+		logger.Info("Verified user removed", "group_id", groupID, "user_id", userID)
 
 		// Check if the group is empty after the user removal
 		if groupBucket.Stats().KeyN == 0 {
 			err := bucket.DeleteBucket(itob(groupID))
 			if err != nil {
-				log.Printf("ERROR: Failed to remove empty group %d: %v", groupID, err)
+				logger.Error("Failed to remove empty group", "group_id", groupID, "error", err)
 				return err
 			}
-			log.Printf("Group %d removed from VerifiedUsersList (empty after user removal)", groupID)
+			logger.Debug("Group removed from VerifiedUsersList (empty after user removal)", "group_id", groupID)
 		}
 
 		return nil
 	})
 
 	if err != nil {
-		log.Printf("ERROR: RemoveVerifiedUser failed: %v", err)
+		logger.Error("RemoveVerifiedUser failed", "group_id", groupID, "user_id", userID, "error", err)
 	}
 }
 
 
 
 // Delete all verified users for the group using groupID
-func DeleteAllVerifiedUsers(groupID int64) {
+func (s *Store) DeleteAllVerifiedUsers(groupID int64) {
 	db.Update(func(tx *bolt.Tx) error {
 		// Get the VerifiedUsersList bucket
-		bucket := tx.Bucket([]byte("VerifiedUsersList"))
+		bucket := s.bucket(tx, "VerifiedUsersList")
 
 		// Delete the group bucket
 		err := bucket.DeleteBucket(itob(groupID))
@@ -810,12 +1236,12 @@ func DeleteAllVerifiedUsers(groupID int64) {
 }
 
 // GetVerifiedUsersList - returns a list of verified users for the group
-func GetVerifiedUsersList(groupID int64) ([]VerifiedUser, error) {
+func (s *Store) GetVerifiedUsersList(groupID int64) ([]VerifiedUser, error) {
 	var users []VerifiedUser
 
 	err := db.View(func(tx *bolt.Tx) error {
 		// Get the VerifiedUsersList bucket
-		bucket := tx.Bucket([]byte("VerifiedUsersList"))
+		bucket := s.bucket(tx, "VerifiedUsersList")
 		if bucket == nil {
 			return fmt.Errorf("bucket VerifiedUsersList not found")
 		}
@@ -834,7 +1260,7 @@ func GetVerifiedUsersList(groupID int64) ([]VerifiedUser, error) {
 		
 			var user VerifiedUser
 			if err := json.Unmarshal(v, &user); err != nil {
-				log.Printf("Error decoding user %s in group %d: %v", k, groupID, err)
+				logger.Error("Error decoding verified user", "group_id", groupID, "user_id", btoi(k), "error", err)
 				return nil // Пропускаем ошибку, но не останавливаем функцию
 			}
 		
@@ -0,0 +1,150 @@
+package storage_db
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testStore is opened in a temporary database, the tests use their own users and groups in it
+var testStore *Store
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "storage_db")
+	if err != nil {
+		panic(err)
+	}
+
+	if err := InitDB(filepath.Join(dir, "test.db")); err != nil {
+		panic(err)
+	}
+	if testStore, err = Open("test"); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	CloseChanges()
+	CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// drainChanges keeps the change channel of the store from filling up
+func drainChanges(t *testing.T) {
+	t.Helper()
+	for {
+		select {
+		case <-testStore.Changes():
+		default:
+			return
+		}
+	}
+}
+
+func TestUpdatePendingUser(t *testing.T) {
+	joinedA := time.Now().Add(-time.Hour)
+	joinedB := time.Now()
+
+	tests := []struct {
+		name     string
+		record   *UserVerification // nil if the user has no record
+		groupID  int64
+		joinedAt time.Time
+		wantErr  error
+	}{
+		{"same join", &UserVerification{GroupID: -1, JoinedAt: joinedA, IsPending: true}, -1, joinedA, nil},
+		{"join of another group", &UserVerification{GroupID: -2, JoinedAt: joinedB, IsPending: true}, -1, joinedA, ErrStaleUser},
+		{"later join of the group", &UserVerification{GroupID: -1, JoinedAt: joinedB, IsPending: true}, -1, joinedA, ErrStaleUser},
+		{"finished join", &UserVerification{GroupID: -1, JoinedAt: joinedA}, -1, joinedA, ErrStaleUser},
+		{"no record", nil, -1, joinedA, ErrNotFound},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer drainChanges(t)
+
+			userID := int64(100 + i)
+			if tt.record != nil {
+				tt.record.UserID = userID
+				if err := testStore.AddOrUpdateUser(userID, tt.record); err != nil {
+					t.Fatalf("AddOrUpdateUser: %v", err)
+				}
+			}
+
+			err := testStore.UpdatePendingUser(context.Background(), userID, tt.groupID, tt.joinedAt, func(user *UserVerification) {
+				user.IsPending = false
+				user.Verified = true
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdatePendingUser error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.record == nil {
+				return
+			}
+
+			user, err := testStore.GetUser(userID)
+			if err != nil {
+				t.Fatalf("GetUser: %v", err)
+			}
+			if user.Verified != (tt.wantErr == nil) {
+				t.Errorf("Verified = %v, want %v", user.Verified, tt.wantErr == nil)
+			}
+			if user.GroupID != tt.record.GroupID || !user.JoinedAt.Equal(tt.record.JoinedAt) {
+				t.Errorf("record changed to group %d joined at %v", user.GroupID, user.JoinedAt)
+			}
+		})
+	}
+}
+
+// testParams returns valid params with the names
+func testParams(names ...string) []VerificationParams {
+	var params []VerificationParams
+	for i, name := range names {
+		params = append(params, VerificationParams{CircuitID: "credentialAtomicQuerySigV2", ID: uint32(i + 1), Query: map[string]interface{}{"type": "KYC"}, Name: name})
+	}
+	return params
+}
+
+func TestDeleteVerificationParams(t *testing.T) {
+	tests := []struct {
+		name              string
+		activeIndex       int
+		index             int
+		wantActiveDeleted bool
+		wantActive        string // name of the active params after the delete
+	}{
+		{"before the active params", 2, 0, false, "c"},
+		{"after the active params", 0, 1, false, "a"},
+		{"the active params", 1, 1, true, "a"},
+		{"the first and active params", 0, 0, true, "b"},
+	}
+
+	for i, tt := range tests {
+		groupID := int64(-3000 - i)
+		t.Run(tt.name, func(t *testing.T) {
+			groupConfig := GroupVerificationConfig{VerificationParams: testParams("a", "b", "c"), ActiveIndex: tt.activeIndex}
+			if err := testStore.SaveGroupConfig(groupID, groupConfig); err != nil {
+				t.Fatalf("SaveGroupConfig: %v", err)
+			}
+
+			activeDeleted, err := testStore.DeleteVerificationParams(groupID, tt.index)
+			if err != nil {
+				t.Fatalf("DeleteVerificationParams: %v", err)
+			}
+			if activeDeleted != tt.wantActiveDeleted {
+				t.Errorf("activeDeleted = %v, want %v", activeDeleted, tt.wantActiveDeleted)
+			}
+
+			active, err := testStore.GetActiveVerificationParams(groupID)
+			if err != nil {
+				t.Fatalf("GetActiveVerificationParams: %v", err)
+			}
+			if active.Name != tt.wantActive {
+				t.Errorf("active params = %q, want %q", active.Name, tt.wantActive)
+			}
+		})
+	}
+}
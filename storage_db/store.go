@@ -0,0 +1,160 @@
+package storage_db
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Buckets of every store
+var storeBuckets = []string{
+	"UserStore",
+	"VerificationParamsStore",
+	"GroupSetupState",
+	"VerifiedUsersList",
+	"AdminAPIKeys",
+	"WebhookSubscriptions",
+	"WebhookDeadLetters",
+	"VerificationFailures",
+	"InvitePolicies",
+	"InviteLinks",
+}
+
+// namespacePattern limits the namespaces to names that are safe in bucket names, paths and logs
+var namespacePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Store is the storage of one bot. All bots share the database file, but not their data:
+// the buckets of the default store (the empty namespace) are at the top level of the database, as before several
+// bots could be hosted, and the buckets of a named store are nested in the "ns:<namespace>" bucket.
+type Store struct {
+	namespace string
+
+	// changes receives the changes of the users for the listener of the bot
+	changes chan UserChangeEvent
+	// changesMutex guards sending to changes against closing it on shutdown
+	changesMutex  sync.RWMutex
+	changesClosed bool
+}
+
+var (
+	stores      = map[string]*Store{}
+	storesMutex sync.Mutex
+)
+
+// IsValidNamespace checks the name of a store, the empty name is the default store
+func IsValidNamespace(namespace string) bool {
+	return namespace == "" || namespacePattern.MatchString(namespace)
+}
+
+// Open returns the store of the namespace and creates its buckets. The database must be initialized.
+func Open(namespace string) (*Store, error) {
+	if !IsValidNamespace(namespace) {
+		return nil, fmt.Errorf("invalid storage namespace %q", namespace)
+	}
+	if db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	storesMutex.Lock()
+	defer storesMutex.Unlock()
+
+	if s, ok := stores[namespace]; ok {
+		return s, nil
+	}
+
+	s := &Store{namespace: namespace, changes: make(chan UserChangeEvent, 100)}
+
+	// A read-only database is left as it is, the buckets it lacks are reported as not found
+	if db.IsReadOnly() {
+		stores[namespace] = s
+		return s, nil
+	}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		logger.Debug("Creating buckets if not exists", "namespace", namespace)
+		for _, bucket := range storeBuckets {
+			if _, err := s.createBucket(tx, bucket); err != nil {
+				return fmt.Errorf("ошибка при создании bucket %s: %w", bucket, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stores[namespace] = s
+	return s, nil
+}
+
+// Namespace returns the name of the store, empty for the default one
+func (s *Store) Namespace() string {
+	return s.namespace
+}
+
+// Changes returns the channel of the user changes of the store, it's closed by CloseChanges
+func (s *Store) Changes() <-chan UserChangeEvent {
+	return s.changes
+}
+
+// root returns the bucket holding the buckets of the store, nil for the default store
+func (s *Store) root(tx *bolt.Tx) *bolt.Bucket {
+	if s.namespace == "" {
+		return nil
+	}
+	return tx.Bucket([]byte("ns:" + s.namespace))
+}
+
+// bucket returns a bucket of the store, nil if it doesn't exist
+func (s *Store) bucket(tx *bolt.Tx, name string) *bolt.Bucket {
+	if s.namespace == "" {
+		return tx.Bucket([]byte(name))
+	}
+	root := s.root(tx)
+	if root == nil {
+		return nil
+	}
+	return root.Bucket([]byte(name))
+}
+
+// createBucket returns a bucket of the store, it's created if it doesn't exist
+func (s *Store) createBucket(tx *bolt.Tx, name string) (*bolt.Bucket, error) {
+	if s.namespace == "" {
+		return tx.CreateBucketIfNotExists([]byte(name))
+	}
+	root, err := tx.CreateBucketIfNotExists([]byte("ns:" + s.namespace))
+	if err != nil {
+		return nil, err
+	}
+	return root.CreateBucketIfNotExists([]byte(name))
+}
+
+// publishChange sends a user change event to the listener unless the channel was closed on shutdown
+func (s *Store) publishChange(event UserChangeEvent) {
+	s.changesMutex.RLock()
+	defer s.changesMutex.RUnlock()
+
+	if s.changesClosed {
+		logger.Warn("Change event dropped, shutting down", "namespace", s.namespace, "user_id", event.UserID)
+		return
+	}
+	s.changes <- event
+}
+
+// CloseChanges closes the change channels of all stores so their listeners can flush the pending events and exit
+func CloseChanges() {
+	storesMutex.Lock()
+	defer storesMutex.Unlock()
+
+	for _, s := range stores {
+		s.changesMutex.Lock()
+		if !s.changesClosed {
+			s.changesClosed = true
+			close(s.changes)
+		}
+		s.changesMutex.Unlock()
+	}
+}
@@ -0,0 +1,108 @@
+package storage_db
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Messages of the verification a group can replace with its own text
+const (
+	TemplateWelcome = "welcome" // posted in the group when a member joins
+	TemplateVerify  = "verify"  // instructions above the verification button
+	TemplateSuccess = "success"
+	TemplateFailure = "failure"
+	TemplateTimeout = "timeout"
+)
+
+// TemplateKinds lists the messages a group can replace
+var TemplateKinds = []string{TemplateWelcome, TemplateVerify, TemplateSuccess, TemplateFailure, TemplateTimeout}
+
+// TemplatePlaceholders are replaced with the values of the member when a message is sent
+var TemplatePlaceholders = []string{"{username}", "{group}", "{requirement}", "{deadline}"}
+
+// Maximum length of a message template, Telegram messages are limited to 4096 characters
+const maxTemplateLength = 1000
+
+var placeholderPattern = regexp.MustCompile(`\{[A-Za-z_]+\}`)
+
+// ValidateMessageTemplate checks that the text of a message template can be sent
+func ValidateMessageTemplate(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("the message is empty")
+	}
+	if len([]rune(text)) > maxTemplateLength {
+		return fmt.Errorf("the message is longer than %d characters", maxTemplateLength)
+	}
+	for _, placeholder := range placeholderPattern.FindAllString(text, -1) {
+		if !slices.Contains(TemplatePlaceholders, placeholder) {
+			return fmt.Errorf("unknown placeholder %s, use %s", placeholder, strings.Join(TemplatePlaceholders, ", "))
+		}
+	}
+	return nil
+}
+
+// IsTemplateKind reports whether the group can replace the message
+func IsTemplateKind(kind string) bool {
+	return slices.Contains(TemplateKinds, kind)
+}
+
+// SetGroupTemplate sets the text of a message of the group, an empty text restores the default message
+func (s *Store) SetGroupTemplate(groupID int64, kind, text string) error {
+	if !IsTemplateKind(kind) {
+		return fmt.Errorf("unknown message %q", kind)
+	}
+	if text != "" {
+		if err := ValidateMessageTemplate(text); err != nil {
+			return err
+		}
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "VerificationParamsStore")
+		if bucket == nil {
+			return fmt.Errorf("bucket VerificationParamsStore not found")
+		}
+
+		data := bucket.Get(itob(groupID))
+		var groupConfig GroupVerificationConfig
+
+		if data != nil {
+			if err := json.Unmarshal(data, &groupConfig); err != nil {
+				return fmt.Errorf("error parsing JSON: %w", err)
+			}
+		} else {
+			// A new config has no active params yet
+			groupConfig.ActiveIndex = -1
+		}
+
+		if text == "" {
+			delete(groupConfig.Templates, kind)
+		} else {
+			if groupConfig.Templates == nil {
+				groupConfig.Templates = make(map[string]string)
+			}
+			groupConfig.Templates[kind] = text
+		}
+
+		encoded, err := json.Marshal(groupConfig)
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+
+		return bucket.Put(itob(groupID), encoded)
+	})
+}
+
+// GetGroupTemplate returns the text of a message of the group, or an empty string if the group uses the default one
+func (s *Store) GetGroupTemplate(groupID int64, kind string) string {
+	groupConfig, err := s.GetGroupConfigParams(groupID)
+	if err != nil {
+		return ""
+	}
+	return groupConfig.Templates[kind]
+}
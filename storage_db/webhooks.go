@@ -0,0 +1,219 @@
+package storage_db
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// WebhookSubscription is an outgoing webhook of a group
+type WebhookSubscription struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Wants reports whether the subscription receives the event type, no event types means all of them
+func (s WebhookSubscription) Wants(eventType string) bool {
+	return len(s.Events) == 0 || slices.Contains(s.Events, eventType)
+}
+
+// DeadLetter is a webhook delivery that failed after all retries
+type DeadLetter struct {
+	ID             uint64          `json:"id"`
+	GroupID        int64           `json:"groupId"`
+	SubscriptionID string          `json:"subscriptionId"`
+	URL            string          `json:"url"`
+	EventID        string          `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Attempts       int             `json:"attempts"`
+	LastError      string          `json:"lastError"`
+	FailedAt       time.Time       `json:"failedAt"`
+}
+
+// getWebhookSubscriptions reads the subscriptions of the group in the transaction
+func getWebhookSubscriptions(bucket *bolt.Bucket, groupID int64) ([]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+
+	if data := bucket.Get(itob(groupID)); data != nil {
+		if err := json.Unmarshal(data, &subscriptions); err != nil {
+			return nil, fmt.Errorf("error parsing JSON: %w", err)
+		}
+	}
+
+	return subscriptions, nil
+}
+
+// putWebhookSubscriptions saves the subscriptions of the group in the transaction
+func putWebhookSubscriptions(bucket *bolt.Bucket, groupID int64, subscriptions []WebhookSubscription) error {
+	if len(subscriptions) == 0 {
+		return bucket.Delete(itob(groupID))
+	}
+
+	encoded, err := json.Marshal(subscriptions)
+	if err != nil {
+		return fmt.Errorf("error encoding JSON: %w", err)
+	}
+
+	return bucket.Put(itob(groupID), encoded)
+}
+
+// AddWebhookSubscription stores a new subscription of the group and returns it with the generated ID
+func (s *Store) AddWebhookSubscription(groupID int64, subscription WebhookSubscription) (WebhookSubscription, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return WebhookSubscription{}, fmt.Errorf("error generating subscription ID: %w", err)
+	}
+	subscription.ID = hex.EncodeToString(b)
+	subscription.CreatedAt = time.Now()
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "WebhookSubscriptions")
+		if bucket == nil {
+			return fmt.Errorf("bucket WebhookSubscriptions not found")
+		}
+
+		subscriptions, err := getWebhookSubscriptions(bucket, groupID)
+		if err != nil {
+			return err
+		}
+
+		return putWebhookSubscriptions(bucket, groupID, append(subscriptions, subscription))
+	})
+	if err != nil {
+		return WebhookSubscription{}, err
+	}
+
+	return subscription, nil
+}
+
+// DeleteWebhookSubscription deletes the subscription of the group
+func (s *Store) DeleteWebhookSubscription(groupID int64, subscriptionID string) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "WebhookSubscriptions")
+		if bucket == nil {
+			return fmt.Errorf("bucket WebhookSubscriptions not found")
+		}
+
+		subscriptions, err := getWebhookSubscriptions(bucket, groupID)
+		if err != nil {
+			return err
+		}
+
+		count := len(subscriptions)
+		subscriptions = slices.DeleteFunc(subscriptions, func(s WebhookSubscription) bool { return s.ID == subscriptionID })
+		if len(subscriptions) == count {
+			return fmt.Errorf("webhook subscription %s %w", subscriptionID, ErrNotFound)
+		}
+
+		return putWebhookSubscriptions(bucket, groupID, subscriptions)
+	})
+}
+
+// GetWebhookSubscriptions returns the subscriptions of the group
+func (s *Store) GetWebhookSubscriptions(groupID int64) ([]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+
+	err := db.View(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "WebhookSubscriptions")
+		if bucket == nil {
+			return fmt.Errorf("bucket WebhookSubscriptions not found")
+		}
+
+		var err error
+		subscriptions, err = getWebhookSubscriptions(bucket, groupID)
+		return err
+	})
+
+	return subscriptions, err
+}
+
+// AddDeadLetter stores a failed delivery
+func (s *Store) AddDeadLetter(deadLetter DeadLetter) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "WebhookDeadLetters")
+		if bucket == nil {
+			return fmt.Errorf("bucket WebhookDeadLetters not found")
+		}
+
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		deadLetter.ID = id
+
+		encoded, err := json.Marshal(deadLetter)
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+
+		return bucket.Put(itob(int64(id)), encoded)
+	})
+}
+
+// GetDeadLetters returns the failed deliveries of the group, oldest first
+func (s *Store) GetDeadLetters(groupID int64) ([]DeadLetter, error) {
+	deadLetters := []DeadLetter{}
+
+	err := db.View(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "WebhookDeadLetters")
+		if bucket == nil {
+			return fmt.Errorf("bucket WebhookDeadLetters not found")
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var deadLetter DeadLetter
+			if err := json.Unmarshal(v, &deadLetter); err != nil {
+				return fmt.Errorf("error parsing JSON: %w", err)
+			}
+
+			if deadLetter.GroupID == groupID {
+				deadLetters = append(deadLetters, deadLetter)
+			}
+			return nil
+		})
+	})
+
+	return deadLetters, err
+}
+
+// TakeDeadLetter removes the failed delivery of the group and returns it, e.g. to deliver it again
+func (s *Store) TakeDeadLetter(groupID int64, id uint64) (DeadLetter, error) {
+	var deadLetter DeadLetter
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		bucket := s.bucket(tx, "WebhookDeadLetters")
+		if bucket == nil {
+			return fmt.Errorf("bucket WebhookDeadLetters not found")
+		}
+
+		data := bucket.Get(itob(int64(id)))
+		if data == nil {
+			return fmt.Errorf("dead letter %d %w", id, ErrNotFound)
+		}
+
+		if err := json.Unmarshal(data, &deadLetter); err != nil {
+			return fmt.Errorf("error parsing JSON: %w", err)
+		}
+
+		// Dead letters of other groups are hidden from the caller
+		if deadLetter.GroupID != groupID {
+			return fmt.Errorf("dead letter %d %w", id, ErrNotFound)
+		}
+
+		return bucket.Delete(itob(int64(id)))
+	})
+	if err != nil {
+		return DeadLetter{}, err
+	}
+
+	return deadLetter, nil
+}
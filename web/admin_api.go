@@ -0,0 +1,404 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+	"github.com/ArtemHvozdov/tg-auth-bot/webhooks"
+)
+
+// Maximum size of a request body of the admin API
+const maxAdminRequestSize = 1 << 20
+
+// adminHandler is a handler of the admin API that got a valid API key
+type adminHandler func(w http.ResponseWriter, r *http.Request, apiKey storage_db.APIKey)
+
+// groupHandler is a handler of the admin API for one group the API key has access to
+type groupHandler func(w http.ResponseWriter, r *http.Request, groupID int64)
+
+// groupSummary is an entry of the groups list
+type groupSummary struct {
+	GroupID             int64  `json:"groupId"`
+	Configured          bool   `json:"configured"`
+	ParamsCount         int    `json:"paramsCount"`
+	ActiveIndex         int    `json:"activeIndex"`
+	RestrictionType     string `json:"restrictionType,omitempty"`
+	VerificationTimeout int    `json:"verificationTimeout,omitempty"`
+}
+
+// verifiedUser is an entry of the verified users list
+type verifiedUser struct {
+	UserID            int64    `json:"userId"`
+	UserName          string   `json:"userName"`
+	TypesVerification []string `json:"typesVerification"`
+}
+
+// registerAdminAPI adds the routes of the admin REST API
+func registerAdminAPI(routes *http.ServeMux) {
+	routes.HandleFunc("GET /api/admin/groups", requireAPIKey(listGroups))
+	routes.HandleFunc("GET /api/admin/groups/{group}", requireGroup(getGroupConfig))
+	routes.HandleFunc("GET /api/admin/groups/{group}/params", requireGroup(listParams))
+	routes.HandleFunc("POST /api/admin/groups/{group}/params", requireGroup(createParams))
+	routes.HandleFunc("GET /api/admin/groups/{group}/params/{index}", requireGroup(getParams))
+	routes.HandleFunc("PUT /api/admin/groups/{group}/params/{index}", requireGroup(updateParams))
+	routes.HandleFunc("DELETE /api/admin/groups/{group}/params/{index}", requireGroup(deleteParams))
+	routes.HandleFunc("PUT /api/admin/groups/{group}/active", requireGroup(setActiveParams))
+	routes.HandleFunc("PUT /api/admin/groups/{group}/restriction", requireGroup(setRestriction))
+	routes.HandleFunc("GET /api/admin/groups/{group}/verified-users", requireGroup(listVerifiedUsers))
+	routes.HandleFunc("DELETE /api/admin/groups/{group}/verified-users/{user}", requireGroup(removeVerifiedUser))
+	routes.HandleFunc("GET /api/admin/groups/{group}/webhooks", requireGroup(listWebhooks))
+	routes.HandleFunc("POST /api/admin/groups/{group}/webhooks", requireGroup(createWebhook))
+	routes.HandleFunc("DELETE /api/admin/groups/{group}/webhooks/{id}", requireGroup(deleteWebhook))
+	routes.HandleFunc("GET /api/admin/groups/{group}/webhooks/dead-letters", requireGroup(listDeadLetters))
+	routes.HandleFunc("POST /api/admin/groups/{group}/webhooks/dead-letters/{id}/redeliver", requireGroup(redeliverDeadLetter))
+	routes.HandleFunc("DELETE /api/admin/groups/{group}/webhooks/dead-letters/{id}", requireGroup(deleteDeadLetter))
+}
+
+// requireAPIKey authenticates the request by the "Authorization: Bearer <key>" header
+func requireAPIKey(next adminHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || key == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSONError(w, "API key required", http.StatusUnauthorized)
+			return
+		}
+
+		apiKey, err := tenantOf(r).store().FindAPIKey(strings.TrimSpace(key))
+		if errors.Is(err, storage_db.ErrNotFound) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSONError(w, "Invalid API key", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			requestLogger(r).Error("Error looking up API key", "error", err)
+			writeJSONError(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		next(w, r, apiKey)
+	}
+}
+
+// requireGroup checks that the API key has access to the group from the path
+func requireGroup(next groupHandler) http.HandlerFunc {
+	return requireAPIKey(func(w http.ResponseWriter, r *http.Request, apiKey storage_db.APIKey) {
+		groupID, err := strconv.ParseInt(r.PathValue("group"), 10, 64)
+		if err != nil {
+			writeJSONError(w, "Invalid group ID", http.StatusBadRequest)
+			return
+		}
+
+		if !apiKey.HasGroup(groupID) {
+			writeJSONError(w, "No access to the group", http.StatusForbidden)
+			return
+		}
+
+		// Admins lose the access as soon as they are demoted in the group
+		if telegram := tenantOf(r).telegram; telegram != nil && !telegram.IsGroupAdmin(groupID, apiKey.UserID) {
+			writeJSONError(w, "You are not an administrator of the group", http.StatusForbidden)
+			return
+		}
+
+		next(w, r, groupID)
+	})
+}
+
+// listGroups returns the groups the API key has access to
+func listGroups(w http.ResponseWriter, r *http.Request, apiKey storage_db.APIKey) {
+	store := tenantOf(r).store()
+	groups := make([]groupSummary, 0, len(apiKey.GroupIDs))
+
+	for _, groupID := range apiKey.GroupIDs {
+		summary := groupSummary{GroupID: groupID, ActiveIndex: -1}
+
+		groupConfig, err := store.GetGroupConfigParams(groupID)
+		if err == nil {
+			summary.Configured = true
+			summary.ParamsCount = len(groupConfig.VerificationParams)
+			summary.ActiveIndex = groupConfig.ActiveIndex
+			summary.RestrictionType = groupConfig.RestrictionType
+			summary.VerificationTimeout = groupConfig.VerificationTimeout
+		} else if !errors.Is(err, storage_db.ErrNotFound) {
+			writeStorageError(w, r, err)
+			return
+		}
+
+		groups = append(groups, summary)
+	}
+
+	writeJSON(w, http.StatusOK, groups)
+}
+
+// getGroupConfig returns the whole verification config of the group
+func getGroupConfig(w http.ResponseWriter, r *http.Request, groupID int64) {
+	store := tenantOf(r).store()
+	groupConfig, err := store.GetGroupConfigParams(groupID)
+	if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, groupConfig)
+}
+
+// listParams returns the verification params of the group
+func listParams(w http.ResponseWriter, r *http.Request, groupID int64) {
+	store := tenantOf(r).store()
+	groupConfig, err := store.GetGroupConfigParams(groupID)
+	if errors.Is(err, storage_db.ErrNotFound) {
+		writeJSON(w, http.StatusOK, []storage_db.VerificationParams{})
+		return
+	}
+	if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, groupConfig.VerificationParams)
+}
+
+// createParams adds verification params to the group, the first params become active
+func createParams(w http.ResponseWriter, r *http.Request, groupID int64) {
+	store := tenantOf(r).store()
+	var params storage_db.VerificationParams
+	if !readParams(w, r, &params) {
+		return
+	}
+
+	if err := store.SaveVerificationParams(groupID, params); err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	groupConfig, err := store.GetGroupConfigParams(groupID)
+	if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"index":  len(groupConfig.VerificationParams) - 1,
+		"params": params,
+	})
+}
+
+// getParams returns the verification params at the index
+func getParams(w http.ResponseWriter, r *http.Request, groupID int64) {
+	store := tenantOf(r).store()
+	index, ok := paramsIndex(w, r)
+	if !ok {
+		return
+	}
+
+	groupConfig, err := store.GetGroupConfigParams(groupID)
+	if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	if index >= len(groupConfig.VerificationParams) {
+		writeJSONError(w, "Verification params not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, groupConfig.VerificationParams[index])
+}
+
+// updateParams replaces the verification params at the index
+func updateParams(w http.ResponseWriter, r *http.Request, groupID int64) {
+	store := tenantOf(r).store()
+	index, ok := paramsIndex(w, r)
+	if !ok {
+		return
+	}
+
+	var params storage_db.VerificationParams
+	if !readParams(w, r, &params) {
+		return
+	}
+
+	if err := store.UpdateVerificationParams(groupID, index, params); err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, params)
+}
+
+// deleteParams deletes the verification params at the index
+func deleteParams(w http.ResponseWriter, r *http.Request, groupID int64) {
+	store := tenantOf(r).store()
+	index, ok := paramsIndex(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := store.DeleteVerificationParams(groupID, index); err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setActiveParams switches the params new members are verified with
+func setActiveParams(w http.ResponseWriter, r *http.Request, groupID int64) {
+	store := tenantOf(r).store()
+	var body struct {
+		Index *int `json:"index"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Index == nil {
+		writeJSONError(w, "'index' is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := store.SetActiveVerificationParams(groupID, *body.Index); err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{"activeIndex": *body.Index})
+}
+
+// setRestriction sets what happens to members who don't pass the verification
+func setRestriction(w http.ResponseWriter, r *http.Request, groupID int64) {
+	store := tenantOf(r).store()
+	var body struct {
+		RestrictionType string `json:"restrictionType"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if !storage_db.IsValidRestrictionType(body.RestrictionType) {
+		writeJSONError(w, "'restrictionType' must be 'block' or 'delete'", http.StatusBadRequest)
+		return
+	}
+
+	if err := store.AddRestrictionType(groupID, body.RestrictionType); err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"restrictionType": body.RestrictionType})
+}
+
+// listVerifiedUsers returns the verified members of the group, their auth tokens are not exposed
+func listVerifiedUsers(w http.ResponseWriter, r *http.Request, groupID int64) {
+	store := tenantOf(r).store()
+	// The group is missing until the first member passes the verification
+	users, _ := store.GetVerifiedUsersList(groupID)
+
+	result := make([]verifiedUser, 0, len(users))
+	for _, user := range users {
+		result = append(result, verifiedUser{
+			UserID:            user.User.ID,
+			UserName:          user.User.UserName,
+			TypesVerification: user.TypesVerification,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// removeVerifiedUser removes a member from the verified users of the group
+func removeVerifiedUser(w http.ResponseWriter, r *http.Request, groupID int64) {
+	userID, err := strconv.ParseInt(r.PathValue("user"), 10, 64)
+	if err != nil {
+		writeJSONError(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if !revokeVerifiedUser(tenantOf(r).store(), groupID, userID) {
+		writeJSONError(w, "Verified user not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeVerifiedUser removes the member from the verified users and notifies the webhooks,
+// it reports whether the member was verified
+func revokeVerifiedUser(store *storage_db.Store, groupID int64, userID int64) bool {
+	users, _ := store.GetVerifiedUsersList(groupID)
+	for _, user := range users {
+		if user.User.ID != userID {
+			continue
+		}
+
+		store.RemoveVerifiedUser(groupID, userID)
+		webhooks.Publish(store, webhooks.Event{
+			Type:     webhooks.EventVerificationRevoked,
+			GroupID:  groupID,
+			UserID:   userID,
+			Username: user.User.UserName,
+		})
+		return true
+	}
+
+	return false
+}
+
+// paramsIndex parses the index of the params from the path
+func paramsIndex(w http.ResponseWriter, r *http.Request) (int, bool) {
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil || index < 0 {
+		writeJSONError(w, "Invalid params index", http.StatusBadRequest)
+		return 0, false
+	}
+	return index, true
+}
+
+// readParams reads and validates verification params from the request body
+func readParams(w http.ResponseWriter, r *http.Request, params *storage_db.VerificationParams) bool {
+	if !readJSON(w, r, params) {
+		return false
+	}
+
+	if err := storage_db.ValidateVerificationParams(*params); err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// readJSON decodes the request body, unknown fields are rejected to catch typos
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxAdminRequestSize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		writeJSONError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeJSON writes v as the JSON response
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Warn("Error writing response", "error", err)
+	}
+}
+
+// writeStorageError maps the errors of storage_db to the HTTP status
+func writeStorageError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, storage_db.ErrNotFound) {
+		writeJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	requestLogger(r).Error("Storage error", "error", err)
+	writeJSONError(w, "Internal server error", http.StatusInternalServerError)
+}
@@ -0,0 +1,157 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+	"github.com/ArtemHvozdov/tg-auth-bot/webhooks"
+)
+
+// webhookSubscription is a subscription in the responses, the secret is only returned on creation
+type webhookSubscription struct {
+	storage_db.WebhookSubscription
+	Secret string `json:"secret,omitempty"`
+}
+
+// listWebhooks returns the outgoing webhook subscriptions of the group
+func listWebhooks(w http.ResponseWriter, r *http.Request, groupID int64) {
+	store := tenantOf(r).store()
+	subscriptions, err := store.GetWebhookSubscriptions(groupID)
+	if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	result := make([]webhookSubscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		result = append(result, webhookSubscription{WebhookSubscription: subscription})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// createWebhook subscribes a URL to events of the group, a secret is generated if none is given
+func createWebhook(w http.ResponseWriter, r *http.Request, groupID int64) {
+	store := tenantOf(r).store()
+	var body struct {
+		URL    string   `json:"url"`
+		Secret string   `json:"secret"`
+		Events []string `json:"events"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	target, err := url.Parse(body.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		writeJSONError(w, "'url' must be an absolute http(s) URL", http.StatusBadRequest)
+		return
+	}
+
+	if err := webhooks.CheckURL(r.Context(), target); err != nil {
+		if errors.Is(err, webhooks.ErrForbiddenAddress) {
+			writeJSONError(w, "'url' must point to a public address", http.StatusBadRequest)
+		} else {
+			writeJSONError(w, "'url' host can't be resolved", http.StatusBadRequest)
+		}
+		return
+	}
+
+	for _, eventType := range body.Events {
+		if !webhooks.IsEventType(eventType) {
+			writeJSONError(w, "Unknown event type '"+eventType+"'", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if body.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			requestLogger(r).Error("Error generating webhook secret", "error", err)
+			writeJSONError(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		body.Secret = hex.EncodeToString(b)
+	}
+
+	subscription, err := store.AddWebhookSubscription(groupID, storage_db.WebhookSubscription{
+		URL:    body.URL,
+		Secret: body.Secret,
+		Events: body.Events,
+	})
+	if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, webhookSubscription{WebhookSubscription: subscription, Secret: subscription.Secret})
+}
+
+// deleteWebhook deletes a subscription of the group
+func deleteWebhook(w http.ResponseWriter, r *http.Request, groupID int64) {
+	store := tenantOf(r).store()
+	if err := store.DeleteWebhookSubscription(groupID, r.PathValue("id")); err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listDeadLetters returns the deliveries of the group that failed after all retries
+func listDeadLetters(w http.ResponseWriter, r *http.Request, groupID int64) {
+	store := tenantOf(r).store()
+	deadLetters, err := store.GetDeadLetters(groupID)
+	if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deadLetters)
+}
+
+// redeliverDeadLetter queues a failed delivery again
+func redeliverDeadLetter(w http.ResponseWriter, r *http.Request, groupID int64) {
+	id, ok := deadLetterID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := webhooks.Redeliver(tenantOf(r).store(), groupID, id); err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// deleteDeadLetter drops a failed delivery
+func deleteDeadLetter(w http.ResponseWriter, r *http.Request, groupID int64) {
+	store := tenantOf(r).store()
+	id, ok := deadLetterID(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := store.TakeDeadLetter(groupID, id); err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deadLetterID parses the ID of the dead letter from the path
+func deadLetterID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeJSONError(w, "Invalid dead letter ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
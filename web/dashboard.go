@@ -0,0 +1,342 @@
+package web
+
+import (
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+)
+
+var dashboardTemplates = template.Must(template.New("").Funcs(template.FuncMap{
+	"formatTime": func(t time.Time) string {
+		if t.IsZero() {
+			return "unknown"
+		}
+		return t.Local().Format("2006-01-02 15:04")
+	},
+	"minutes": func(d time.Duration) int {
+		return int(d.Minutes())
+	},
+}).ParseFS(templatesFS, "templates/admin_*.html"))
+
+// Texts of the failure reasons
+var failureReasonTexts = map[string]string{
+	storage_db.FailureProof:   "Proof verification failed",
+	storage_db.FailureTimeout: "Timed out",
+}
+
+// Notices shown after the actions, the page gets only their keys
+var dashboardNotices = map[string]string{
+	"active":      "The active verification params were changed.",
+	"restriction": "The restriction type was changed.",
+	"revoked":     "The user was removed from the verified users.",
+}
+
+// dashboardHandler is a dashboard page of a signed in admin
+type dashboardHandler func(w http.ResponseWriter, r *http.Request, userID int64, session string)
+
+// dashboardGroupHandler is a dashboard page of a group the signed in admin manages
+type dashboardGroupHandler func(w http.ResponseWriter, r *http.Request, groupID int64, session string)
+
+// Data of the dashboard templates
+
+type loginPageData struct {
+	Base        string // path prefix of the bot
+	BotUsername string
+	AuthURL     string
+	Error       string
+}
+
+type groupsPageData struct {
+	Base   string
+	CSRF   string
+	Groups []dashboardGroup
+}
+
+type dashboardGroup struct {
+	ID    int64
+	Title string
+}
+
+type groupPageData struct {
+	Base            string
+	CSRF            string
+	Group           dashboardGroup
+	Notice          string
+	Params          []dashboardParams
+	RestrictionType string
+	Timeout         time.Duration
+	Pending         []dashboardPendingUser
+	Verified        []storage_db.VerifiedUser
+	Failures        []dashboardFailure
+}
+
+type dashboardParams struct {
+	Index       int
+	Number      int // shown to admins, counts from 1 like in the bot
+	Name        string
+	Type        string
+	Description string
+	Active      bool
+}
+
+type dashboardPendingUser struct {
+	UserID   int64
+	Username string
+	JoinedAt time.Time
+	// Deadline in Unix milliseconds for the countdown, 0 if the join time is unknown
+	Deadline int64
+}
+
+type dashboardFailure struct {
+	UserID   int64
+	Username string
+	Reason   string
+	FailedAt time.Time
+}
+
+// registerDashboard adds the routes of the admin dashboard
+func registerDashboard(routes *http.ServeMux) {
+	routes.HandleFunc("GET /admin", requireDashboardUser(groupsPage))
+	routes.HandleFunc("GET /admin/login", dashboardLogin)
+	routes.HandleFunc("POST /admin/logout", dashboardLogout)
+	routes.HandleFunc("GET /admin/groups/{group}", requireDashboardGroup(groupPage))
+	routes.HandleFunc("POST /admin/groups/{group}/active", requireDashboardGroup(dashboardSetActive))
+	routes.HandleFunc("POST /admin/groups/{group}/restriction", requireDashboardGroup(dashboardSetRestriction))
+	routes.HandleFunc("POST /admin/groups/{group}/verified-users/{user}/remove", requireDashboardGroup(dashboardRemoveVerifiedUser))
+}
+
+// requireDashboardUser shows the login page to visitors who are not signed in
+func requireDashboardUser(next dashboardHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		telegram := tenantOf(r).telegram
+		if telegram == nil {
+			http.Error(w, "The dashboard is not available", http.StatusServiceUnavailable)
+			return
+		}
+
+		userID, session, ok := dashboardUser(r)
+		if !ok {
+			renderLoginPage(w, r, "")
+			return
+		}
+
+		// Forms are checked here, so the actions can't be triggered from other sites
+		if r.Method == http.MethodPost && !checkCSRF(r, session) {
+			http.Error(w, "The form has expired, please reload the page", http.StatusForbidden)
+			return
+		}
+
+		next(w, r, userID, session)
+	}
+}
+
+// requireDashboardGroup checks that the signed in admin administers the group from the path
+func requireDashboardGroup(next dashboardGroupHandler) http.HandlerFunc {
+	return requireDashboardUser(func(w http.ResponseWriter, r *http.Request, userID int64, session string) {
+		groupID, err := strconv.ParseInt(r.PathValue("group"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid group ID", http.StatusBadRequest)
+			return
+		}
+
+		if !tenantOf(r).telegram.IsGroupAdmin(groupID, userID) {
+			http.Error(w, "You are not an administrator of this group", http.StatusForbidden)
+			return
+		}
+
+		next(w, r, groupID, session)
+	})
+}
+
+// renderLoginPage shows the Telegram Login Widget
+func renderLoginPage(w http.ResponseWriter, r *http.Request, loginError string) {
+	telegram := tenantOf(r).telegram
+	renderDashboard(w, "admin_login", loginPageData{
+		Base:        tenantOf(r).path(""),
+		BotUsername: telegram.BotUsername(),
+		AuthURL:     requestScheme(r) + "://" + r.Host + tenantOf(r).path("/admin/login"),
+		Error:       loginError,
+	})
+}
+
+// dashboardLogin is the auth URL of the Telegram Login Widget
+func dashboardLogin(w http.ResponseWriter, r *http.Request) {
+	telegram := tenantOf(r).telegram
+	if telegram == nil {
+		http.Error(w, "The dashboard is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	userID, err := checkLoginWidget(r.URL.Query(), telegram.BotToken())
+	if err != nil {
+		requestLogger(r).Warn("Dashboard login rejected", "error", err)
+		renderLoginPage(w, r, "Telegram login failed, please try again.")
+		return
+	}
+
+	setDashboardSession(w, r, userID)
+	http.Redirect(w, r, tenantOf(r).path("/admin"), http.StatusSeeOther)
+}
+
+// dashboardLogout signs the admin out
+func dashboardLogout(w http.ResponseWriter, r *http.Request) {
+	clearDashboardSession(w, r)
+	http.Redirect(w, r, tenantOf(r).path("/admin"), http.StatusSeeOther)
+}
+
+// groupsPage lists the groups the admin manages
+func groupsPage(w http.ResponseWriter, r *http.Request, userID int64, session string) {
+	telegram := tenantOf(r).telegram
+	store := tenantOf(r).store()
+	groupIDs, err := store.ListConfiguredGroups()
+	if err != nil {
+		requestLogger(r).Error("Error listing groups", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// The group the admin is setting up may have no config yet
+	if setupGroupID, err := store.GetIdGroupFromGroupSetupState(userID); err == nil && setupGroupID != 0 && !slices.Contains(groupIDs, setupGroupID) {
+		groupIDs = append(groupIDs, setupGroupID)
+	}
+
+	data := groupsPageData{Base: tenantOf(r).path(""), CSRF: csrfToken(telegram, session)}
+	for _, groupID := range groupIDs {
+		if telegram.IsGroupAdmin(groupID, userID) {
+			data.Groups = append(data.Groups, dashboardGroup{ID: groupID, Title: telegram.GroupTitle(groupID)})
+		}
+	}
+
+	renderDashboard(w, "admin_groups", data)
+}
+
+// groupPage shows the config, the members and the recent failures of the group
+func groupPage(w http.ResponseWriter, r *http.Request, groupID int64, session string) {
+	telegram := tenantOf(r).telegram
+	store := tenantOf(r).store()
+	data := groupPageData{
+		Base:    tenantOf(r).path(""),
+		CSRF:    csrfToken(telegram, session),
+		Group:   dashboardGroup{ID: groupID, Title: telegram.GroupTitle(groupID)},
+		Notice:  dashboardNotices[r.URL.Query().Get("done")],
+		Timeout: store.GetVerificationTimeout(groupID),
+	}
+
+	if groupConfig, err := store.GetGroupConfigParams(groupID); err == nil {
+		data.RestrictionType = groupConfig.RestrictionType
+		for i, params := range groupConfig.VerificationParams {
+			data.Params = append(data.Params, dashboardParams{
+				Index:       i,
+				Number:      i + 1,
+				Name:        params.DisplayName(),
+				Type:        params.Type(),
+				Description: params.Description,
+				Active:      i == groupConfig.ActiveIndex,
+			})
+		}
+	}
+
+	pending, err := store.GetPendingUsers(groupID)
+	if err != nil {
+		requestLogger(r).Error("Error getting pending users", "group_id", groupID, "error", err)
+	}
+	for _, user := range pending {
+		pendingUser := dashboardPendingUser{UserID: user.UserID, Username: user.Username, JoinedAt: user.JoinedAt}
+		if !user.JoinedAt.IsZero() {
+			pendingUser.Deadline = user.JoinedAt.Add(data.Timeout).UnixMilli()
+		}
+		data.Pending = append(data.Pending, pendingUser)
+	}
+
+	// The list is missing until the first member passes the verification
+	data.Verified, _ = store.GetVerifiedUsersList(groupID)
+
+	failures, err := store.GetRecentFailures(groupID)
+	if err != nil {
+		requestLogger(r).Error("Error getting failures", "group_id", groupID, "error", err)
+	}
+	for _, failure := range failures {
+		reason, ok := failureReasonTexts[failure.Reason]
+		if !ok {
+			reason = failure.Reason
+		}
+		data.Failures = append(data.Failures, dashboardFailure{
+			UserID:   failure.UserID,
+			Username: failure.Username,
+			Reason:   reason,
+			FailedAt: failure.FailedAt,
+		})
+	}
+
+	renderDashboard(w, "admin_group", data)
+}
+
+// dashboardSetActive switches the active verification params
+func dashboardSetActive(w http.ResponseWriter, r *http.Request, groupID int64, session string) {
+	store := tenantOf(r).store()
+	index, err := strconv.Atoi(r.PostFormValue("index"))
+	if err != nil {
+		http.Error(w, "Invalid params index", http.StatusBadRequest)
+		return
+	}
+
+	if err := store.SetActiveVerificationParams(groupID, index); err != nil {
+		requestLogger(r).Warn("Error setting active params", "group_id", groupID, "error", err)
+		http.Error(w, "Failed to change the active params: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	redirectToGroup(w, r, groupID, "active")
+}
+
+// dashboardSetRestriction changes the restriction type of the group
+func dashboardSetRestriction(w http.ResponseWriter, r *http.Request, groupID int64, session string) {
+	store := tenantOf(r).store()
+	restrictionType := r.PostFormValue("restrictionType")
+	if !storage_db.IsValidRestrictionType(restrictionType) {
+		http.Error(w, "Unknown restriction type", http.StatusBadRequest)
+		return
+	}
+
+	if err := store.AddRestrictionType(groupID, restrictionType); err != nil {
+		requestLogger(r).Error("Error setting restriction type", "group_id", groupID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	redirectToGroup(w, r, groupID, "restriction")
+}
+
+// dashboardRemoveVerifiedUser removes a member from the verified users
+func dashboardRemoveVerifiedUser(w http.ResponseWriter, r *http.Request, groupID int64, session string) {
+	userID, err := strconv.ParseInt(r.PathValue("user"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if !revokeVerifiedUser(tenantOf(r).store(), groupID, userID) {
+		http.Error(w, "Verified user not found", http.StatusNotFound)
+		return
+	}
+
+	redirectToGroup(w, r, groupID, "revoked")
+}
+
+// redirectToGroup shows the group page with the notice after an action
+func redirectToGroup(w http.ResponseWriter, r *http.Request, groupID int64, notice string) {
+	http.Redirect(w, r, tenantOf(r).path("/admin/groups/")+strconv.FormatInt(groupID, 10)+"?done="+notice, http.StatusSeeOther)
+}
+
+// renderDashboard executes the dashboard template
+func renderDashboard(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := dashboardTemplates.ExecuteTemplate(w, name, data); err != nil {
+		logger.Error("Error rendering dashboard", "template", name, "error", err)
+	}
+}
@@ -0,0 +1,153 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Name of the cookie with the dashboard session
+	dashboardCookie = "tgab_admin"
+	// How long an admin stays signed in to the dashboard
+	dashboardSessionTTL = 12 * time.Hour
+	// How old the data of the Telegram Login Widget may be
+	loginMaxAge = 24 * time.Hour
+)
+
+// checkLoginWidget verifies the data the Telegram Login Widget passed to the auth URL and returns the user ID.
+// See https://core.telegram.org/widgets/login#checking-authorization
+func checkLoginWidget(values url.Values, botToken string) (int64, error) {
+	hash := values.Get("hash")
+	if hash == "" {
+		return 0, errors.New("hash is missing")
+	}
+
+	// All fields except the hash, sorted and joined with line breaks
+	fields := make([]string, 0, len(values))
+	for key := range values {
+		if key != "hash" {
+			fields = append(fields, key+"="+values.Get(key))
+		}
+	}
+	sort.Strings(fields)
+
+	secret := sha256.Sum256([]byte(botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(fields, "\n")))
+	if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(hash)) {
+		return 0, errors.New("invalid hash")
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil || time.Since(time.Unix(authDate, 0)) > loginMaxAge {
+		return 0, errors.New("login data is outdated")
+	}
+
+	userID, err := strconv.ParseInt(values.Get("id"), 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid user ID")
+	}
+
+	return userID, nil
+}
+
+// dashboardSign signs the value with a key derived from the bot token, so sessions survive restarts
+// and aren't valid for the other bots
+func dashboardSign(telegram Telegram, value string) string {
+	key := sha256.Sum256([]byte("dashboard:" + telegram.BotToken()))
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// setDashboardSession signs the admin in
+func setDashboardSession(w http.ResponseWriter, r *http.Request, userID int64) {
+	expires := time.Now().Add(dashboardSessionTTL)
+	value := fmt.Sprintf("%d.%d", userID, expires.Unix())
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     dashboardCookie,
+		Value:    value + "." + dashboardSign(tenantOf(r).telegram, value),
+		Path:     tenantOf(r).path("/admin"),
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   requestScheme(r) == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearDashboardSession signs the admin out
+func clearDashboardSession(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     dashboardCookie,
+		Value:    "",
+		Path:     tenantOf(r).path("/admin"),
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
+
+// dashboardUser returns the signed in admin and the session cookie value
+func dashboardUser(r *http.Request) (int64, string, bool) {
+	telegram := tenantOf(r).telegram
+	if telegram == nil {
+		return 0, "", false
+	}
+
+	cookie, err := r.Cookie(dashboardCookie)
+	if err != nil {
+		return 0, "", false
+	}
+
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 {
+		return 0, "", false
+	}
+
+	value := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(dashboardSign(telegram, value)), []byte(parts[2])) {
+		return 0, "", false
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return 0, "", false
+	}
+
+	userID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+
+	return userID, cookie.Value, true
+}
+
+// csrfToken returns the token the forms of the session must send back
+func csrfToken(telegram Telegram, session string) string {
+	return dashboardSign(telegram, "csrf:"+session)
+}
+
+// checkCSRF verifies the token of a submitted form
+func checkCSRF(r *http.Request, session string) bool {
+	return hmac.Equal([]byte(r.PostFormValue("csrf")), []byte(csrfToken(tenantOf(r).telegram, session)))
+}
+
+// requestScheme returns the scheme the client used, also behind a proxy such as ngrok
+func requestScheme(r *http.Request) string {
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		return proto
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,164 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/auth"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+)
+
+const (
+	// How long a probe waits for a single check
+	healthCheckTimeout = 5 * time.Second
+	// How long the results of the remote checks are reused, so frequent probes don't call Telegram and the RPC nodes each time
+	remoteCheckTTL = 30 * time.Second
+)
+
+// healthCheck is a dependency checked by the probes
+type healthCheck struct {
+	name string
+	// ttl of the cached result, 0 runs the check on every probe
+	ttl time.Duration
+	run func(ctx context.Context) error
+
+	mu     sync.Mutex
+	result checkResult
+}
+
+// checkResult is the result of a check in the JSON breakdown
+type checkResult struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Cached    bool      `json:"cached,omitempty"`
+}
+
+// healthReport is the response of /healthz and /readyz
+type healthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+// Checks of the local dependencies for /healthz, /readyz adds the remote ones: the bots added by AddTenant
+var livenessChecks, remoteChecks []*healthCheck
+
+var (
+	// resolverChecks are created for the resolvers of the current config, a reload can add or remove them
+	resolverChecks      = map[string]*healthCheck{}
+	resolverChecksMutex sync.Mutex
+)
+
+// registerHealth adds the liveness and readiness probes
+func registerHealth() {
+	livenessChecks = []*healthCheck{
+		{name: "database", run: func(ctx context.Context) error {
+			return storage_db.CheckDB()
+		}},
+		{name: "verifier", run: func(ctx context.Context) error {
+			return auth.CheckVerifier()
+		}},
+	}
+
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealthReport(w, r, livenessChecks)
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		checks := append(append([]*healthCheck{}, livenessChecks...), remoteChecks...)
+		writeHealthReport(w, r, append(checks, currentResolverChecks()...))
+	})
+}
+
+// currentResolverChecks returns the checks of the configured resolvers, the cached results of a resolver are kept
+// across reloads unless its RPC URL changed
+func currentResolverChecks() []*healthCheck {
+	resolverChecksMutex.Lock()
+	defer resolverChecksMutex.Unlock()
+
+	var checks []*healthCheck
+	current := map[string]*healthCheck{}
+	for _, resolver := range auth.Resolvers() {
+		key := resolver.Name + " " + resolver.RPCURL
+		check, ok := resolverChecks[key]
+		if !ok {
+			name := resolver.Name
+			check = &healthCheck{name: "resolver:" + name, ttl: remoteCheckTTL, run: func(ctx context.Context) error {
+				return auth.CheckResolver(ctx, name)
+			}}
+		}
+		current[key] = check
+		checks = append(checks, check)
+	}
+	resolverChecks = current
+	return checks
+}
+
+// writeHealthReport runs the checks in parallel, the status is 503 if any of them fails
+func writeHealthReport(w http.ResponseWriter, r *http.Request, checks []*healthCheck) {
+	report := healthReport{Status: "ok", Checks: make(map[string]checkResult, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := check.check(r.Context())
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.name] = result
+			if result.Status != "ok" {
+				report.Status = "fail"
+			}
+		}()
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	if report.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, code, report)
+}
+
+// check returns the cached result if it's fresh, otherwise runs the check.
+// Probes arriving during a check wait for its result instead of starting another one.
+func (c *healthCheck) check(ctx context.Context) checkResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ttl > 0 && !c.result.CheckedAt.IsZero() && time.Since(c.result.CheckedAt) < c.ttl {
+		result := c.result
+		result.Cached = true
+		return result
+	}
+
+	// A probe that gave up must not leave a failure in the cache
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), healthCheckTimeout)
+	defer cancel()
+
+	// Not every check takes a context, so the timeout is applied here as well
+	done := make(chan error, 1)
+	go func() {
+		done <- c.run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	c.result = checkResult{Status: "ok", CheckedAt: time.Now()}
+	if err != nil {
+		c.result.Status = "fail"
+		c.result.Error = err.Error()
+	}
+	return c.result
+}
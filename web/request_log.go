@@ -0,0 +1,62 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/logging"
+)
+
+// Request IDs accepted from a proxy in front of the server
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// withRequestLog gives every request an ID, it's added to all log records of the request and returned in X-Request-ID
+func withRequestLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+
+		ctx := logging.WithAttrs(r.Context(), "request_id", requestID, "method", r.Method, "path", r.URL.Path)
+		r = r.WithContext(ctx)
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		requestLogger(r).Debug("Request handled", "status", recorder.status, "duration", time.Since(start))
+	})
+}
+
+// requestLogger returns the logger with the correlation IDs of the request
+func requestLogger(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context(), logger)
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// statusRecorder keeps the status code of the response for the log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the flusher and the deadlines of the connection
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,120 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/auth"
+)
+
+// How often an idle event stream sends a comment so proxies don't close it
+const sseKeepAliveInterval = 15 * time.Second
+
+// SessionJSON returns the state of a verification session
+func SessionJSON(w http.ResponseWriter, r *http.Request) {
+	session, ok := tenantOf(r).auth.GetSession(r.PathValue("id"))
+	if !ok {
+		writeJSONError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(session); err != nil {
+		requestLogger(r).Warn("Error writing response", "session_id", session.ID, "error", err)
+	}
+}
+
+// SessionEvents streams the state changes of a verification session as Server-Sent Events.
+// The current state is sent first, the stream ends after the verified or failed state.
+func SessionEvents(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("id")
+
+	// Subscribe before reading the state, so a change between the two isn't lost
+	updates, unsubscribe := auth.SubscribeSession(sessionID)
+	defer unsubscribe()
+
+	session, ok := tenantOf(r).auth.GetSession(sessionID)
+	if !ok {
+		writeJSONError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	// The stream lives longer than the write timeout of the server
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		requestLogger(r).Warn("Error clearing write deadline", "session_id", sessionID, "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	if err := writeSessionEvent(w, rc, session); err != nil || session.IsFinal() {
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case session = <-updates:
+			if err := writeSessionEvent(w, rc, session); err != nil || session.IsFinal() {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// SignInRequest returns the authorization request of a session, the wallets fetch it by the request_uri link
+func SignInRequest(w http.ResponseWriter, r *http.Request) {
+	session, ok := tenantOf(r).auth.GetSession(r.PathValue("session"))
+	if !ok {
+		writeJSONError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	// A finished session doesn't accept proofs anymore
+	if session.IsFinal() {
+		writeJSONError(w, "Session is already "+session.Status, http.StatusGone)
+		return
+	}
+
+	// The web wallet fetches the request from its own origin
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(session.Request); err != nil {
+		requestLogger(r).Warn("Error writing response", "session_id", session.ID, "error", err)
+	}
+}
+
+// writeSessionEvent writes one "status" event with the session state
+func writeSessionEvent(w http.ResponseWriter, rc *http.ResponseController, session auth.Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: status\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
+
+// writeJSONError writes an error in the same JSON form as the other API responses
+func writeJSONError(w http.ResponseWriter, message string, code int) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
@@ -0,0 +1,15 @@
+package web
+
+// Telegram is what the web server needs from the bot
+type Telegram interface {
+	// IsGroupAdmin checks that the user administers the group right now
+	IsGroupAdmin(groupID int64, userID int64) bool
+	// GroupTitle returns the title of the group, or its ID if the title is unknown
+	GroupTitle(groupID int64) string
+	// BotUsername is shown by the Telegram Login Widget
+	BotUsername() string
+	// BotToken signs the data of the Telegram Login Widget
+	BotToken() string
+	// Ping checks the connection to the Bot API with getMe
+	Ping() error
+}
@@ -0,0 +1,70 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/auth"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+)
+
+// tenant is a bot served by the web server, its pages and APIs are mounted under its path prefix
+type tenant struct {
+	auth     *auth.Tenant
+	telegram Telegram
+}
+
+// tenantKey is the context key of the tenant of a request
+type tenantKey struct{}
+
+// AddTenant serves the verification pages, the admin dashboard and the admin API of the bot under the path
+// prefix of the tenant and adds the bot to the readiness checks. It must be called before the server runs.
+func AddTenant(authTenant *auth.Tenant, telegram Telegram) {
+	t := &tenant{auth: authTenant, telegram: telegram}
+
+	routes := http.NewServeMux()
+	routes.HandleFunc("GET /api/sign-in/{session}", SignInRequest)
+	routes.HandleFunc("/api/callback", authTenant.Callback)
+	routes.HandleFunc("GET /verify/{session}", VerifyPage)
+	routes.HandleFunc("GET /api/sessions/{id}", SessionJSON)
+	routes.HandleFunc("GET /api/sessions/{id}/events", SessionEvents)
+	registerAdminAPI(routes)
+	registerDashboard(routes)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		routes.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, t)))
+	})
+	if authTenant.PathPrefix == "" {
+		mux.Handle("/", handler)
+	} else {
+		mux.Handle(authTenant.PathPrefix+"/", http.StripPrefix(authTenant.PathPrefix, handler))
+	}
+	logger.Info("Web server routes of the bot registered", "bot", authTenant.Name, "prefix", authTenant.PathPrefix)
+
+	name := "telegram"
+	if authTenant.Name != "" {
+		name += ":" + authTenant.Name
+	}
+	remoteChecks = append(remoteChecks, &healthCheck{name: name, ttl: remoteCheckTTL, run: func(ctx context.Context) error {
+		if telegram == nil {
+			return errors.New("bot is not connected")
+		}
+		return telegram.Ping()
+	}})
+}
+
+// tenantOf returns the bot the request was routed to
+func tenantOf(r *http.Request) *tenant {
+	return r.Context().Value(tenantKey{}).(*tenant)
+}
+
+// store returns the storage namespace of the bot
+func (t *tenant) store() *storage_db.Store {
+	return t.auth.Store
+}
+
+// path returns the absolute path of a route of the bot, e.g. /admin under the prefix
+func (t *tenant) path(route string) string {
+	return t.auth.PathPrefix + route
+}
@@ -0,0 +1,127 @@
+package web
+
+import (
+	"embed"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/auth"
+	"github.com/ArtemHvozdov/tg-auth-bot/i18n"
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+//go:embed templates/*.html
+var templatesFS embed.FS
+
+var verifyTemplate = template.Must(template.ParseFS(templatesFS, "templates/verify.html"))
+
+// Size of the QR code image in pixels
+const qrCodeSize = 512
+
+// statusTexts returns the texts of the session statuses shown on the page
+func statusTexts(lang string) map[string]string {
+	return map[string]string{
+		auth.SessionPending:  i18n.T(lang, "verify_page.pending"),
+		auth.SessionVerified: i18n.T(lang, "verify_page.verified"),
+		auth.SessionFailed:   i18n.T(lang, "verify_page.failed"),
+	}
+}
+
+// verifyPageData is the data of the verification page template
+type verifyPageData struct {
+	Lang         string
+	PageTitle    string
+	Title        string // what the member is asked to prove
+	Description  string
+	Instructions string
+	QRCode       string
+	QRCodeAlt    string
+	DeepLink     string
+	OpenWallet   string
+	Status       string
+	StatusText   string
+	StatusTexts  map[string]string // the page script shows them when the status changes
+	StatusURL    string
+	EventsURL    string
+}
+
+// pageLang returns the language of the verification page. The member sees it in the language the bot talks to them in,
+// the browser's language is used when the bot doesn't know theirs.
+func pageLang(r *http.Request, store *storage_db.Store, session auth.Session) string {
+	if user, err := store.GetUser(session.UserID); err == nil {
+		if lang := i18n.Match(user.LanguageCode); lang != "" {
+			return lang
+		}
+	}
+	if lang := store.GetGroupLanguage(session.GroupID); lang != "" {
+		return lang
+	}
+	return browserLang(r)
+}
+
+// browserLang returns the first supported language of the Accept-Language header, or i18n.Default
+func browserLang(r *http.Request) string {
+	for _, tag := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, _, _ = strings.Cut(strings.TrimSpace(tag), ";")
+		if lang := i18n.Match(tag); lang != "" {
+			return lang
+		}
+	}
+	return i18n.Default
+}
+
+// VerifyPage renders the QR code and the wallet link of a verification session
+func VerifyPage(w http.ResponseWriter, r *http.Request) {
+	t := tenantOf(r)
+	sessionID := r.PathValue("session")
+
+	session, ok := t.auth.GetSession(sessionID)
+	if !ok {
+		http.Error(w, i18n.T(browserLang(r), "verify_page.not_found"), http.StatusNotFound)
+		return
+	}
+	store := t.store()
+	lang := pageLang(r, store, session)
+
+	// The QR code only holds the link to the request, so it stays small and easy to scan
+	png, err := qrcode.Encode(t.auth.QRCodePayload(sessionID), qrcode.Medium, qrCodeSize)
+	if err != nil {
+		requestLogger(r).Error("Error generating QR code", "session_id", sessionID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	texts := statusTexts(lang)
+	data := verifyPageData{
+		Lang:         lang,
+		PageTitle:    i18n.T(lang, "verify_page.title"),
+		Title:        i18n.T(lang, "verify_page.title"),
+		Instructions: i18n.T(lang, "verify_page.instructions"),
+		QRCode:       base64.StdEncoding.EncodeToString(png),
+		QRCodeAlt:    i18n.T(lang, "verify_page.qr_alt"),
+		DeepLink:     t.auth.WalletDeepLink(sessionID),
+		OpenWallet:   i18n.T(lang, "verify_page.open_wallet"),
+		Status:       session.Status,
+		StatusText:   texts[session.Status],
+		StatusTexts:  texts,
+		StatusURL:    t.path("/api/sessions/" + url.PathEscape(sessionID)),
+		EventsURL:    t.path("/api/sessions/" + url.PathEscape(sessionID) + "/events"),
+	}
+
+	// Show what the member is asked to prove
+	if params, err := store.GetActiveVerificationParams(session.GroupID); err == nil {
+		data.Title = params.DisplayName()
+		data.Description = params.Description
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := verifyTemplate.Execute(w, data); err != nil {
+		requestLogger(r).Warn("Error rendering page", "session_id", sessionID, "error", err)
+	}
+}
@@ -1,18 +1,101 @@
 package web
 
 import (
-	"log"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
 	"net/http"
-	"github.com/ArtemHvozdov/tg-auth-bot/auth"
+	"strings"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/config"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// StartServer run webserver
-func StartServer() {
-	//http.HandleFunc("/api/sign-in", auth.GetAuthRequest)
-	http.HandleFunc("/api/callback", auth.Callback)
-	http.HandleFunc("/home", auth.Home)
-	log.Println("Web server started on :8080")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-        log.Fatal(err)
-    }
+// mux holds all routes of the web server
+var mux = http.NewServeMux()
+
+// logger is replaced by main with SetLogger
+var logger = slog.Default()
+
+// SetLogger sets the logger of the web server
+func SetLogger(l *slog.Logger) {
+	logger = l.With("component", "web")
+}
+
+// Handle registers an extra handler on the web server, e.g. the Telegram webhook
+func Handle(pattern string, handler http.Handler) {
+	mux.Handle(pattern, handler)
+	logger.Info("Web server route registered", "pattern", pattern)
+}
+
+// Server is the single HTTP server of the application
+type Server struct {
+	httpServer *http.Server
+	cfg        config.HTTPConfig
+}
+
+// NewServer creates the web server with the probes and the metrics, AddTenant adds the routes of the bots
+func NewServer(cfg config.HTTPConfig) *Server {
+	registerHealth()
+	if cfg.MetricsToken != "" {
+		mux.Handle("GET /metrics", requireMetricsToken(cfg.MetricsToken, promhttp.Handler()))
+	} else {
+		logger.Warn("Metrics are not served, METRICS_TOKEN is not set")
+	}
+
+	return &Server{
+		httpServer: &http.Server{
+			Addr:         cfg.Addr,
+			Handler:      withRequestLog(mux),
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			IdleTimeout:  cfg.IdleTimeout,
+		},
+		cfg: cfg,
+	}
+}
+
+// requireMetricsToken lets through the requests with the "Authorization: Bearer <token>" header,
+// the metrics are on the public server and reveal the groups and the load of the bots
+func requireMetricsToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(key), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Handler returns the handler with all routes of the server
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
+// Run serves requests until the server is shut down
+func (s *Server) Run() error {
+	var err error
+	if s.cfg.TLSCertFile != "" {
+		logger.Info("Web server started with TLS", "addr", s.cfg.Addr)
+		err = s.httpServer.ListenAndServeTLS(s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+	} else {
+		logger.Info("Web server started", "addr", s.cfg.Addr)
+		err = s.httpServer.ListenAndServe()
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests, such as callbacks, to finish
+func (s *Server) Shutdown(ctx context.Context) error {
+	logger.Info("Web server shutting down")
+	return s.httpServer.Shutdown(ctx)
 }
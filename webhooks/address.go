@@ -0,0 +1,66 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+// ErrForbiddenAddress is returned for webhook URLs that point into the network of the bot
+var ErrForbiddenAddress = errors.New("address is not public")
+
+// allowedIP reports whether deliveries may connect to the address, tests replace it to reach their local receivers
+var allowedIP = publicIP
+
+// publicIP rejects the loopback, private, link-local, multicast and unspecified addresses,
+// a webhook must not make the bot reach services that are only visible from its host
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified()
+}
+
+// CheckURL resolves the host of the webhook URL and checks that all of its addresses are public.
+// Deliveries check the address they connect to again, the host may resolve differently later.
+func CheckURL(ctx context.Context, target *url.URL) error {
+	host := target.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !allowedIP(ip) {
+			return fmt.Errorf("%s: %w", host, ErrForbiddenAddress)
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+
+	for _, addr := range addrs {
+		if !allowedIP(addr.IP) {
+			return fmt.Errorf("%s resolves to %s: %w", host, addr.IP, ErrForbiddenAddress)
+		}
+	}
+	return nil
+}
+
+// checkDial is the Control of the dialer of the deliveries, it runs for the resolved address right before connecting
+func checkDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !allowedIP(ip) {
+		return fmt.Errorf("%s: %w", host, ErrForbiddenAddress)
+	}
+	return nil
+}
@@ -0,0 +1,383 @@
+// Package webhooks delivers verification events of a group to its outgoing webhook subscriptions.
+// The Telegram webhook of the bot itself lives in the bot package.
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+)
+
+// Event types
+const (
+	EventMemberJoined          = "member.joined"
+	EventJoinRequested         = "member.join_requested"
+	EventVerificationSucceeded = "verification.succeeded"
+	EventVerificationFailed    = "verification.failed"
+	EventMemberTimedOut        = "member.timeout_removed"
+	EventVerificationRevoked   = "verification.revoked"
+)
+
+// EventTypes lists the event types a subscription can choose from
+var EventTypes = []string{
+	EventMemberJoined,
+	EventJoinRequested,
+	EventVerificationSucceeded,
+	EventVerificationFailed,
+	EventMemberTimedOut,
+	EventVerificationRevoked,
+}
+
+const (
+	// Number of deliveries running at the same time
+	workers = 4
+	// Deliveries waiting for a worker, more are dead-lettered right away
+	queueSize = 256
+	// Timeout of one delivery attempt
+	requestTimeout = 10 * time.Second
+)
+
+// retryDelays are the pauses before the attempts after the first one
+var retryDelays = []time.Duration{time.Second, 5 * time.Second, 30 * time.Second, 2 * time.Minute}
+
+// Event is the JSON payload posted to the subscribers
+type Event struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	GroupID   int64                  `json:"groupId"`
+	UserID    int64                  `json:"userId"`
+	Username  string                 `json:"username,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+// delivery is one event for one subscription
+type delivery struct {
+	store        *storage_db.Store // namespace of the bot the group belongs to
+	groupID      int64
+	subscription storage_db.WebhookSubscription
+	eventID      string
+	eventType    string
+	payload      []byte
+}
+
+var (
+	queue = make(chan delivery, queueSize)
+	quit  = make(chan struct{})
+	wg    sync.WaitGroup
+
+	// stateMutex guards sending to the queue against closing it on stop
+	stateMutex sync.RWMutex
+	stopped    bool
+
+	client = &http.Client{Timeout: requestTimeout, Transport: newTransport()}
+
+	// logger is replaced by main with SetLogger
+	logger = slog.Default()
+)
+
+// newTransport returns the transport of the deliveries, it connects only to public addresses.
+// A proxy would connect to the receiver on our behalf, so none is used.
+func newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   requestTimeout,
+		KeepAlive: 30 * time.Second,
+		Control:   checkDial,
+	}).DialContext
+	return transport
+}
+
+// statusError is a delivery the receiver didn't accept
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return "receiver responded with " + e.status
+}
+
+// errorClass returns what went wrong with the delivery without the details of the network of the bot,
+// the dead letters are shown to the group admins
+func errorClass(err error) string {
+	var statusErr *statusError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &statusErr):
+		return "status " + strconv.Itoa(statusErr.code)
+	case errors.Is(err, ErrForbiddenAddress):
+		return "forbidden address"
+	case errors.As(err, &dnsErr):
+		return "dns error"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "connection error"
+	}
+}
+
+// SetLogger sets the logger of the webhook deliveries
+func SetLogger(l *slog.Logger) {
+	logger = l.With("component", "webhooks")
+}
+
+// IsEventType reports whether the event type is known
+func IsEventType(eventType string) bool {
+	return slices.Contains(EventTypes, eventType)
+}
+
+// Start launches the delivery workers and returns the function that stops them.
+// Deliveries that are still waiting or retrying on stop are dead-lettered, so the database must be open until it returns.
+func Start() func() {
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go worker()
+	}
+	logger.Info("Delivery workers started", "workers", workers)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			stateMutex.Lock()
+			stopped = true
+			close(quit)
+			close(queue)
+			stateMutex.Unlock()
+
+			wg.Wait()
+			logger.Info("Delivery workers stopped")
+		})
+	}
+}
+
+// Publish sends the event to the subscriptions of its group in the store that want it, it doesn't wait for the delivery
+func Publish(store *storage_db.Store, event Event) {
+	subscriptions, err := store.GetWebhookSubscriptions(event.GroupID)
+	if err != nil {
+		logger.Error("Error getting subscriptions", "group_id", event.GroupID, "error", err)
+		return
+	}
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("Error encoding event", "group_id", event.GroupID, "event_type", event.Type, "error", err)
+		return
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Wants(event.Type) {
+			continue
+		}
+
+		enqueue(delivery{
+			store:        store,
+			groupID:      event.GroupID,
+			subscription: subscription,
+			eventID:      event.ID,
+			eventType:    event.Type,
+			payload:      payload,
+		})
+	}
+}
+
+// Redeliver takes a dead letter of the group and queues it again for its subscription
+func Redeliver(store *storage_db.Store, groupID int64, deadLetterID uint64) error {
+	subscriptions, err := store.GetWebhookSubscriptions(groupID)
+	if err != nil {
+		return err
+	}
+
+	deadLetter, err := store.TakeDeadLetter(groupID, deadLetterID)
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		if subscription.ID == deadLetter.SubscriptionID {
+			enqueue(delivery{
+				store:        store,
+				groupID:      groupID,
+				subscription: subscription,
+				eventID:      deadLetter.EventID,
+				eventType:    deadLetter.EventType,
+				payload:      deadLetter.Payload,
+			})
+			return nil
+		}
+	}
+
+	// Keep the dead letter, there is nobody to deliver it to
+	if err := store.AddDeadLetter(deadLetter); err != nil {
+		logger.Error("Error restoring dead letter", "group_id", groupID, "dead_letter_id", deadLetterID, "error", err)
+	}
+	return fmt.Errorf("webhook subscription %s %w", deadLetter.SubscriptionID, storage_db.ErrNotFound)
+}
+
+// enqueue hands the delivery to the workers without blocking the caller
+func enqueue(d delivery) {
+	stateMutex.RLock()
+	defer stateMutex.RUnlock()
+
+	if stopped {
+		deadLetter(d, 0, "webhooks are stopped")
+		return
+	}
+
+	select {
+	case queue <- d:
+	default:
+		deadLetter(d, 0, "delivery queue is full")
+	}
+}
+
+// worker delivers the queued events until Start's stop function is called
+func worker() {
+	defer wg.Done()
+
+	for d := range queue {
+		// On shutdown the waiting deliveries are kept for a redelivery instead of delaying the exit
+		select {
+		case <-quit:
+			deadLetter(d, 0, "shutdown")
+			continue
+		default:
+		}
+
+		deliverWithRetries(d)
+	}
+}
+
+// deliverWithRetries posts the event until it is accepted, retries are given up on shutdown
+func deliverWithRetries(d delivery) {
+	attempts := 0
+
+	for {
+		attempts++
+		retry, err := deliver(d)
+		if err == nil {
+			return
+		}
+
+		// The details of the error are only logged, the dead letter keeps its class
+		deliveryLogger(d).Warn("Delivery attempt failed", "attempt", attempts, "error", err)
+
+		if !retry || attempts > len(retryDelays) {
+			deadLetter(d, attempts, errorClass(err))
+			return
+		}
+
+		select {
+		case <-time.After(retryDelays[attempts-1]):
+		case <-quit:
+			deadLetter(d, attempts, "shutdown after: "+errorClass(err))
+			return
+		}
+	}
+}
+
+// deliver makes one attempt, it reports whether a failed attempt is worth repeating
+func deliver(d delivery) (bool, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, d.subscription.URL, bytes.NewReader(d.payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "tg-auth-bot-webhooks")
+	req.Header.Set("X-Webhook-Event", d.eventType)
+	req.Header.Set("X-Webhook-ID", d.eventID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(d.subscription.Secret, timestamp, d.payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	// Other client errors won't go away by themselves
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+	return retry, &statusError{code: resp.StatusCode, status: resp.Status}
+}
+
+// deliveryLogger returns the logger with the IDs of the delivery
+func deliveryLogger(d delivery) *slog.Logger {
+	return logger.With(
+		"bot", d.store.Namespace(),
+		"group_id", d.groupID,
+		"event_id", d.eventID,
+		"event_type", d.eventType,
+		"subscription_id", d.subscription.ID,
+		"url", d.subscription.URL,
+	)
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<payload>" that receivers compare with X-Webhook-Signature
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deadLetter stores the delivery that could not be made
+func deadLetter(d delivery, attempts int, reason string) {
+	deliveryLogger(d).Error("Giving up the delivery", "attempts", attempts, "reason", reason)
+
+	err := d.store.AddDeadLetter(storage_db.DeadLetter{
+		GroupID:        d.groupID,
+		SubscriptionID: d.subscription.ID,
+		URL:            d.subscription.URL,
+		EventID:        d.eventID,
+		EventType:      d.eventType,
+		Payload:        d.payload,
+		Attempts:       attempts,
+		LastError:      reason,
+		FailedAt:       time.Now(),
+	})
+	if err != nil {
+		deliveryLogger(d).Error("Error storing dead letter", "error", err)
+	}
+}
+
+// newEventID returns a random event ID, receivers use it to drop duplicates
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,224 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ArtemHvozdov/tg-auth-bot/storage_db"
+)
+
+// store is opened in a temporary database, the tests use their own groups in it
+var store *storage_db.Store
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "webhooks")
+	if err != nil {
+		panic(err)
+	}
+
+	if err := storage_db.InitDB(filepath.Join(dir, "test.db")); err != nil {
+		panic(err)
+	}
+	if store, err = storage_db.Open("test"); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	storage_db.CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// allowLocal lets the deliveries reach the httptest receivers and shortens the retry delays
+func allowLocal(t *testing.T) {
+	t.Helper()
+
+	oldAllowed, oldDelays := allowedIP, retryDelays
+	allowedIP = func(net.IP) bool { return true }
+	retryDelays = []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}
+	t.Cleanup(func() {
+		allowedIP, retryDelays = oldAllowed, oldDelays
+	})
+}
+
+// receiver answers the deliveries with the given status codes, the last one is repeated
+type receiver struct {
+	mutex    sync.Mutex
+	statuses []int
+	attempts []time.Time
+	headers  http.Header
+	body     []byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	rc.attempts = append(rc.attempts, time.Now())
+	rc.headers = r.Header.Clone()
+	rc.body = body
+
+	status := rc.statuses[min(len(rc.attempts), len(rc.statuses))-1]
+	w.WriteHeader(status)
+}
+
+func testDelivery(groupID int64, targetURL string) delivery {
+	return delivery{
+		store:   store,
+		groupID: groupID,
+		subscription: storage_db.WebhookSubscription{
+			ID:     "sub",
+			URL:    targetURL,
+			Secret: "secret",
+		},
+		eventID:   "event",
+		eventType: EventMemberJoined,
+		payload:   []byte(`{"id":"event","type":"member.joined"}`),
+	}
+}
+
+func TestDeliverSignsRequest(t *testing.T) {
+	allowLocal(t)
+	rc := &receiver{statuses: []int{http.StatusOK}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	d := testDelivery(-1001, server.URL)
+	if _, err := deliver(d); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+
+	timestamp := rc.headers.Get("X-Webhook-Timestamp")
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(timestamp + "." + string(d.payload)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := rc.headers.Get("X-Webhook-Signature"); got != want {
+		t.Errorf("X-Webhook-Signature = %q, want %q", got, want)
+	}
+	if got := rc.headers.Get("X-Webhook-Event"); got != EventMemberJoined {
+		t.Errorf("X-Webhook-Event = %q, want %q", got, EventMemberJoined)
+	}
+	if got := rc.headers.Get("X-Webhook-ID"); got != "event" {
+		t.Errorf("X-Webhook-ID = %q, want %q", got, "event")
+	}
+	if string(rc.body) != string(d.payload) {
+		t.Errorf("body = %s, want %s", rc.body, d.payload)
+	}
+}
+
+func TestDeliverWithRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantAttempts int
+		wantError    string // lastError of the dead letter, none if empty
+	}{
+		{"accepted", []int{http.StatusOK}, 1, ""},
+		{"retried until accepted", []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}, 3, ""},
+		{"dead-lettered after the last attempt", []int{http.StatusInternalServerError}, 4, "status 500"},
+		{"client error is not retried", []int{http.StatusBadRequest}, 1, "status 400"},
+	}
+
+	for i, tt := range tests {
+		groupID := int64(-2000 - i)
+		t.Run(tt.name, func(t *testing.T) {
+			allowLocal(t)
+			rc := &receiver{statuses: tt.statuses}
+			server := httptest.NewServer(rc)
+			defer server.Close()
+
+			deliverWithRetries(testDelivery(groupID, server.URL))
+
+			if len(rc.attempts) != tt.wantAttempts {
+				t.Fatalf("attempts = %d, want %d", len(rc.attempts), tt.wantAttempts)
+			}
+			for i := 1; i < len(rc.attempts); i++ {
+				if gap := rc.attempts[i].Sub(rc.attempts[i-1]); gap < retryDelays[i-1] {
+					t.Errorf("pause before attempt %d = %v, want at least %v", i+1, gap, retryDelays[i-1])
+				}
+			}
+
+			deadLetters, err := store.GetDeadLetters(groupID)
+			if err != nil {
+				t.Fatalf("GetDeadLetters: %v", err)
+			}
+			if tt.wantError == "" {
+				if len(deadLetters) != 0 {
+					t.Fatalf("dead letters = %+v, want none", deadLetters)
+				}
+				return
+			}
+
+			if len(deadLetters) != 1 {
+				t.Fatalf("dead letters = %d, want 1", len(deadLetters))
+			}
+			deadLetter := deadLetters[0]
+			if deadLetter.Attempts != tt.wantAttempts || deadLetter.LastError != tt.wantError {
+				t.Errorf("dead letter attempts = %d, lastError = %q, want %d, %q",
+					deadLetter.Attempts, deadLetter.LastError, tt.wantAttempts, tt.wantError)
+			}
+			if deadLetter.EventID != "event" || deadLetter.SubscriptionID != "sub" {
+				t.Errorf("dead letter event = %q, subscription = %q", deadLetter.EventID, deadLetter.SubscriptionID)
+			}
+		})
+	}
+}
+
+func TestDeliverRefusesLocalAddress(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusOK}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	_, err := deliver(testDelivery(-1003, server.URL))
+	if got := errorClass(err); got != "forbidden address" {
+		t.Errorf("error class = %q (%v), want %q", got, err, "forbidden address")
+	}
+	if len(rc.attempts) != 0 {
+		t.Errorf("receiver got %d requests, want none", len(rc.attempts))
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://8.8.8.8/hook", true},
+		{"https://[2001:4860:4860::8888]/hook", true},
+		{"http://127.0.0.1:8080/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://10.0.0.5/hook", false},
+		{"http://192.168.1.1/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://0.0.0.0/hook", false},
+		{"http://[::ffff:127.0.0.1]/hook", false},
+		{"http://localhost/hook", false},
+	}
+
+	for _, tt := range tests {
+		target, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatalf("url.Parse(%q): %v", tt.url, err)
+		}
+
+		err = CheckURL(context.Background(), target)
+		if (err == nil) != tt.allowed {
+			t.Errorf("CheckURL(%q) = %v, want allowed %v", tt.url, err, tt.allowed)
+		}
+	}
+}